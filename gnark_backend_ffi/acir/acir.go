@@ -6,16 +6,52 @@ import (
 	"log"
 
 	common "gnark_backend_ffi/internal"
+
+	"github.com/consensys/gnark-crypto/ecc"
 )
 
 type ACIR struct {
 	CurrentWitness common.Witness
 	Opcodes        []opcode.Opcode
 	PublicInputs   common.Witnesses
+	// AssertMessages and DebugInfo are optional, they are only used to
+	// trace failing constraints back to the Noir source.
+	AssertMessages []AssertMessage
+	DebugInfo      *DebugInfo
+	// Curve is the curve the circuit is proven on, its felts belong to its
+	// scalar field. Noir doesn't serialize it, it is BN254 unless set.
+	Curve ecc.ID
+}
+
+// CurveID returns the curve of the circuit, BN254 if Curve isn't set.
+func (a ACIR) CurveID() ecc.ID {
+	if a.Curve == ecc.UNKNOWN {
+		return ecc.BN254
+	}
+	return a.Curve
+}
+
+func (a ACIR) MarshalJSON() ([]byte, error) {
+	opcodes := a.Opcodes
+	if opcodes == nil {
+		opcodes = []opcode.Opcode{}
+	}
+	publicInputs := a.PublicInputs
+	if publicInputs == nil {
+		publicInputs = common.Witnesses{}
+	}
+
+	return json.Marshal(struct {
+		CurrentWitness common.Witness   `json:"current_witness_index"`
+		Opcodes        []opcode.Opcode  `json:"opcodes"`
+		PublicInputs   common.Witnesses `json:"public_inputs"`
+		AssertMessages []AssertMessage  `json:"assert_messages,omitempty"`
+		DebugInfo      *DebugInfo       `json:"debug_symbols,omitempty"`
+	}{a.CurrentWitness, opcodes, publicInputs, a.AssertMessages, a.DebugInfo})
 }
 
 func (a *ACIR) UnmarshalJSON(data []byte) error {
-	var acirMap map[string]interface{}
+	var acirMap map[string]json.RawMessage
 	err := json.Unmarshal(data, &acirMap)
 	if err != nil {
 		log.Print(err)
@@ -25,51 +61,66 @@ func (a *ACIR) UnmarshalJSON(data []byte) error {
 	var opcodes []opcode.Opcode
 	var publicInputs common.Witnesses
 	var currentWitness uint32
+	var assertMessages []AssertMessage
+	var debugInfo *DebugInfo
 
-	if opcodesValue, ok := acirMap["opcodes"].([]interface{}); ok {
-		opcodesJSON, err := json.Marshal(opcodesValue)
+	// Opcodes are kept raw so Directives preserve their original layout.
+	if opcodesValue, ok := acirMap["opcodes"]; ok {
+		err = json.Unmarshal(opcodesValue, &opcodes)
 		if err != nil {
 			log.Print(err)
 			return err
 		}
+	} else {
+		log.Print("Error: couldn't deserialize opcodes.")
+		return &json.UnmarshalTypeError{}
+	}
 
-		err = json.Unmarshal(opcodesJSON, &opcodes)
-
+	if publicInputsValue, ok := acirMap["public_inputs"]; ok {
+		err = json.Unmarshal(publicInputsValue, &publicInputs)
 		if err != nil {
 			log.Print(err)
 			return err
 		}
 	} else {
-		log.Print("Error: couldn't deserialize opcodes.")
+		log.Print("Error: couldn't deserialize public inputs.")
 		return &json.UnmarshalTypeError{}
 	}
 
-	if publicInputsValue, ok := acirMap["public_inputs"].([]interface{}); ok {
-		publicInputsJSON, err := json.Marshal(publicInputsValue)
+	if currentWitnessValue, ok := acirMap["current_witness_index"]; ok {
+		err = json.Unmarshal(currentWitnessValue, &currentWitness)
 		if err != nil {
 			log.Print(err)
 			return err
 		}
-		err = json.Unmarshal(publicInputsJSON, &publicInputs)
+	} else {
+		log.Print("Error: couldn't deserialize current witness.")
+		return &json.UnmarshalTypeError{}
+	}
+
+	// Only newer versions of Noir emit assert messages, and debug symbols
+	// are only here if the caller attached them.
+	if assertMessagesValue, ok := acirMap["assert_messages"]; ok {
+		err = json.Unmarshal(assertMessagesValue, &assertMessages)
 		if err != nil {
 			log.Print(err)
 			return err
 		}
-	} else {
-		log.Print("Error: couldn't deserialize public inputs.")
-		return &json.UnmarshalTypeError{}
 	}
 
-	if currentWitnessValue, ok := acirMap["current_witness_index"].(float64); ok {
-		currentWitness = uint32(currentWitnessValue)
-	} else {
-		log.Print("Error: couldn't deserialize current witness.")
-		return &json.UnmarshalTypeError{}
+	if debugInfoValue, ok := acirMap["debug_symbols"]; ok {
+		err = json.Unmarshal(debugInfoValue, &debugInfo)
+		if err != nil {
+			log.Print(err)
+			return err
+		}
 	}
 
 	a.CurrentWitness = currentWitness
 	a.Opcodes = opcodes
 	a.PublicInputs = publicInputs
+	a.AssertMessages = assertMessages
+	a.DebugInfo = debugInfo
 
 	return nil
 }
@@ -10,23 +10,24 @@ import (
 	common "gnark_backend_ffi/internal"
 	backend_helpers "gnark_backend_ffi/internal/backend"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/stretchr/testify/assert"
 )
 
 // TODO: Test error cases.
 
 func TestACIRUnmarshalJSON(t *testing.T) {
-	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	multiplicand := rand.Uint32()
 	multiplier := rand.Uint32()
 	sum := rand.Uint32()
-	mulTerms := fmt.Sprintf(`[{"coefficient":"%s","multiplicand":%d,"multiplier":%d},{"coefficient":"%s","multiplicand":%d,"multiplier":%d}]`, encodedCoefficient, multiplicand, multiplier, encodedCoefficient, multiplicand, multiplier)
-	addTerms := fmt.Sprintf(`[{"coefficient":"%s","sum":%d},{"coefficient":"%s","sum":%d}]`, encodedCoefficient, sum, encodedCoefficient, sum)
-	encodedConstantTerm, _ := backend_helpers.RandomEncodedFelt()
+	mulTerms := fmt.Sprintf(`[["%s",%d,%d],["%s",%d,%d]]`, encodedCoefficient, multiplicand, multiplier, encodedCoefficient, multiplicand, multiplier)
+	addTerms := fmt.Sprintf(`[["%s",%d],["%s",%d]]`, encodedCoefficient, sum, encodedCoefficient, sum)
+	encodedConstantTerm, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	arithmetic_opcode := fmt.Sprintf(`{"Arithmetic": {"mul_terms":%s,"linear_combinations":%s,"q_c":"%s"}}`, mulTerms, addTerms, encodedConstantTerm)
 	x := rand.Uint32()
 	result := rand.Uint32()
-	invertDirective := fmt.Sprintf(`{"Invert": {"x":%d,"result":%d}}`, x, result)
+	invertDirective := fmt.Sprintf(`{"Directive": {"Invert": {"x":%d,"result":%d}}}`, x, result)
 	opcodes := fmt.Sprintf(`[%s,%s]`, arithmetic_opcode, invertDirective)
 	publicInputs := fmt.Sprintf("[%d,%d,%d]", multiplicand, multiplier, sum)
 	currentWitness := uint32(1)
@@ -39,3 +40,14 @@ func TestACIRUnmarshalJSON(t *testing.T) {
 	assert.Equal(t, opcode.UncheckedDeserializeOpcodes(opcodes), a.Opcodes)
 	assert.Equal(t, common.Witnesses{multiplicand, multiplier, sum}, a.PublicInputs)
 }
+
+func TestACIRMarshalJSON(t *testing.T) {
+	var a ACIR
+	err := json.Unmarshal([]byte(circuitJSON), &a)
+	assert.NoError(t, err)
+
+	serializedACIR, err := json.Marshal(a)
+
+	assert.NoError(t, err)
+	assert.Equal(t, circuitJSON, string(serializedACIR))
+}
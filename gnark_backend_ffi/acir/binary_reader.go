@@ -0,0 +1,745 @@
+package acir
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"gnark_backend_ffi/acir/opcode"
+	"gnark_backend_ffi/acir/term"
+	backend_helpers "gnark_backend_ffi/internal/backend"
+	"gnark_backend_ffi/internal/felt"
+)
+
+// binaryReader abstracts the binary serializations of ACIR. Both of them
+// follow the serde data model of the Rust types so the layout of the circuit
+// is described once below and each format only knows how to read primitives.
+type binaryReader interface {
+	// structBegin reads the header of a struct with the given fields.
+	structBegin(fields []string) error
+	// optionalStructBegin reads the header of a struct whose fields after
+	// the first required ones may be missing, like the circuit.
+	optionalStructBegin(fields []string, required int) error
+	// field reads the key of a struct field if the format has one.
+	field(name string) error
+	// optionalField reads the key of a field of the struct begun with
+	// optionalStructBegin and reports whether the struct has it.
+	optionalField(name string) (bool, error)
+	// variant reads the tag of an enum and returns its index in variants.
+	variant(variants []string, unit bool) (int, error)
+	seqLen() (int, error)
+	mapLen() (int, error)
+	option() (bool, error)
+	u32() (uint32, error)
+	// u64 reads a u64 or a usize, which serde serializes as a u64.
+	u64() (uint64, error)
+	boolean() (bool, error)
+	str() (string, error)
+	// felt reads a field element. It returns its hex representation just like
+	// the JSON serialization does.
+	felt() (string, error)
+	// scalarField is the field of the felts, the scalar field of the curve
+	// the circuit is decoded for.
+	scalarField() *big.Int
+	// newtype reads the header of a newtype struct if the format has one.
+	newtype() error
+	// witness reads the Witness(u32) newtype.
+	witness() (uint32, error)
+	done() error
+}
+
+var (
+	// Assert messages and debug symbols are optional.
+	circuitFields          = []string{"current_witness_index", "opcodes", "public_inputs", "assert_messages", "debug_symbols"}
+	expressionFields       = []string{"mul_terms", "linear_combinations", "q_c"}
+	blackBoxFuncCallFields = []string{"name", "inputs", "outputs"}
+	functionInputFields    = []string{"witness", "num_bits"}
+	memoryBlockFields      = []string{"id", "len", "trace"}
+	memoryOperationFields  = []string{"operation", "index", "value"}
+	memoryOpFields         = []string{"block_id", "op", "predicate"}
+	memoryInitFields       = []string{"block_id", "init"}
+	// opcodeVariants are in the order of Noir's Opcode enum, which bincode
+	// tags them with. MemoryOp and MemoryInit come after the opcodes the
+	// versions of Noir that introduced them removed.
+	opcodeVariants           = []string{"Arithmetic", "BlackBoxFuncCall", "Directive", "Block", "ROM", "RAM", "Oracle", "Brillig", "MemoryOp", "MemoryInit"}
+	blackBoxFunctionVariants = []string{
+		"AES",
+		"AND",
+		"XOR",
+		"RANGE",
+		"SHA256",
+		"Blake2s",
+		"MerkleMembership",
+		"SchnorrVerify",
+		"Pedersen",
+		"HashToField128Security",
+		"EcdsaSecp256k1",
+		"FixedBaseScalarMul",
+		"Keccak256",
+	}
+	directiveVariants = []string{"Invert", "Quotient", "Truncate", "OddRange", "ToRadix", "PermutationSort", "Log"}
+	directiveFields   = map[string][]string{
+		"Invert":          {"x", "result"},
+		"Quotient":        {"a", "b", "q", "r", "predicate"},
+		"Truncate":        {"a", "b", "c", "bit_size"},
+		"OddRange":        {"a", "b", "r", "bit_size"},
+		"ToRadix":         {"a", "b", "radix", "is_little_endian"},
+		"PermutationSort": {"inputs", "tuple", "bits", "sort_by"},
+	}
+	logInfoVariants = []string{"FinalizedOutput", "WitnessOutput"}
+	// Brillig locations are structs, so OpcodeLocation isn't a unit enum.
+	opcodeLocationVariants = []string{"Acir", "Brillig"}
+	brilligLocationFields  = []string{"acir_index", "brillig_index"}
+	debugInfoFields        = []string{"locations"}
+	debugLocationFields    = []string{"span", "file"}
+	spanFields             = []string{"start", "end"}
+)
+
+// object is a JSON object that keeps its fields in the order of the Rust
+// struct, as serde_json does. Directives are kept as such JSON.
+type object struct {
+	keys   []string
+	values []interface{}
+}
+
+func newObject(keys []string, values ...interface{}) *object {
+	return &object{keys: keys, values: values}
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		encodedValue, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func readCircuit(r binaryReader) (circuit ACIR, err error) {
+	if err = r.optionalStructBegin(circuitFields, 3); err != nil {
+		return
+	}
+
+	if err = r.field("current_witness_index"); err != nil {
+		return
+	}
+	if circuit.CurrentWitness, err = r.u32(); err != nil {
+		return
+	}
+
+	if err = r.field("opcodes"); err != nil {
+		return
+	}
+	nbOpcodes, err := r.seqLen()
+	if err != nil {
+		return
+	}
+	circuit.Opcodes = make([]opcode.Opcode, 0, nbOpcodes)
+	for i := 0; i < nbOpcodes; i++ {
+		op, err := readOpcode(r)
+		if err != nil {
+			return ACIR{}, fmt.Errorf("opcode %d: %w", i, err)
+		}
+		circuit.Opcodes = append(circuit.Opcodes, op)
+	}
+
+	if err = r.field("public_inputs"); err != nil {
+		return
+	}
+	if circuit.PublicInputs, err = readWitnesses(r); err != nil {
+		return
+	}
+
+	hasAssertMessages, err := r.optionalField("assert_messages")
+	if err != nil || !hasAssertMessages {
+		if err == nil {
+			err = r.done()
+		}
+		return
+	}
+	if circuit.AssertMessages, err = readAssertMessages(r); err != nil {
+		return
+	}
+
+	hasDebugSymbols, err := r.optionalField("debug_symbols")
+	if err != nil || !hasDebugSymbols {
+		if err == nil {
+			err = r.done()
+		}
+		return
+	}
+	hasDebugInfo, err := r.option()
+	if err != nil {
+		return
+	}
+	if hasDebugInfo {
+		if circuit.DebugInfo, err = readDebugInfo(r); err != nil {
+			return
+		}
+	}
+
+	err = r.done()
+	return
+}
+
+// readAssertMessages reads the (location, message) tuples of the circuit.
+func readAssertMessages(r binaryReader) ([]AssertMessage, error) {
+	length, err := r.seqLen()
+	if err != nil {
+		return nil, err
+	}
+	assertMessages := make([]AssertMessage, 0, length)
+	for i := 0; i < length; i++ {
+		var assertMessage AssertMessage
+		if err := r.structBegin(make([]string, 2)); err != nil {
+			return nil, err
+		}
+		if assertMessage.Location, err = readOpcodeLocation(r); err != nil {
+			return nil, err
+		}
+		if assertMessage.Message, err = r.str(); err != nil {
+			return nil, err
+		}
+		assertMessages = append(assertMessages, assertMessage)
+	}
+	return assertMessages, nil
+}
+
+func readOpcodeLocation(r binaryReader) (OpcodeLocation, error) {
+	index, err := r.variant(opcodeLocationVariants, false)
+	if err != nil {
+		return OpcodeLocation{}, err
+	}
+
+	if opcodeLocationVariants[index] == "Acir" {
+		if err := r.newtype(); err != nil {
+			return OpcodeLocation{}, err
+		}
+		acirIndex, err := r.u64()
+		if err != nil {
+			return OpcodeLocation{}, err
+		}
+		return AcirLocation(int(acirIndex)), nil
+	}
+
+	if err := r.structBegin(brilligLocationFields); err != nil {
+		return OpcodeLocation{}, err
+	}
+	var indices [2]uint64
+	for i, field := range brilligLocationFields {
+		if err := r.field(field); err != nil {
+			return OpcodeLocation{}, err
+		}
+		if indices[i], err = r.u64(); err != nil {
+			return OpcodeLocation{}, err
+		}
+	}
+	return OpcodeLocation{AcirIndex: int(indices[0]), BrilligIndex: int(indices[1])}, nil
+}
+
+// readDebugInfo reads the locations of the debug symbols, which are keyed by
+// the string notation of the opcode locations just like in JSON.
+func readDebugInfo(r binaryReader) (*DebugInfo, error) {
+	if err := r.structBegin(debugInfoFields); err != nil {
+		return nil, err
+	}
+	if err := r.field("locations"); err != nil {
+		return nil, err
+	}
+	length, err := r.mapLen()
+	if err != nil {
+		return nil, err
+	}
+
+	debugInfo := DebugInfo{Locations: make(map[OpcodeLocation][]DebugLocation, length)}
+	for i := 0; i < length; i++ {
+		encodedLocation, err := r.str()
+		if err != nil {
+			return nil, err
+		}
+		location, err := ParseOpcodeLocation(encodedLocation)
+		if err != nil {
+			return nil, err
+		}
+		nbDebugLocations, err := r.seqLen()
+		if err != nil {
+			return nil, err
+		}
+		debugLocations := make([]DebugLocation, 0, nbDebugLocations)
+		for j := 0; j < nbDebugLocations; j++ {
+			debugLocation, err := readDebugLocation(r)
+			if err != nil {
+				return nil, err
+			}
+			debugLocations = append(debugLocations, debugLocation)
+		}
+		debugInfo.Locations[location] = debugLocations
+	}
+	return &debugInfo, nil
+}
+
+func readDebugLocation(r binaryReader) (location DebugLocation, err error) {
+	if err = r.structBegin(debugLocationFields); err != nil {
+		return
+	}
+	if err = r.field("span"); err != nil {
+		return
+	}
+	if err = r.structBegin(spanFields); err != nil {
+		return
+	}
+	if err = r.field("start"); err != nil {
+		return
+	}
+	if location.Start, err = r.u32(); err != nil {
+		return
+	}
+	if err = r.field("end"); err != nil {
+		return
+	}
+	if location.End, err = r.u32(); err != nil {
+		return
+	}
+	if err = r.field("file"); err != nil {
+		return
+	}
+	if err = r.newtype(); err != nil {
+		return
+	}
+	file, err := r.u64()
+	if err != nil {
+		return
+	}
+	if file > 0xffffffff {
+		err = fmt.Errorf("file %d overflows u32", file)
+		return
+	}
+	location.File = uint32(file)
+	return
+}
+
+func readOpcode(r binaryReader) (opcode.Opcode, error) {
+	index, err := r.variant(opcodeVariants, false)
+	if err != nil {
+		return opcode.Opcode{}, err
+	}
+
+	var data interface{}
+	switch opcodeVariants[index] {
+	case "Arithmetic":
+		var expression opcode.Expression
+		expression, err = readExpression(r)
+		data = (*opcode.ArithmeticOpcode)(&expression)
+	case "BlackBoxFuncCall":
+		data, err = readBlackBoxFuncCall(r)
+	case "Directive":
+		data, err = readDirective(r)
+	case "Block", "ROM", "RAM":
+		data, err = readMemoryBlock(r, opcodeVariants[index])
+	case "MemoryOp":
+		data, err = readMemoryOp(r)
+	case "MemoryInit":
+		data, err = readMemoryInit(r)
+	case "Oracle":
+		data, err = readOracle(r)
+	case "Brillig":
+		data, err = readBrillig(r)
+	}
+	if err != nil {
+		return opcode.Opcode{}, err
+	}
+
+	return opcode.Opcode{Data: data}, nil
+}
+
+func readExpression(r binaryReader) (expression opcode.Expression, err error) {
+	if err = r.structBegin(expressionFields); err != nil {
+		return
+	}
+
+	if err = r.field("mul_terms"); err != nil {
+		return
+	}
+	nbMulTerms, err := r.seqLen()
+	if err != nil {
+		return
+	}
+	expression.MulTerms = make(term.MulTerms, 0, nbMulTerms)
+	for i := 0; i < nbMulTerms; i++ {
+		var mulTerm term.MulTerm
+		if err = r.structBegin(make([]string, 3)); err != nil {
+			return
+		}
+		if mulTerm.Coefficient, err = readFelt(r); err != nil {
+			return
+		}
+		if mulTerm.MultiplicandIndex, err = r.witness(); err != nil {
+			return
+		}
+		if mulTerm.MultiplierIndex, err = r.witness(); err != nil {
+			return
+		}
+		expression.MulTerms = append(expression.MulTerms, mulTerm)
+	}
+
+	if err = r.field("linear_combinations"); err != nil {
+		return
+	}
+	nbLinearCombinations, err := r.seqLen()
+	if err != nil {
+		return
+	}
+	expression.SimpleTerms = make(term.SimpleTerms, 0, nbLinearCombinations)
+	for i := 0; i < nbLinearCombinations; i++ {
+		var simpleTerm term.SimpleTerm
+		if err = r.structBegin(make([]string, 2)); err != nil {
+			return
+		}
+		if simpleTerm.Coefficient, err = readFelt(r); err != nil {
+			return
+		}
+		if simpleTerm.VariableIndex, err = r.witness(); err != nil {
+			return
+		}
+		expression.SimpleTerms = append(expression.SimpleTerms, simpleTerm)
+	}
+
+	if err = r.field("q_c"); err != nil {
+		return
+	}
+	expression.QC, err = readFelt(r)
+	return
+}
+
+// readFelt reads a field element as the JSON serialization decodes its hex
+// representation.
+func readFelt(r binaryReader) (felt.Element, error) {
+	encoded, err := r.felt()
+	if err != nil {
+		return felt.Element{}, err
+	}
+	return backend_helpers.DeserializeFelt(encoded, r.scalarField())
+}
+
+func readBlackBoxFuncCall(r binaryReader) (*opcode.BlackBoxFunction, error) {
+	if err := r.structBegin(blackBoxFuncCallFields); err != nil {
+		return nil, err
+	}
+
+	if err := r.field("name"); err != nil {
+		return nil, err
+	}
+	index, err := r.variant(blackBoxFunctionVariants, true)
+	if err != nil {
+		return nil, err
+	}
+	name, ok := opcode.BlackBoxFunctionByName(blackBoxFunctionVariants[index])
+	if !ok {
+		return nil, fmt.Errorf("unknown black box function %s", blackBoxFunctionVariants[index])
+	}
+
+	if err := r.field("inputs"); err != nil {
+		return nil, err
+	}
+	nbInputs, err := r.seqLen()
+	if err != nil {
+		return nil, err
+	}
+	inputs := make(opcode.FunctionInputs, 0, nbInputs)
+	for i := 0; i < nbInputs; i++ {
+		var input opcode.FunctionInput
+		if err := r.structBegin(functionInputFields); err != nil {
+			return nil, err
+		}
+		if err := r.field("witness"); err != nil {
+			return nil, err
+		}
+		if input.Witness, err = r.witness(); err != nil {
+			return nil, err
+		}
+		if err := r.field("num_bits"); err != nil {
+			return nil, err
+		}
+		if input.NumBits, err = r.u32(); err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+
+	if err := r.field("outputs"); err != nil {
+		return nil, err
+	}
+	outputs, err := readWitnesses(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &opcode.BlackBoxFunction{Name: name, Inputs: inputs, Outputs: outputs}, nil
+}
+
+// readDirective reads a directive into the raw JSON DirectiveOpcode keeps,
+// the Rust side solves directives so they are never decoded further.
+func readDirective(r binaryReader) (*opcode.DirectiveOpcode, error) {
+	index, err := r.variant(directiveVariants, false)
+	if err != nil {
+		return nil, err
+	}
+	name := directiveVariants[index]
+
+	var directive interface{}
+	if name == "Log" {
+		if directive, err = readLogInfo(r); err != nil {
+			return nil, err
+		}
+	} else {
+		fields := directiveFields[name]
+		if err := r.structBegin(fields); err != nil {
+			return nil, err
+		}
+		directiveObject := newObject(fields)
+		for _, field := range fields {
+			if err := r.field(field); err != nil {
+				return nil, err
+			}
+			value, err := readDirectiveField(r, name, field)
+			if err != nil {
+				return nil, fmt.Errorf("directive %s: %s: %w", name, field, err)
+			}
+			directiveObject.values = append(directiveObject.values, value)
+		}
+		directive = directiveObject
+	}
+
+	encodedDirective, err := json.Marshal(newObject([]string{name}, directive))
+	if err != nil {
+		return nil, err
+	}
+	return &opcode.DirectiveOpcode{Directive: encodedDirective}, nil
+}
+
+func readDirectiveField(r binaryReader, directive string, field string) (interface{}, error) {
+	switch {
+	case field == "bit_size" || field == "radix" || field == "tuple":
+		return r.u32()
+	case field == "is_little_endian":
+		return r.boolean()
+	case field == "predicate":
+		some, err := r.option()
+		if err != nil || !some {
+			return nil, err
+		}
+		return readExpression(r)
+	case field == "a" && directive != "OddRange", field == "b" && directive == "Quotient":
+		return readExpression(r)
+	case field == "b" && directive == "ToRadix", field == "bits":
+		return readWitnesses(r)
+	case field == "sort_by":
+		length, err := r.seqLen()
+		if err != nil {
+			return nil, err
+		}
+		sortBy := make([]uint32, 0, length)
+		for i := 0; i < length; i++ {
+			value, err := r.u32()
+			if err != nil {
+				return nil, err
+			}
+			sortBy = append(sortBy, value)
+		}
+		return sortBy, nil
+	case field == "inputs":
+		nbInputs, err := r.seqLen()
+		if err != nil {
+			return nil, err
+		}
+		inputs := make([]interface{}, 0, nbInputs)
+		for i := 0; i < nbInputs; i++ {
+			length, err := r.seqLen()
+			if err != nil {
+				return nil, err
+			}
+			tuple := make([]interface{}, 0, length)
+			for j := 0; j < length; j++ {
+				expression, err := readExpression(r)
+				if err != nil {
+					return nil, err
+				}
+				tuple = append(tuple, expression)
+			}
+			inputs = append(inputs, tuple)
+		}
+		return inputs, nil
+	default:
+		return r.witness()
+	}
+}
+
+func readLogInfo(r binaryReader) (*object, error) {
+	index, err := r.variant(logInfoVariants, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var logInfo interface{}
+	if logInfoVariants[index] == "FinalizedOutput" {
+		logInfo, err = r.str()
+	} else {
+		logInfo, err = readWitnesses(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newObject([]string{logInfoVariants[index]}, logInfo), nil
+}
+
+func readMemoryOperation(r binaryReader) (operation opcode.MemoryOperation, err error) {
+	if err = r.structBegin(memoryOperationFields); err != nil {
+		return
+	}
+	for _, field := range []struct {
+		name       string
+		expression *opcode.Expression
+	}{{"operation", &operation.Operation}, {"index", &operation.Index}, {"value", &operation.Value}} {
+		if err = r.field(field.name); err != nil {
+			return
+		}
+		if *field.expression, err = readExpression(r); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func readMemoryBlock(r binaryReader, kind string) (*opcode.MemoryBlockOpcode, error) {
+	block := opcode.MemoryBlockOpcode{Kind: kind}
+	if err := r.structBegin(memoryBlockFields); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if err = r.field("id"); err != nil {
+		return nil, err
+	}
+	if block.ID, err = r.witness(); err != nil {
+		return nil, err
+	}
+	if err = r.field("len"); err != nil {
+		return nil, err
+	}
+	if block.Len, err = r.u32(); err != nil {
+		return nil, err
+	}
+	if err = r.field("trace"); err != nil {
+		return nil, err
+	}
+	length, err := r.seqLen()
+	if err != nil {
+		return nil, err
+	}
+	block.Trace = make([]opcode.MemoryOperation, 0, length)
+	for i := 0; i < length; i++ {
+		operation, err := readMemoryOperation(r)
+		if err != nil {
+			return nil, err
+		}
+		block.Trace = append(block.Trace, operation)
+	}
+	if int(block.Len) > len(block.Trace) {
+		return nil, fmt.Errorf("memory block %d has length %d but only %d operations", block.ID, block.Len, len(block.Trace))
+	}
+
+	return &block, nil
+}
+
+func readMemoryOp(r binaryReader) (*opcode.MemoryOpOpcode, error) {
+	var memoryOp opcode.MemoryOpOpcode
+	if err := r.structBegin(memoryOpFields); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if err = r.field("block_id"); err != nil {
+		return nil, err
+	}
+	if memoryOp.BlockID, err = r.witness(); err != nil {
+		return nil, err
+	}
+	if err = r.field("op"); err != nil {
+		return nil, err
+	}
+	if memoryOp.Op, err = readMemoryOperation(r); err != nil {
+		return nil, err
+	}
+	if err = r.field("predicate"); err != nil {
+		return nil, err
+	}
+	some, err := r.option()
+	if err != nil {
+		return nil, err
+	}
+	if some {
+		predicate, err := readExpression(r)
+		if err != nil {
+			return nil, err
+		}
+		memoryOp.Predicate = &predicate
+	}
+
+	return &memoryOp, nil
+}
+
+func readMemoryInit(r binaryReader) (*opcode.MemoryInitOpcode, error) {
+	var memoryInit opcode.MemoryInitOpcode
+	if err := r.structBegin(memoryInitFields); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if err = r.field("block_id"); err != nil {
+		return nil, err
+	}
+	if memoryInit.BlockID, err = r.witness(); err != nil {
+		return nil, err
+	}
+	if err = r.field("init"); err != nil {
+		return nil, err
+	}
+	if memoryInit.Init, err = readWitnesses(r); err != nil {
+		return nil, err
+	}
+
+	return &memoryInit, nil
+}
+
+func readWitnesses(r binaryReader) ([]uint32, error) {
+	length, err := r.seqLen()
+	if err != nil {
+		return nil, err
+	}
+	witnesses := make([]uint32, 0, length)
+	for i := 0; i < length; i++ {
+		witness, err := r.witness()
+		if err != nil {
+			return nil, err
+		}
+		witnesses = append(witnesses, witness)
+	}
+
+	return witnesses, nil
+}
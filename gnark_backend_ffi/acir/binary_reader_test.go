@@ -0,0 +1,390 @@
+package acir
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+// binaryOpcodeTestCase is an opcode in JSON along with its bincode and
+// MessagePack serializations.
+type binaryOpcodeTestCase struct {
+	kind    string
+	json    string
+	bincode func(w *bincodeWriter)
+	msgpack func(w *msgpackWriter)
+}
+
+var binaryOpcodeTestCases = []binaryOpcodeTestCase{
+	{
+		kind: "Arithmetic",
+		json: `{"Arithmetic":` + witnessExpressionJSON("1") + `}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(0)
+			w.witnessExpression(1)
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.uint(0)
+			w.witnessExpression(1)
+		},
+	},
+	{
+		kind: "BlackBoxFuncCall",
+		json: `{"BlackBoxFuncCall":{"name":"SHA256","inputs":[{"witness":1,"num_bits":8}],"outputs":[2,3]}}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(1)
+			w.u32(4)
+			w.u64(1)
+			w.u32(1)
+			w.u32(8)
+			w.u64(2)
+			w.u32(2)
+			w.u32(3)
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.str("BlackBoxFuncCall")
+			w.array(3)
+			w.str("SHA256")
+			w.array(1)
+			w.array(2)
+			w.uint(1)
+			w.uint(8)
+			w.array(2)
+			w.uint(2)
+			w.uint(3)
+		},
+	},
+	{
+		kind: "Directive",
+		json: `{"Directive":{"Quotient":{"a":` + witnessExpressionJSON("1") + `,"b":` + constantJSON(one) + `,"q":2,"r":3,"predicate":` + witnessExpressionJSON("4") + `}}}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(2)
+			w.u32(1)
+			w.witnessExpression(1)
+			w.constant(one)
+			w.u32(2)
+			w.u32(3)
+			w.WriteByte(1)
+			w.witnessExpression(4)
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.uint(2)
+			w.fixmap(1)
+			w.str("Quotient")
+			w.array(5)
+			w.witnessExpression(1)
+			w.constant(one)
+			w.uint(2)
+			w.uint(3)
+			w.witnessExpression(4)
+		},
+	},
+	{
+		kind: "Directive",
+		json: `{"Directive":{"ToRadix":{"a":` + witnessExpressionJSON("1") + `,"b":[2,3],"radix":2,"is_little_endian":true}}}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(2)
+			w.u32(4)
+			w.witnessExpression(1)
+			w.u64(2)
+			w.u32(2)
+			w.u32(3)
+			w.u32(2)
+			w.WriteByte(1)
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.str("Directive")
+			w.fixmap(1)
+			w.uint(4)
+			w.array(4)
+			w.witnessExpression(1)
+			w.array(2)
+			w.uint(2)
+			w.uint(3)
+			w.uint(2)
+			w.WriteByte(0xc3)
+		},
+	},
+	{
+		kind: "Directive",
+		json: `{"Directive":{"Log":{"FinalizedOutput":"x"}}}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(2)
+			w.u32(6)
+			w.u32(0)
+			w.str("x")
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.uint(2)
+			w.fixmap(1)
+			w.str("Log")
+			w.fixmap(1)
+			w.str("FinalizedOutput")
+			w.str("x")
+		},
+	},
+	{
+		kind: "Block",
+		json: `{"Block":{"id":0,"len":1,"trace":[{"operation":` + constantJSON(one) + `,"index":` + constantJSON(zero) + `,"value":` + witnessExpressionJSON("1") + `}]}}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(3)
+			w.u32(0)
+			w.u32(1)
+			w.u64(1)
+			w.constant(one)
+			w.constant(zero)
+			w.witnessExpression(1)
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.str("Block")
+			w.array(3)
+			w.uint(0)
+			w.uint(1)
+			w.array(1)
+			w.array(3)
+			w.constant(one)
+			w.constant(zero)
+			w.witnessExpression(1)
+		},
+	},
+	{
+		kind: "ROM",
+		json: `{"ROM":{"id":1,"len":1,"trace":[{"operation":` + constantJSON(one) + `,"index":` + constantJSON(zero) + `,"value":` + witnessExpressionJSON("1") + `}]}}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(4)
+			w.u32(1)
+			w.u32(1)
+			w.u64(1)
+			w.constant(one)
+			w.constant(zero)
+			w.witnessExpression(1)
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.uint(4)
+			w.array(3)
+			w.uint(1)
+			w.uint(1)
+			w.array(1)
+			w.array(3)
+			w.constant(one)
+			w.constant(zero)
+			w.witnessExpression(1)
+		},
+	},
+	{
+		kind: "RAM",
+		json: `{"RAM":{"id":2,"len":1,"trace":[` +
+			`{"operation":` + constantJSON(one) + `,"index":` + constantJSON(zero) + `,"value":` + witnessExpressionJSON("1") + `},` +
+			`{"operation":` + constantJSON(zero) + `,"index":` + constantJSON(zero) + `,"value":` + witnessExpressionJSON("2") + `}]}}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(5)
+			w.u32(2)
+			w.u32(1)
+			w.u64(2)
+			w.constant(one)
+			w.constant(zero)
+			w.witnessExpression(1)
+			w.constant(zero)
+			w.constant(zero)
+			w.witnessExpression(2)
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.str("RAM")
+			w.array(3)
+			w.uint(2)
+			w.uint(1)
+			w.array(2)
+			w.array(3)
+			w.constant(one)
+			w.constant(zero)
+			w.witnessExpression(1)
+			w.array(3)
+			w.constant(zero)
+			w.constant(zero)
+			w.witnessExpression(2)
+		},
+	},
+	{
+		kind: "Oracle",
+		json: `{"Oracle":{"name":"get_number","inputs":[],"input_values":[],"outputs":[2],"output_values":[]}}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(6)
+			w.str("get_number")
+			w.u64(0)
+			w.u64(0)
+			w.u64(1)
+			w.u32(2)
+			w.u64(0)
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.uint(6)
+			w.array(5)
+			w.str("get_number")
+			w.array(0)
+			w.array(0)
+			w.array(1)
+			w.uint(2)
+			w.array(0)
+		},
+	},
+	{
+		kind: "Brillig",
+		json: `{"Brillig":{"inputs":[{"Array":[` + witnessExpressionJSON("1") + `]}],"outputs":[{"Simple":2}],"foreign_call_results":[],` +
+			`"bytecode":[{"BinaryIntOp":{"destination":2,"op":"LessThan","bit_size":32,"lhs":0,"rhs":1}},{"JumpIfNot":{"condition":2,"location":3}},"Trap","Return"],` +
+			`"predicate":` + witnessExpressionJSON("4") + `}}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(7)
+			w.u64(1)
+			w.u32(1)
+			w.u64(1)
+			w.witnessExpression(1)
+			w.u64(1)
+			w.u32(0)
+			w.u32(2)
+			w.u64(0)
+			w.u64(4)
+			w.u32(1)
+			w.u64(2)
+			w.u32(6)
+			w.u32(32)
+			w.u64(0)
+			w.u64(1)
+			w.u32(2)
+			w.u64(2)
+			w.u64(3)
+			w.u32(12)
+			w.u32(7)
+			w.WriteByte(1)
+			w.witnessExpression(4)
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.str("Brillig")
+			w.array(5)
+			w.array(1)
+			w.fixmap(1)
+			w.uint(1)
+			w.array(1)
+			w.witnessExpression(1)
+			w.array(1)
+			w.fixmap(1)
+			w.str("Simple")
+			w.uint(2)
+			w.array(0)
+			w.array(4)
+			w.fixmap(1)
+			w.str("BinaryIntOp")
+			w.array(5)
+			w.uint(2)
+			w.str("LessThan")
+			w.uint(32)
+			w.uint(0)
+			w.uint(1)
+			w.fixmap(1)
+			w.uint(2)
+			w.array(2)
+			w.uint(2)
+			w.uint(3)
+			w.str("Trap")
+			w.uint(7)
+			w.witnessExpression(4)
+		},
+	},
+	{
+		kind: "MemoryOp",
+		json: `{"MemoryOp":{"block_id":0,"op":{"operation":` + constantJSON(zero) + `,"index":` + constantJSON(zero) + `,"value":` + witnessExpressionJSON("3") + `},"predicate":` + witnessExpressionJSON("4") + `}}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(8)
+			w.u32(0)
+			w.constant(zero)
+			w.constant(zero)
+			w.witnessExpression(3)
+			w.WriteByte(1)
+			w.witnessExpression(4)
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.uint(8)
+			w.array(3)
+			w.uint(0)
+			w.array(3)
+			w.constant(zero)
+			w.constant(zero)
+			w.witnessExpression(3)
+			w.witnessExpression(4)
+		},
+	},
+	{
+		kind: "MemoryInit",
+		json: `{"MemoryInit":{"block_id":1,"init":[1,2]}}`,
+		bincode: func(w *bincodeWriter) {
+			w.u32(9)
+			w.u32(1)
+			w.u64(2)
+			w.u32(1)
+			w.u32(2)
+		},
+		msgpack: func(w *msgpackWriter) {
+			w.fixmap(1)
+			w.str("MemoryInit")
+			w.array(2)
+			w.uint(1)
+			w.array(2)
+			w.uint(1)
+			w.uint(2)
+		},
+	},
+}
+
+func TestDecodeBinaryEveryOpcode(t *testing.T) {
+	kinds := make(map[string]bool)
+	for _, testCase := range binaryOpcodeTestCases {
+		kinds[testCase.kind] = true
+		circuitJSON := `{"current_witness_index":4,"opcodes":[` + testCase.json + `],"public_inputs":[]}`
+		var expected ACIR
+		if !assert.NoError(t, json.Unmarshal([]byte(circuitJSON), &expected), testCase.kind) {
+			t.FailNow()
+		}
+		expected.Curve = ecc.BN254
+
+		var bincode bincodeWriter
+		bincode.u32(4)
+		bincode.u64(1)
+		testCase.bincode(&bincode)
+		bincode.u64(0)
+
+		var msgpack msgpackWriter
+		msgpack.array(3)
+		msgpack.uint(4)
+		msgpack.array(1)
+		testCase.msgpack(&msgpack)
+		msgpack.array(0)
+
+		for _, data := range [][]byte{bincode.Bytes(), msgpack.Bytes()} {
+			a, _, err := Decode(data, ecc.BN254)
+
+			if !assert.NoError(t, err, testCase.kind) {
+				continue
+			}
+			assert.Equal(t, expected, a, testCase.kind)
+			serializedACIR, err := json.Marshal(a)
+			assert.NoError(t, err, testCase.kind)
+			assert.JSONEq(t, circuitJSON, string(serializedACIR), testCase.kind)
+		}
+	}
+
+	for _, kind := range opcodeVariants {
+		assert.True(t, kinds[kind], "no binary test case for %s opcodes", kind)
+	}
+}
@@ -0,0 +1,218 @@
+package acir
+
+import (
+	"encoding/json"
+
+	"gnark_backend_ffi/acir/opcode"
+)
+
+// schema reads a value of a Rust type into what serde_json serializes it as.
+// Brillig calls and oracles are solved in the Rust backend side and kept as
+// raw JSON, so instead of decoding them we read them with the schema of their
+// types.
+type schema func(r binaryReader) (interface{}, error)
+
+// variantSchema is a variant of an enum, unit variants have no payload.
+type variantSchema struct {
+	name    string
+	payload schema
+}
+
+func u32Schema(r binaryReader) (interface{}, error) { return r.u32() }
+
+func u64Schema(r binaryReader) (interface{}, error) { return r.u64() }
+
+func strSchema(r binaryReader) (interface{}, error) { return r.str() }
+
+func feltSchema(r binaryReader) (interface{}, error) { return r.felt() }
+
+func witnessSchema(r binaryReader) (interface{}, error) { return r.witness() }
+
+func expressionSchema(r binaryReader) (interface{}, error) { return readExpression(r) }
+
+func newtypeSchema(inner schema) schema {
+	return func(r binaryReader) (interface{}, error) {
+		if err := r.newtype(); err != nil {
+			return nil, err
+		}
+		return inner(r)
+	}
+}
+
+func seqSchema(element schema) schema {
+	return func(r binaryReader) (interface{}, error) {
+		length, err := r.seqLen()
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, 0, length)
+		for i := 0; i < length; i++ {
+			value, err := element(r)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+}
+
+func optionSchema(inner schema) schema {
+	return func(r binaryReader) (interface{}, error) {
+		some, err := r.option()
+		if err != nil || !some {
+			return nil, err
+		}
+		return inner(r)
+	}
+}
+
+// structSchema reads a struct whose fields have the given schemas, in order.
+func structSchema(fields []string, schemas ...schema) schema {
+	return func(r binaryReader) (interface{}, error) {
+		if err := r.structBegin(fields); err != nil {
+			return nil, err
+		}
+		value := newObject(fields)
+		for i, field := range fields {
+			if err := r.field(field); err != nil {
+				return nil, err
+			}
+			fieldValue, err := schemas[i](r)
+			if err != nil {
+				return nil, err
+			}
+			value.values = append(value.values, fieldValue)
+		}
+		return value, nil
+	}
+}
+
+func enumSchema(variants ...variantSchema) schema {
+	names := make([]string, len(variants))
+	unit := false
+	for i, variant := range variants {
+		names[i] = variant.name
+		unit = unit || variant.payload == nil
+	}
+	return func(r binaryReader) (interface{}, error) {
+		index, err := r.variant(names, unit)
+		if err != nil {
+			return nil, err
+		}
+		variant := variants[index]
+		if variant.payload == nil {
+			return variant.name, nil
+		}
+		payload, err := variant.payload(r)
+		if err != nil {
+			return nil, err
+		}
+		return newObject([]string{variant.name}, payload), nil
+	}
+}
+
+// The schemas below follow the Brillig and Oracle opcodes of the ACVM
+// versions that introduced foreign call results.
+var (
+	registerIndexSchema = newtypeSchema(u64Schema)
+	valueSchema         = structSchema([]string{"inner"}, feltSchema)
+	binaryFieldOpSchema = enumSchema(
+		variantSchema{"Add", nil},
+		variantSchema{"Sub", nil},
+		variantSchema{"Mul", nil},
+		variantSchema{"Div", nil},
+		variantSchema{"Equals", nil},
+	)
+	binaryIntOpSchema = enumSchema(
+		variantSchema{"Add", nil},
+		variantSchema{"Sub", nil},
+		variantSchema{"Mul", nil},
+		variantSchema{"SignedDiv", nil},
+		variantSchema{"UnsignedDiv", nil},
+		variantSchema{"Equals", nil},
+		variantSchema{"LessThan", nil},
+		variantSchema{"LessThanEquals", nil},
+		variantSchema{"And", nil},
+		variantSchema{"Or", nil},
+		variantSchema{"Xor", nil},
+		variantSchema{"Shl", nil},
+		variantSchema{"Shr", nil},
+	)
+	registerOrMemorySchema = enumSchema(
+		variantSchema{"RegisterIndex", registerIndexSchema},
+		variantSchema{"HeapArray", structSchema([]string{"pointer", "size"}, registerIndexSchema, u64Schema)},
+		variantSchema{"HeapVector", structSchema([]string{"pointer", "size"}, registerIndexSchema, registerIndexSchema)},
+	)
+	brilligVMOpcodeSchema = enumSchema(
+		variantSchema{"BinaryFieldOp", structSchema([]string{"destination", "op", "lhs", "rhs"}, registerIndexSchema, binaryFieldOpSchema, registerIndexSchema, registerIndexSchema)},
+		variantSchema{"BinaryIntOp", structSchema([]string{"destination", "op", "bit_size", "lhs", "rhs"}, registerIndexSchema, binaryIntOpSchema, u32Schema, registerIndexSchema, registerIndexSchema)},
+		variantSchema{"JumpIfNot", structSchema([]string{"condition", "location"}, registerIndexSchema, u64Schema)},
+		variantSchema{"JumpIf", structSchema([]string{"condition", "location"}, registerIndexSchema, u64Schema)},
+		variantSchema{"Jump", structSchema([]string{"location"}, u64Schema)},
+		variantSchema{"Call", structSchema([]string{"location"}, u64Schema)},
+		variantSchema{"Const", structSchema([]string{"destination", "value"}, registerIndexSchema, valueSchema)},
+		variantSchema{"Return", nil},
+		variantSchema{"ForeignCall", structSchema([]string{"function", "destinations", "inputs"}, strSchema, seqSchema(registerOrMemorySchema), seqSchema(registerOrMemorySchema))},
+		variantSchema{"Mov", structSchema([]string{"destination", "source"}, registerIndexSchema, registerIndexSchema)},
+		variantSchema{"Load", structSchema([]string{"destination", "source_pointer"}, registerIndexSchema, registerIndexSchema)},
+		variantSchema{"Store", structSchema([]string{"destination_pointer", "source"}, registerIndexSchema, registerIndexSchema)},
+		variantSchema{"Trap", nil},
+		variantSchema{"Stop", nil},
+	)
+	brilligSchema = structSchema(
+		[]string{"inputs", "outputs", "foreign_call_results", "bytecode", "predicate"},
+		seqSchema(enumSchema(
+			variantSchema{"Single", expressionSchema},
+			variantSchema{"Array", seqSchema(expressionSchema)},
+		)),
+		seqSchema(enumSchema(
+			variantSchema{"Simple", witnessSchema},
+			variantSchema{"Array", seqSchema(witnessSchema)},
+		)),
+		seqSchema(structSchema([]string{"values"}, seqSchema(enumSchema(
+			variantSchema{"Single", valueSchema},
+			variantSchema{"Array", seqSchema(valueSchema)},
+		)))),
+		seqSchema(brilligVMOpcodeSchema),
+		optionSchema(expressionSchema),
+	)
+	oracleSchema = structSchema(
+		[]string{"name", "inputs", "input_values", "outputs", "output_values"},
+		strSchema,
+		seqSchema(expressionSchema),
+		seqSchema(feltSchema),
+		seqSchema(witnessSchema),
+		seqSchema(feltSchema),
+	)
+)
+
+// readRawOpcode reads an opcode with its schema and decodes its JSON as the
+// JSON decoder of the opcode does, so both keep the same raw opcode.
+func readRawOpcode(r binaryReader, name string, opcodeSchema schema, data json.Unmarshaler) error {
+	value, err := opcodeSchema(r)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(newObject([]string{name}, value))
+	if err != nil {
+		return err
+	}
+	return data.UnmarshalJSON(encoded)
+}
+
+func readBrillig(r binaryReader) (*opcode.BrilligOpcode, error) {
+	var brillig opcode.BrilligOpcode
+	if err := readRawOpcode(r, "Brillig", brilligSchema, &brillig); err != nil {
+		return nil, err
+	}
+	return &brillig, nil
+}
+
+func readOracle(r binaryReader) (*opcode.OracleOpcode, error) {
+	var oracle opcode.OracleOpcode
+	if err := readRawOpcode(r, "Oracle", oracleSchema, &oracle); err != nil {
+		return nil, err
+	}
+	return &oracle, nil
+}
@@ -0,0 +1,136 @@
+package acir
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// bincodeReader reads ACIR serialized with bincode's default configuration:
+// little-endian fixed size integers, u64 lengths and u32 enum tags.
+type bincodeReader struct {
+	data    []byte
+	pos     int
+	modulus *big.Int
+}
+
+func newBincodeReader(data []byte, scalarField *big.Int) *bincodeReader {
+	return &bincodeReader{data: data, modulus: scalarField}
+}
+
+func (r *bincodeReader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *bincodeReader) structBegin(fields []string) error { return nil }
+
+func (r *bincodeReader) optionalStructBegin(fields []string, required int) error { return nil }
+
+func (r *bincodeReader) field(name string) error { return nil }
+
+// Bincode has no struct headers, trailing optional fields are there if the
+// input isn't over.
+func (r *bincodeReader) optionalField(name string) (bool, error) {
+	return r.pos < len(r.data), nil
+}
+
+func (r *bincodeReader) variant(variants []string, unit bool) (int, error) {
+	index, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	if int(index) >= len(variants) {
+		return 0, fmt.Errorf("bincode: unknown variant %d", index)
+	}
+	return int(index), nil
+}
+
+func (r *bincodeReader) seqLen() (int, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	length := binary.LittleEndian.Uint64(b)
+	// Every element takes at least one byte so this bounds the allocations.
+	if length > uint64(len(r.data)-r.pos) {
+		return 0, fmt.Errorf("bincode: sequence length %d exceeds input", length)
+	}
+	return int(length), nil
+}
+
+func (r *bincodeReader) mapLen() (int, error) {
+	return r.seqLen()
+}
+
+func (r *bincodeReader) option() (bool, error) {
+	return r.boolean()
+}
+
+func (r *bincodeReader) u32() (uint32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+func (r *bincodeReader) u64() (uint64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func (r *bincodeReader) boolean() (bool, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return false, err
+	}
+	switch b[0] {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("bincode: invalid bool %d", b[0])
+	}
+}
+
+func (r *bincodeReader) str() (string, error) {
+	length, err := r.seqLen()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.next(length)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (r *bincodeReader) felt() (string, error) {
+	return r.str()
+}
+
+func (r *bincodeReader) scalarField() *big.Int { return r.modulus }
+
+func (r *bincodeReader) newtype() error { return nil }
+
+func (r *bincodeReader) witness() (uint32, error) {
+	return r.u32()
+}
+
+func (r *bincodeReader) done() error {
+	if r.pos != len(r.data) {
+		return errors.New("bincode: trailing bytes after circuit")
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package acir
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OpcodeLocation points to an opcode of the circuit or, for Brillig calls,
+// to an instruction of their bytecode. BrilligIndex is -1 for ACIR opcodes.
+type OpcodeLocation struct {
+	AcirIndex    int
+	BrilligIndex int
+}
+
+// AcirLocation is the location of the ACIR opcode at index.
+func AcirLocation(index int) OpcodeLocation {
+	return OpcodeLocation{AcirIndex: index, BrilligIndex: -1}
+}
+
+// String uses Noir's notation: 3 for an ACIR opcode and 3.1 for a Brillig
+// instruction.
+func (l OpcodeLocation) String() string {
+	if l.BrilligIndex < 0 {
+		return strconv.Itoa(l.AcirIndex)
+	}
+	return fmt.Sprintf("%d.%d", l.AcirIndex, l.BrilligIndex)
+}
+
+func ParseOpcodeLocation(s string) (OpcodeLocation, error) {
+	acirIndex, brilligIndex, isBrillig := strings.Cut(s, ".")
+	location := AcirLocation(0)
+	var err error
+	if location.AcirIndex, err = strconv.Atoi(acirIndex); err != nil {
+		return location, fmt.Errorf("invalid opcode location %q", s)
+	}
+	if isBrillig {
+		if location.BrilligIndex, err = strconv.Atoi(brilligIndex); err != nil {
+			return location, fmt.Errorf("invalid opcode location %q", s)
+		}
+	}
+	return location, nil
+}
+
+type brilligLocationJSON struct {
+	AcirIndex    int `json:"acir_index"`
+	BrilligIndex int `json:"brillig_index"`
+}
+
+func (l OpcodeLocation) MarshalJSON() ([]byte, error) {
+	if l.BrilligIndex < 0 {
+		return json.Marshal(map[string]int{"Acir": l.AcirIndex})
+	}
+	return json.Marshal(map[string]brilligLocationJSON{"Brillig": {l.AcirIndex, l.BrilligIndex}})
+}
+
+// Locations come as {"Acir": 3} or {"Brillig": {...}}, older versions of
+// Noir only have ACIR locations and serialize them as plain numbers.
+func (l *OpcodeLocation) UnmarshalJSON(data []byte) error {
+	var index int
+	if json.Unmarshal(data, &index) == nil {
+		*l = AcirLocation(index)
+		return nil
+	}
+	var encodedLocation string
+	if json.Unmarshal(data, &encodedLocation) == nil {
+		location, err := ParseOpcodeLocation(encodedLocation)
+		*l = location
+		return err
+	}
+
+	var locationMap map[string]json.RawMessage
+	if err := json.Unmarshal(data, &locationMap); err != nil {
+		return err
+	}
+	if acirIndex, ok := locationMap["Acir"]; ok {
+		*l = AcirLocation(0)
+		return json.Unmarshal(acirIndex, &l.AcirIndex)
+	}
+	if brilligLocation, ok := locationMap["Brillig"]; ok {
+		var location brilligLocationJSON
+		if err := json.Unmarshal(brilligLocation, &location); err != nil {
+			return err
+		}
+		*l = OpcodeLocation{AcirIndex: location.AcirIndex, BrilligIndex: location.BrilligIndex}
+		return nil
+	}
+
+	return &json.UnmarshalTypeError{}
+}
+
+// AssertMessage is the message of the Noir assert an opcode comes from.
+type AssertMessage struct {
+	Location OpcodeLocation
+	Message  string
+}
+
+// Noir serializes assert messages as (location, message) tuples.
+func (m AssertMessage) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{m.Location, m.Message})
+}
+
+func (m *AssertMessage) UnmarshalJSON(data []byte) error {
+	var tuple []json.RawMessage
+	if err := json.Unmarshal(data, &tuple); err != nil {
+		return err
+	}
+	if len(tuple) != 2 {
+		return &json.UnmarshalTypeError{}
+	}
+	if err := json.Unmarshal(tuple[0], &m.Location); err != nil {
+		return err
+	}
+	return json.Unmarshal(tuple[1], &m.Message)
+}
+
+// DebugLocation is a span of bytes of a Noir source file.
+type DebugLocation struct {
+	File  uint32
+	Start uint32
+	End   uint32
+}
+
+type debugLocationJSON struct {
+	Span struct {
+		Start uint32 `json:"start"`
+		End   uint32 `json:"end"`
+	} `json:"span"`
+	File uint32 `json:"file"`
+}
+
+func (l DebugLocation) String() string {
+	return fmt.Sprintf("file %d [%d, %d)", l.File, l.Start, l.End)
+}
+
+func (l DebugLocation) MarshalJSON() ([]byte, error) {
+	var location debugLocationJSON
+	location.Span.Start, location.Span.End, location.File = l.Start, l.End, l.File
+	return json.Marshal(location)
+}
+
+func (l *DebugLocation) UnmarshalJSON(data []byte) error {
+	var location debugLocationJSON
+	if err := json.Unmarshal(data, &location); err != nil {
+		return err
+	}
+	l.Start, l.End, l.File = location.Span.Start, location.Span.End, location.File
+	return nil
+}
+
+// DebugInfo is the part of Noir's debug symbols that maps opcodes to the
+// source code they were compiled from.
+type DebugInfo struct {
+	Locations map[OpcodeLocation][]DebugLocation
+}
+
+// The locations are keyed by the string notation of the opcode location.
+func (d DebugInfo) MarshalJSON() ([]byte, error) {
+	locations := make(map[string][]DebugLocation, len(d.Locations))
+	for location, debugLocations := range d.Locations {
+		locations[location.String()] = debugLocations
+	}
+	return json.Marshal(struct {
+		Locations map[string][]DebugLocation `json:"locations"`
+	}{locations})
+}
+
+func (d *DebugInfo) UnmarshalJSON(data []byte) error {
+	var debugInfo struct {
+		Locations map[string][]DebugLocation `json:"locations"`
+	}
+	if err := json.Unmarshal(data, &debugInfo); err != nil {
+		return err
+	}
+
+	d.Locations = make(map[OpcodeLocation][]DebugLocation, len(debugInfo.Locations))
+	for encodedLocation, debugLocations := range debugInfo.Locations {
+		location, err := ParseOpcodeLocation(encodedLocation)
+		if err != nil {
+			return err
+		}
+		d.Locations[location] = debugLocations
+	}
+	return nil
+}
+
+// AssertMessage returns the message of the assert the opcode at index comes
+// from, if any.
+func (a ACIR) AssertMessage(index int) (string, bool) {
+	for _, assertMessage := range a.AssertMessages {
+		if assertMessage.Location == AcirLocation(index) {
+			return assertMessage.Message, true
+		}
+	}
+	return "", false
+}
+
+// DebugLocations returns the source locations of the opcode at index, if the
+// circuit carries debug symbols.
+func (a ACIR) DebugLocations(index int) []DebugLocation {
+	if a.DebugInfo == nil {
+		return nil
+	}
+	return a.DebugInfo.Locations[AcirLocation(index)]
+}
+
+// DescribeOpcode renders the opcode at index along with everything known of
+// its origin, for example
+// opcode 3: w1 − w2 = 0, assert "x != y", at file 0 [25, 38).
+func (a ACIR) DescribeOpcode(index int) string {
+	var description strings.Builder
+	fmt.Fprintf(&description, "opcode %d", index)
+	if index < 0 || index >= len(a.Opcodes) {
+		return description.String()
+	}
+	fmt.Fprintf(&description, ": %s", FormatOpcode(a.Opcodes[index], a.CurveID()))
+	if message, ok := a.AssertMessage(index); ok {
+		fmt.Fprintf(&description, ", assert %q", message)
+	}
+	if debugLocations := a.DebugLocations(index); len(debugLocations) > 0 {
+		formattedLocations := make([]string, 0, len(debugLocations))
+		for _, debugLocation := range debugLocations {
+			formattedLocations = append(formattedLocations, debugLocation.String())
+		}
+		fmt.Fprintf(&description, ", at %s", strings.Join(formattedLocations, ", "))
+	}
+	return description.String()
+}
@@ -0,0 +1,61 @@
+package acir
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const debugCircuitJSON = `{"current_witness_index":2,"opcodes":[{"Arithmetic":{"mul_terms":[],"linear_combinations":[["` + one + `",1],["` + minusOne + `",2]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},{"Brillig":{"inputs":[],"outputs":[{"Simple":2}],"foreign_call_results":[],"bytecode":[],"predicate":null}}],"public_inputs":[],"assert_messages":[[{"Acir":0},"x != y"],[{"Brillig":{"acir_index":1,"brillig_index":3}},"unreachable"]],"debug_symbols":{"locations":{"0":[{"span":{"start":25,"end":38},"file":0}]}}}`
+
+func TestACIRUnmarshalJSONDebugInfo(t *testing.T) {
+	var a ACIR
+	err := json.Unmarshal([]byte(debugCircuitJSON), &a)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []AssertMessage{
+		{Location: AcirLocation(0), Message: "x != y"},
+		{Location: OpcodeLocation{AcirIndex: 1, BrilligIndex: 3}, Message: "unreachable"},
+	}, a.AssertMessages)
+	assert.Equal(t, []DebugLocation{{File: 0, Start: 25, End: 38}}, a.DebugLocations(0))
+	assert.Nil(t, a.DebugLocations(1))
+
+	message, ok := a.AssertMessage(0)
+	assert.True(t, ok)
+	assert.Equal(t, "x != y", message)
+	// Brillig messages are not the message of the ACIR opcode.
+	_, ok = a.AssertMessage(1)
+	assert.False(t, ok)
+
+	marshaled, err := json.Marshal(a)
+	assert.NoError(t, err)
+	assert.JSONEq(t, debugCircuitJSON, string(marshaled))
+}
+
+func TestOpcodeLocationUnmarshalJSON(t *testing.T) {
+	for encodedLocation, expectedLocation := range map[string]OpcodeLocation{
+		`3`:          AcirLocation(3),
+		`"3"`:        AcirLocation(3),
+		`"3.1"`:      {AcirIndex: 3, BrilligIndex: 1},
+		`{"Acir":3}`: AcirLocation(3),
+		`{"Brillig":{"acir_index":3,"brillig_index":1}}`: {AcirIndex: 3, BrilligIndex: 1},
+	} {
+		var location OpcodeLocation
+		assert.NoError(t, json.Unmarshal([]byte(encodedLocation), &location))
+		assert.Equal(t, expectedLocation, location)
+	}
+
+	var location OpcodeLocation
+	assert.Error(t, json.Unmarshal([]byte(`"3.x"`), &location))
+	assert.Error(t, json.Unmarshal([]byte(`{"Unknown":3}`), &location))
+}
+
+func TestDescribeOpcode(t *testing.T) {
+	var a ACIR
+	assert.NoError(t, json.Unmarshal([]byte(debugCircuitJSON), &a))
+
+	assert.Equal(t, `opcode 0: w1 − w2 = 0, assert "x != y", at file 0 [25, 38)`, a.DescribeOpcode(0))
+	assert.Equal(t, "opcode 1: BRILLIG → [w2]", a.DescribeOpcode(1))
+	assert.Equal(t, "opcode 2", a.DescribeOpcode(2))
+}
@@ -0,0 +1,162 @@
+package acir
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	backend_helpers "gnark_backend_ffi/internal/backend"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+type Encoding int
+
+const (
+	JSONEncoding Encoding = iota
+	BincodeEncoding
+	MessagePackEncoding
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case JSONEncoding:
+		return "json"
+	case BincodeEncoding:
+		return "bincode"
+	case MessagePackEncoding:
+		return "msgpack"
+	default:
+		return fmt.Sprintf("unknown encoding %d", int(e))
+	}
+}
+
+// Format describes how a serialized ACIR was encoded.
+type Format struct {
+	Encoding Encoding
+	Gzipped  bool
+}
+
+func (f Format) String() string {
+	if f.Gzipped {
+		return f.Encoding.String() + "+gzip"
+	}
+	return f.Encoding.String()
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Decode deserializes an ACIR that comes either as JSON or in one of Noir's
+// binary serializations (bincode or MessagePack), optionally gzipped, and
+// returns the format it detected. Its felts must be the canonical encodings
+// of elements of the scalar field of the curve it is decoded for.
+func Decode(data []byte, curveID ecc.ID) (circuit ACIR, format Format, err error) {
+	defer func() {
+		if err == nil {
+			circuit.Curve = curveID
+		}
+	}()
+	scalarField := curveID.ScalarField()
+
+	if bytes.HasPrefix(data, gzipMagic) {
+		format.Gzipped = true
+		if data, err = gunzip(data); err != nil {
+			err = fmt.Errorf("decoding gzipped ACIR: %w", err)
+			return
+		}
+	}
+
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		format.Encoding = JSONEncoding
+		if err = json.Unmarshal(trimmed, &circuit); err != nil {
+			return
+		}
+		// The felts are unmarshalled without knowing the curve, they are
+		// checked against its field from the generic JSON.
+		var value interface{}
+		if err = json.Unmarshal(trimmed, &value); err != nil {
+			return
+		}
+		if err = checkJSONFelts(value, scalarField); err != nil {
+			circuit = ACIR{}
+		}
+		return
+	}
+
+	// A MessagePack circuit always starts with a three to five elements array
+	// or map header, the last two fields are optional. Bincode has no header
+	// so we only fall back to it when the data doesn't look like MessagePack
+	// or fails to decode as such.
+	var msgpackErr error
+	if len(data) > 0 && (data[0]&0xf0 == 0x90 || data[0]&0xf0 == 0x80) && data[0]&0x0f >= 3 && data[0]&0x0f <= 5 {
+		if circuit, msgpackErr = readCircuit(newMsgpackReader(data, scalarField)); msgpackErr == nil {
+			format.Encoding = MessagePackEncoding
+			return
+		}
+	}
+
+	circuit, err = readCircuit(newBincodeReader(data, scalarField))
+	if err != nil {
+		circuit = ACIR{}
+		if msgpackErr != nil {
+			err = fmt.Errorf("decoding ACIR as %s: %v, as %s: %w", MessagePackEncoding, msgpackErr, BincodeEncoding, err)
+		} else {
+			err = fmt.Errorf("decoding ACIR as %s: %w", BincodeEncoding, err)
+		}
+		return
+	}
+	format.Encoding = BincodeEncoding
+
+	return
+}
+
+// checkJSONFelts checks the felts of the expressions found in a generic JSON
+// value: their constant terms and the coefficients of their terms.
+func checkJSONFelts(value interface{}, scalarField *big.Int) error {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if constantTerm, ok := value["q_c"].(string); ok {
+			if _, err := backend_helpers.DeserializeFelt(constantTerm, scalarField); err != nil {
+				return err
+			}
+			for _, key := range []string{"mul_terms", "linear_combinations"} {
+				terms, _ := value[key].([]interface{})
+				for _, t := range terms {
+					if t, ok := t.([]interface{}); ok && len(t) > 0 {
+						if coefficient, ok := t[0].(string); ok {
+							if _, err := backend_helpers.DeserializeFelt(coefficient, scalarField); err != nil {
+								return err
+							}
+						}
+					}
+				}
+			}
+		}
+		for _, v := range value {
+			if err := checkJSONFelts(v, scalarField); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, v := range value {
+			if err := checkJSONFelts(v, scalarField); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
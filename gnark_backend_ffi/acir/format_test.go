@@ -0,0 +1,626 @@
+package acir
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gnark_backend_ffi/acir/opcode"
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	zero     = "0000000000000000000000000000000000000000000000000000000000000000"
+	one      = "0000000000000000000000000000000000000000000000000000000000000001"
+	minusOne = "30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000"
+)
+
+// x != y, with x = w1, y = w2 and w2 public.
+var circuitJSON = `{"current_witness_index":4,"opcodes":[{"Arithmetic":{"mul_terms":[],"linear_combinations":[["` + one + `",1],["` + minusOne + `",2],["` + minusOne + `",3]],"q_c":"` + minusOne + `"}},{"Directive":{"Invert":{"x":3,"result":4}}},{"Arithmetic":{"mul_terms":[["` + one + `",3,4]],"linear_combinations":[],"q_c":"` + minusOne + `"}},{"BlackBoxFuncCall":{"name":"RANGE","inputs":[{"witness":1,"num_bits":32}],"outputs":[]}}],"public_inputs":[2]}`
+
+type bincodeWriter struct{ bytes.Buffer }
+
+func (w *bincodeWriter) u32(v uint32) { binary.Write(w, binary.LittleEndian, v) }
+func (w *bincodeWriter) u64(v uint64) { binary.Write(w, binary.LittleEndian, v) }
+func (w *bincodeWriter) str(s string) { w.u64(uint64(len(s))); w.WriteString(s) }
+
+func bincodeCircuit() []byte {
+	var w bincodeWriter
+	w.u32(4)
+	w.u64(4)
+	// Arithmetic
+	w.u32(0)
+	w.u64(0)
+	w.u64(3)
+	w.str(one)
+	w.u32(1)
+	w.str(minusOne)
+	w.u32(2)
+	w.str(minusOne)
+	w.u32(3)
+	w.str(minusOne)
+	// Directive::Invert
+	w.u32(2)
+	w.u32(0)
+	w.u32(3)
+	w.u32(4)
+	// Arithmetic
+	w.u32(0)
+	w.u64(1)
+	w.str(one)
+	w.u32(3)
+	w.u32(4)
+	w.u64(0)
+	w.str(minusOne)
+	// BlackBoxFuncCall RANGE
+	w.u32(1)
+	w.u32(3)
+	w.u64(1)
+	w.u32(1)
+	w.u32(32)
+	w.u64(0)
+	// Public inputs
+	w.u64(1)
+	w.u32(2)
+	return w.Bytes()
+}
+
+// constant writes the Expression of a constant felt.
+func (w *bincodeWriter) constant(c string) {
+	w.u64(0)
+	w.u64(0)
+	w.str(c)
+}
+
+// witnessExpression writes the Expression of a single witness.
+func (w *bincodeWriter) witnessExpression(witness uint32) {
+	w.u64(0)
+	w.u64(1)
+	w.str(one)
+	w.u32(witness)
+	w.str(zero)
+}
+
+type msgpackWriter struct{ bytes.Buffer }
+
+func (w *msgpackWriter) array(n int)  { w.WriteByte(0x90 | byte(n)) }
+func (w *msgpackWriter) fixmap(n int) { w.WriteByte(0x80 | byte(n)) }
+func (w *msgpackWriter) uint(v uint8) {
+	if v > 0x7f {
+		w.WriteByte(0xcc)
+	}
+	w.WriteByte(v)
+}
+func (w *msgpackWriter) str(s string) {
+	if len(s) < 32 {
+		w.WriteByte(0xa0 | byte(len(s)))
+	} else {
+		w.WriteByte(0xd9)
+		w.WriteByte(byte(len(s)))
+	}
+	w.WriteString(s)
+}
+
+func (w *msgpackWriter) constant(c string) {
+	w.array(3)
+	w.array(0)
+	w.array(0)
+	w.str(c)
+}
+
+func (w *msgpackWriter) witnessExpression(witness uint32) {
+	w.array(3)
+	w.array(0)
+	w.array(1)
+	w.array(2)
+	w.str(one)
+	w.uint(uint8(witness))
+	w.str(zero)
+}
+
+func msgpackCircuit() []byte {
+	var w msgpackWriter
+	w.array(3)
+	w.uint(4)
+	w.array(4)
+	// Arithmetic
+	w.fixmap(1)
+	w.uint(0)
+	w.array(3)
+	w.array(0)
+	w.array(3)
+	w.array(2)
+	w.str(one)
+	w.uint(1)
+	w.array(2)
+	w.str(minusOne)
+	w.uint(2)
+	w.array(2)
+	w.str(minusOne)
+	w.uint(3)
+	w.str(minusOne)
+	// Directive::Invert
+	w.fixmap(1)
+	w.str("Directive")
+	w.fixmap(1)
+	w.str("Invert")
+	w.array(2)
+	w.uint(3)
+	w.uint(4)
+	// Arithmetic
+	w.fixmap(1)
+	w.uint(0)
+	w.array(3)
+	w.array(1)
+	w.array(3)
+	w.str(one)
+	w.uint(3)
+	w.uint(4)
+	w.array(0)
+	w.str(minusOne)
+	// BlackBoxFuncCall RANGE
+	w.fixmap(1)
+	w.uint(1)
+	w.array(3)
+	w.str("RANGE")
+	w.array(1)
+	w.array(2)
+	w.uint(1)
+	w.uint(32)
+	w.array(0)
+	// Public inputs
+	w.array(1)
+	w.uint(2)
+	return w.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestDecodeJSON(t *testing.T) {
+	var expected ACIR
+	assert.NoError(t, json.Unmarshal([]byte(circuitJSON), &expected))
+	expected.Curve = ecc.BN254
+
+	a, format, err := Decode([]byte(circuitJSON), ecc.BN254)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Format{Encoding: JSONEncoding}, format)
+	assert.Equal(t, expected, a)
+}
+
+func TestDecodeBincode(t *testing.T) {
+	var expected ACIR
+	assert.NoError(t, json.Unmarshal([]byte(circuitJSON), &expected))
+	expected.Curve = ecc.BN254
+
+	a, format, err := Decode(bincodeCircuit(), ecc.BN254)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Format{Encoding: BincodeEncoding}, format)
+	assert.Equal(t, expected, a)
+}
+
+func TestDecodeMessagePack(t *testing.T) {
+	var expected ACIR
+	assert.NoError(t, json.Unmarshal([]byte(circuitJSON), &expected))
+	expected.Curve = ecc.BN254
+
+	a, format, err := Decode(msgpackCircuit(), ecc.BN254)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Format{Encoding: MessagePackEncoding}, format)
+	assert.Equal(t, expected, a)
+}
+
+func TestDecodeGzipped(t *testing.T) {
+	var expected ACIR
+	assert.NoError(t, json.Unmarshal([]byte(circuitJSON), &expected))
+	expected.Curve = ecc.BN254
+
+	for _, data := range [][]byte{[]byte(circuitJSON), bincodeCircuit(), msgpackCircuit()} {
+		a, format, err := Decode(gzipped(t, data), ecc.BN254)
+
+		assert.NoError(t, err)
+		assert.True(t, format.Gzipped)
+		assert.Equal(t, expected, a)
+	}
+}
+
+func TestDecodeThrowsErrorTruncatedBincode(t *testing.T) {
+	data := bincodeCircuit()
+
+	_, _, err := Decode(data[:len(data)-1], ecc.BN254)
+	assert.Error(t, err)
+}
+
+func TestDecodeThrowsErrorTrailingBytes(t *testing.T) {
+	data := append(bincodeCircuit(), 0)
+
+	_, _, err := Decode(data, ecc.BN254)
+	assert.Error(t, err)
+}
+
+func TestDecodeChecksFeltsAgainstTheCurve(t *testing.T) {
+	wideOne := strings.Repeat("00", 16) + one
+	wideCircuitJSON := `{"current_witness_index":1,"opcodes":[{"Arithmetic":{"mul_terms":[],"linear_combinations":[["` + wideOne + `",1]],"q_c":"` + strings.Repeat("00", 48) + `"}}],"public_inputs":[]}`
+
+	a, _, err := Decode([]byte(wideCircuitJSON), ecc.BW6_761)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, ecc.BW6_761, a.Curve)
+
+	// BN254 felts take 32 bytes and BW6-761 ones 48.
+	_, _, err = Decode([]byte(wideCircuitJSON), ecc.BN254)
+	assert.ErrorContains(t, err, "felt of 48 bytes, expected 32")
+	for _, data := range [][]byte{[]byte(circuitJSON), bincodeCircuit(), msgpackCircuit()} {
+		_, _, err = Decode(data, ecc.BW6_761)
+		assert.ErrorContains(t, err, "felt of 32 bytes, expected 48")
+	}
+
+	modulus := hex.EncodeToString(ecc.BN254.ScalarField().Bytes())
+	_, _, err = Decode([]byte(strings.ReplaceAll(circuitJSON, minusOne, modulus)), ecc.BN254)
+	assert.ErrorIs(t, err, felt.ErrNonCanonical)
+}
+
+func TestDecodeNamesEveryFormatTried(t *testing.T) {
+	data := msgpackCircuit()
+
+	_, _, err := Decode(data[:len(data)-1], ecc.BN254)
+	assert.ErrorContains(t, err, "as msgpack")
+	assert.ErrorContains(t, err, "as bincode")
+}
+
+func constantJSON(c string) string {
+	return `{"mul_terms":[],"linear_combinations":[],"q_c":"` + c + `"}`
+}
+
+func witnessExpressionJSON(witness string) string {
+	return `{"mul_terms":[],"linear_combinations":[["` + one + `",` + witness + `]],"q_c":"` + zero + `"}`
+}
+
+// Reads w3 from the block [w1, w2] under the predicate w2, writes it back
+// without predicate and reads the ROM block [w1].
+var memoryCircuitJSON = `{"current_witness_index":3,"opcodes":[` +
+	`{"MemoryInit":{"block_id":0,"init":[1,2]}},` +
+	`{"MemoryOp":{"block_id":0,"op":{"operation":` + constantJSON(zero) + `,"index":` + constantJSON(one) + `,"value":` + witnessExpressionJSON("3") + `},"predicate":` + witnessExpressionJSON("2") + `}},` +
+	`{"MemoryOp":{"block_id":0,"op":{"operation":` + constantJSON(one) + `,"index":` + constantJSON(one) + `,"value":` + witnessExpressionJSON("3") + `}}},` +
+	`{"ROM":{"id":1,"len":1,"trace":[{"operation":` + constantJSON(one) + `,"index":` + constantJSON(zero) + `,"value":` + witnessExpressionJSON("1") + `}]}}` +
+	`],"public_inputs":[]}`
+
+func bincodeMemoryCircuit() []byte {
+	var w bincodeWriter
+	w.u32(3)
+	w.u64(4)
+	// MemoryInit
+	w.u32(9)
+	w.u32(0)
+	w.u64(2)
+	w.u32(1)
+	w.u32(2)
+	// MemoryOp with a predicate
+	w.u32(8)
+	w.u32(0)
+	w.constant(zero)
+	w.constant(one)
+	w.witnessExpression(3)
+	w.WriteByte(1)
+	w.witnessExpression(2)
+	// MemoryOp without predicate
+	w.u32(8)
+	w.u32(0)
+	w.constant(one)
+	w.constant(one)
+	w.witnessExpression(3)
+	w.WriteByte(0)
+	// ROM
+	w.u32(4)
+	w.u32(1)
+	w.u32(1)
+	w.u64(1)
+	w.constant(one)
+	w.constant(zero)
+	w.witnessExpression(1)
+	// Public inputs
+	w.u64(0)
+	return w.Bytes()
+}
+
+func msgpackMemoryCircuit() []byte {
+	var w msgpackWriter
+	w.array(3)
+	w.uint(3)
+	w.array(4)
+	// MemoryInit
+	w.fixmap(1)
+	w.str("MemoryInit")
+	w.array(2)
+	w.uint(0)
+	w.array(2)
+	w.uint(1)
+	w.uint(2)
+	// MemoryOp with a predicate
+	w.fixmap(1)
+	w.uint(8)
+	w.array(3)
+	w.uint(0)
+	w.array(3)
+	w.constant(zero)
+	w.constant(one)
+	w.witnessExpression(3)
+	w.witnessExpression(2)
+	// MemoryOp without predicate
+	w.fixmap(1)
+	w.str("MemoryOp")
+	w.array(3)
+	w.uint(0)
+	w.array(3)
+	w.constant(one)
+	w.constant(one)
+	w.witnessExpression(3)
+	w.WriteByte(0xc0)
+	// ROM
+	w.fixmap(1)
+	w.str("ROM")
+	w.array(3)
+	w.uint(1)
+	w.uint(1)
+	w.array(1)
+	w.array(3)
+	w.constant(one)
+	w.constant(zero)
+	w.witnessExpression(1)
+	// Public inputs
+	w.array(0)
+	return w.Bytes()
+}
+
+func TestDecodeBinaryMemoryOpcodes(t *testing.T) {
+	var expected ACIR
+	assert.NoError(t, json.Unmarshal([]byte(memoryCircuitJSON), &expected))
+	expected.Curve = ecc.BN254
+
+	for _, data := range [][]byte{bincodeMemoryCircuit(), msgpackMemoryCircuit()} {
+		a, _, err := Decode(data, ecc.BN254)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, a)
+		serializedACIR, err := json.Marshal(a)
+		assert.NoError(t, err)
+		assert.JSONEq(t, memoryCircuitJSON, string(serializedACIR))
+	}
+}
+
+// Asks the get_number oracle for w2 and then calls it again from Brillig to
+// solve w2, w3 and w4.
+var oracleCircuitJSON = `{"current_witness_index":4,"opcodes":[` +
+	`{"Oracle":{"name":"get_number","inputs":[` + witnessExpressionJSON("1") + `],"input_values":["` + one + `"],"outputs":[2],"output_values":[]}},` +
+	`{"Brillig":{"inputs":[{"Single":` + witnessExpressionJSON("1") + `}],"outputs":[{"Simple":2},{"Array":[3,4]}],` +
+	`"foreign_call_results":[{"values":[{"Single":{"inner":"` + one + `"}}]}],` +
+	`"bytecode":[{"Const":{"destination":0,"value":{"inner":"` + one + `"}}},` +
+	`{"ForeignCall":{"function":"get_number","destinations":[{"RegisterIndex":0}],"inputs":[{"HeapArray":{"pointer":1,"size":2}}]}},` +
+	`"Stop"],"predicate":null}}` +
+	`],"public_inputs":[]}`
+
+func bincodeOracleCircuit() []byte {
+	var w bincodeWriter
+	w.u32(4)
+	w.u64(2)
+	// Oracle
+	w.u32(6)
+	w.str("get_number")
+	w.u64(1)
+	w.witnessExpression(1)
+	w.u64(1)
+	w.str(one)
+	w.u64(1)
+	w.u32(2)
+	w.u64(0)
+	// Brillig
+	w.u32(7)
+	w.u64(1)
+	w.u32(0)
+	w.witnessExpression(1)
+	w.u64(2)
+	w.u32(0)
+	w.u32(2)
+	w.u32(1)
+	w.u64(2)
+	w.u32(3)
+	w.u32(4)
+	w.u64(1)
+	w.u64(1)
+	w.u32(0)
+	w.str(one)
+	w.u64(3)
+	w.u32(6)
+	w.u64(0)
+	w.str(one)
+	w.u32(8)
+	w.str("get_number")
+	w.u64(1)
+	w.u32(0)
+	w.u64(0)
+	w.u64(1)
+	w.u32(1)
+	w.u64(1)
+	w.u64(2)
+	w.u32(13)
+	w.WriteByte(0)
+	// Public inputs
+	w.u64(0)
+	return w.Bytes()
+}
+
+func msgpackOracleCircuit() []byte {
+	var w msgpackWriter
+	w.array(3)
+	w.uint(4)
+	w.array(2)
+	// Oracle
+	w.fixmap(1)
+	w.str("Oracle")
+	w.array(5)
+	w.str("get_number")
+	w.array(1)
+	w.witnessExpression(1)
+	w.array(1)
+	w.str(one)
+	w.array(1)
+	w.uint(2)
+	w.array(0)
+	// Brillig
+	w.fixmap(1)
+	w.uint(7)
+	w.array(5)
+	w.array(1)
+	w.fixmap(1)
+	w.str("Single")
+	w.witnessExpression(1)
+	w.array(2)
+	w.fixmap(1)
+	w.uint(0)
+	w.uint(2)
+	w.fixmap(1)
+	w.str("Array")
+	w.array(2)
+	w.uint(3)
+	w.uint(4)
+	w.array(1)
+	w.array(1)
+	w.array(1)
+	w.fixmap(1)
+	w.uint(0)
+	w.array(1)
+	w.str(one)
+	w.array(3)
+	w.fixmap(1)
+	w.str("Const")
+	w.array(2)
+	w.uint(0)
+	w.array(1)
+	w.str(one)
+	w.fixmap(1)
+	w.str("ForeignCall")
+	w.array(3)
+	w.str("get_number")
+	w.array(1)
+	w.fixmap(1)
+	w.str("RegisterIndex")
+	// Newtype wrapped by older rmp_serde versions.
+	w.array(1)
+	w.uint(0)
+	w.array(1)
+	w.fixmap(1)
+	w.uint(1)
+	w.array(2)
+	w.uint(1)
+	w.uint(2)
+	w.str("Stop")
+	w.WriteByte(0xc0)
+	// Public inputs
+	w.array(0)
+	return w.Bytes()
+}
+
+func TestDecodeBinaryBrilligAndOracleOpcodes(t *testing.T) {
+	var expected ACIR
+	assert.NoError(t, json.Unmarshal([]byte(oracleCircuitJSON), &expected))
+	expected.Curve = ecc.BN254
+
+	for _, data := range [][]byte{bincodeOracleCircuit(), msgpackOracleCircuit()} {
+		a, _, err := Decode(data, ecc.BN254)
+
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, expected.CurrentWitness, a.CurrentWitness)
+		assert.Equal(t, expected.Opcodes[0].Data.(*opcode.OracleOpcode).Name, a.Opcodes[0].Data.(*opcode.OracleOpcode).Name)
+		assert.Equal(t, expected.Opcodes[0].Data.(*opcode.OracleOpcode).Outputs, a.Opcodes[0].Data.(*opcode.OracleOpcode).Outputs)
+		assert.Equal(t, expected.Opcodes[1].Data.(*opcode.BrilligOpcode).Outputs, a.Opcodes[1].Data.(*opcode.BrilligOpcode).Outputs)
+		serializedACIR, err := json.Marshal(a)
+		assert.NoError(t, err)
+		assert.JSONEq(t, oracleCircuitJSON, string(serializedACIR))
+	}
+}
+
+var annotatedCircuitJSON = circuitJSON[:len(circuitJSON)-1] +
+	`,"assert_messages":[[{"Acir":2},"x != y"],[{"Brillig":{"acir_index":3,"brillig_index":1}},"unreachable"]]` +
+	`,"debug_symbols":{"locations":{"2":[{"span":{"start":25,"end":38},"file":0}]}}}`
+
+func TestDecodeBinaryAssertMessagesAndDebugSymbols(t *testing.T) {
+	var expected ACIR
+	assert.NoError(t, json.Unmarshal([]byte(annotatedCircuitJSON), &expected))
+	expected.Curve = ecc.BN254
+
+	var bincode bincodeWriter
+	bincode.Write(bincodeCircuit())
+	bincode.u64(2)
+	bincode.u32(0)
+	bincode.u64(2)
+	bincode.str("x != y")
+	bincode.u32(1)
+	bincode.u64(3)
+	bincode.u64(1)
+	bincode.str("unreachable")
+	bincode.WriteByte(1)
+	bincode.u64(1)
+	bincode.str("2")
+	bincode.u64(1)
+	bincode.u32(25)
+	bincode.u32(38)
+	bincode.u64(0)
+
+	var msgpack msgpackWriter
+	msgpack.array(5)
+	msgpack.Write(msgpackCircuit()[1:])
+	msgpack.array(2)
+	msgpack.array(2)
+	msgpack.fixmap(1)
+	msgpack.str("Acir")
+	msgpack.uint(2)
+	msgpack.str("x != y")
+	msgpack.array(2)
+	msgpack.fixmap(1)
+	msgpack.uint(1)
+	msgpack.array(2)
+	msgpack.uint(3)
+	msgpack.uint(1)
+	msgpack.str("unreachable")
+	msgpack.array(1)
+	msgpack.fixmap(1)
+	msgpack.str("2")
+	msgpack.array(1)
+	msgpack.array(2)
+	msgpack.array(2)
+	msgpack.uint(25)
+	msgpack.uint(38)
+	msgpack.uint(0)
+
+	for _, data := range [][]byte{bincode.Bytes(), msgpack.Bytes()} {
+		a, _, err := Decode(data, ecc.BN254)
+
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, expected, a)
+		message, ok := a.AssertMessage(2)
+		assert.True(t, ok)
+		assert.Equal(t, "x != y", message)
+		assert.Equal(t, []DebugLocation{{File: 0, Start: 25, End: 38}}, a.DebugLocations(2))
+	}
+}
@@ -0,0 +1,362 @@
+package acir
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// msgpackReader reads ACIR serialized with rmp_serde. Structs may come either
+// as arrays (rmp_serde::to_vec) or as maps keyed by field name
+// (rmp_serde::to_vec_named) and enums as single entry maps keyed by the
+// variant index or name.
+type msgpackReader struct {
+	data []byte
+	pos  int
+	// Remaining fields of the named structs being read.
+	structs []int
+	// Optional fields the struct begun with optionalStructBegin has left.
+	optionalFields int
+	modulus        *big.Int
+}
+
+func newMsgpackReader(data []byte, scalarField *big.Int) *msgpackReader {
+	return &msgpackReader{data: data, modulus: scalarField}
+}
+
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *msgpackReader) peek() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return r.data[r.pos], nil
+}
+
+func (r *msgpackReader) uint(size int) (uint64, error) {
+	b, err := r.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// header reads an array or map header and returns its length.
+func (r *msgpackReader) header() (length int, isMap bool, err error) {
+	b, err := r.next(1)
+	if err != nil {
+		return
+	}
+	var n uint64
+	switch tag := b[0]; {
+	case tag&0xf0 == 0x90:
+		n = uint64(tag & 0x0f)
+	case tag&0xf0 == 0x80:
+		n, isMap = uint64(tag&0x0f), true
+	case tag == 0xdc:
+		n, err = r.uint(2)
+	case tag == 0xdd:
+		n, err = r.uint(4)
+	case tag == 0xde:
+		isMap = true
+		n, err = r.uint(2)
+	case tag == 0xdf:
+		isMap = true
+		n, err = r.uint(4)
+	default:
+		err = fmt.Errorf("msgpack: expected array or map, found 0x%02x", tag)
+	}
+	if err == nil && n > uint64(len(r.data)-r.pos) {
+		err = fmt.Errorf("msgpack: length %d exceeds input", n)
+	}
+	length = int(n)
+	return
+}
+
+func (r *msgpackReader) isString() bool {
+	tag, err := r.peek()
+	return err == nil && (tag&0xe0 == 0xa0 || tag == 0xd9 || tag == 0xda || tag == 0xdb)
+}
+
+func (r *msgpackReader) isNil() bool {
+	tag, err := r.peek()
+	return err == nil && tag == 0xc0
+}
+
+func (r *msgpackReader) unsigned() (uint64, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	switch tag := b[0]; {
+	case tag <= 0x7f:
+		return uint64(tag), nil
+	case tag == 0xcc, tag == 0xd0:
+		return r.uint(1)
+	case tag == 0xcd, tag == 0xd1:
+		return r.uint(2)
+	case tag == 0xce, tag == 0xd2:
+		return r.uint(4)
+	case tag == 0xcf, tag == 0xd3:
+		return r.uint(8)
+	default:
+		return 0, fmt.Errorf("msgpack: expected integer, found 0x%02x", tag)
+	}
+}
+
+func (r *msgpackReader) bytes() ([]byte, bool, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return nil, false, err
+	}
+	var length uint64
+	isString := true
+	switch tag := b[0]; {
+	case tag&0xe0 == 0xa0:
+		length = uint64(tag & 0x1f)
+	case tag == 0xd9:
+		length, err = r.uint(1)
+	case tag == 0xda:
+		length, err = r.uint(2)
+	case tag == 0xdb:
+		length, err = r.uint(4)
+	case tag == 0xc4:
+		isString = false
+		length, err = r.uint(1)
+	case tag == 0xc5:
+		isString = false
+		length, err = r.uint(2)
+	case tag == 0xc6:
+		isString = false
+		length, err = r.uint(4)
+	default:
+		err = fmt.Errorf("msgpack: expected string or binary, found 0x%02x", tag)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if length > uint64(len(r.data)-r.pos) {
+		return nil, false, io.ErrUnexpectedEOF
+	}
+	value, err := r.next(int(length))
+	return value, isString, err
+}
+
+func (r *msgpackReader) structBegin(fields []string) error {
+	return r.optionalStructBegin(fields, len(fields))
+}
+
+func (r *msgpackReader) optionalStructBegin(fields []string, required int) error {
+	length, isMap, err := r.header()
+	if err != nil {
+		return err
+	}
+	if length < required || length > len(fields) {
+		return fmt.Errorf("msgpack: expected %d fields, found %d", len(fields), length)
+	}
+	if isMap {
+		r.structs = append(r.structs, length)
+	}
+	if required < len(fields) {
+		r.optionalFields = length - required
+	}
+	return nil
+}
+
+func (r *msgpackReader) field(name string) error {
+	// Leave the named structs that were already read completely.
+	for len(r.structs) > 0 && r.structs[len(r.structs)-1] == 0 {
+		r.structs = r.structs[:len(r.structs)-1]
+	}
+	if len(r.structs) == 0 || !r.isString() {
+		return nil
+	}
+	key, _, err := r.bytes()
+	if err != nil {
+		return err
+	}
+	if string(key) != name {
+		return fmt.Errorf("msgpack: expected field %s, found %s", name, key)
+	}
+	r.structs[len(r.structs)-1]--
+	return nil
+}
+
+func (r *msgpackReader) optionalField(name string) (bool, error) {
+	if r.optionalFields == 0 {
+		return false, nil
+	}
+	r.optionalFields--
+	return true, r.field(name)
+}
+
+func (r *msgpackReader) variantIdentifier(variants []string) (int, error) {
+	if r.isString() {
+		name, _, err := r.bytes()
+		if err != nil {
+			return 0, err
+		}
+		for i, variant := range variants {
+			if variant == string(name) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("msgpack: unknown variant %s", name)
+	}
+
+	index, err := r.unsigned()
+	if err != nil {
+		return 0, err
+	}
+	if index >= uint64(len(variants)) {
+		return 0, fmt.Errorf("msgpack: unknown variant %d", index)
+	}
+	return int(index), nil
+}
+
+func (r *msgpackReader) variant(variants []string, unit bool) (int, error) {
+	tag, err := r.peek()
+	if err != nil {
+		return 0, err
+	}
+	// Unit variants may come without a payload.
+	if unit && tag&0xf0 != 0x80 {
+		return r.variantIdentifier(variants)
+	}
+
+	length, isMap, err := r.header()
+	if err != nil {
+		return 0, err
+	}
+	if length != 1 || !isMap {
+		return 0, errors.New("msgpack: expected a single entry map for enum")
+	}
+	index, err := r.variantIdentifier(variants)
+	if err != nil {
+		return 0, err
+	}
+	if unit && r.isNil() {
+		r.pos++
+	}
+	return index, nil
+}
+
+func (r *msgpackReader) seqLen() (int, error) {
+	length, isMap, err := r.header()
+	if err == nil && isMap {
+		err = errors.New("msgpack: expected array, found map")
+	}
+	return length, err
+}
+
+func (r *msgpackReader) mapLen() (int, error) {
+	length, isMap, err := r.header()
+	if err == nil && !isMap {
+		err = errors.New("msgpack: expected map, found array")
+	}
+	return length, err
+}
+
+func (r *msgpackReader) option() (bool, error) {
+	if r.isNil() {
+		r.pos++
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *msgpackReader) u32() (uint32, error) {
+	value, err := r.unsigned()
+	if err != nil {
+		return 0, err
+	}
+	if value > 0xffffffff {
+		return 0, fmt.Errorf("msgpack: %d overflows u32", value)
+	}
+	return uint32(value), nil
+}
+
+func (r *msgpackReader) u64() (uint64, error) {
+	return r.unsigned()
+}
+
+func (r *msgpackReader) boolean() (bool, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return false, err
+	}
+	switch b[0] {
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	default:
+		return false, fmt.Errorf("msgpack: expected bool, found 0x%02x", b[0])
+	}
+}
+
+func (r *msgpackReader) str() (string, error) {
+	value, isString, err := r.bytes()
+	if err == nil && !isString {
+		err = errors.New("msgpack: expected string, found binary")
+	}
+	return string(value), err
+}
+
+func (r *msgpackReader) felt() (string, error) {
+	value, isString, err := r.bytes()
+	if err != nil {
+		return "", err
+	}
+	if !isString {
+		return hex.EncodeToString(value), nil
+	}
+	return string(value), nil
+}
+
+func (r *msgpackReader) scalarField() *big.Int { return r.modulus }
+
+func (r *msgpackReader) newtype() error {
+	tag, err := r.peek()
+	if err != nil {
+		return err
+	}
+	// Older rmp_serde versions wrap newtypes in a single element array.
+	if tag == 0x91 {
+		r.pos++
+	}
+	return nil
+}
+
+func (r *msgpackReader) witness() (uint32, error) {
+	if err := r.newtype(); err != nil {
+		return 0, err
+	}
+	return r.u32()
+}
+
+func (r *msgpackReader) done() error {
+	if r.pos != len(r.data) {
+		return errors.New("msgpack: trailing bytes after circuit")
+	}
+	return nil
+}
@@ -9,19 +9,20 @@ import (
 	"gnark_backend_ffi/acir/term"
 	backend_helpers "gnark_backend_ffi/internal/backend"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/stretchr/testify/assert"
 )
 
 // TODO: Test error cases.
 
 func TestArithmeticOpcodeUnmarshalJSON(t *testing.T) {
-	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	multiplicand := rand.Uint32()
 	multiplier := rand.Uint32()
 	sum := rand.Uint32()
-	mulTerms := fmt.Sprintf(`[{"coefficient":"%s","multiplicand":%d,"multiplier":%d},{"coefficient":"%s","multiplicand":%d,"multiplier":%d}]`, encodedCoefficient, multiplicand, multiplier, encodedCoefficient, multiplicand, multiplier)
-	addTerms := fmt.Sprintf(`[{"coefficient":"%s","sum":%d},{"coefficient":"%s","sum":%d}]`, encodedCoefficient, sum, encodedCoefficient, sum)
-	encodedConstantTerm, nonEncodedConstantTerm := backend_helpers.RandomEncodedFelt()
+	mulTerms := fmt.Sprintf(`[["%s",%d,%d],["%s",%d,%d]]`, encodedCoefficient, multiplicand, multiplier, encodedCoefficient, multiplicand, multiplier)
+	addTerms := fmt.Sprintf(`[["%s",%d],["%s",%d]]`, encodedCoefficient, sum, encodedCoefficient, sum)
+	encodedConstantTerm, nonEncodedConstantTerm := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	arithmetic_opcode := fmt.Sprintf(`{"Arithmetic": {"mul_terms":%s,"linear_combinations":%s,"q_c":"%s"}}`, mulTerms, addTerms, encodedConstantTerm)
 
 	var r ArithmeticOpcode
@@ -34,13 +35,13 @@ func TestArithmeticOpcodeUnmarshalJSON(t *testing.T) {
 }
 
 func TestArithmeticOpcodesTermUnmarshalJSON(t *testing.T) {
-	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	multiplicand := rand.Uint32()
 	multiplier := rand.Uint32()
 	sum := rand.Uint32()
-	mulTerms := fmt.Sprintf(`[{"coefficient":"%s","multiplicand":%d,"multiplier":%d},{"coefficient":"%s","multiplicand":%d,"multiplier":%d}]`, encodedCoefficient, multiplicand, multiplier, encodedCoefficient, multiplicand, multiplier)
-	addTerms := fmt.Sprintf(`[{"coefficient":"%s","sum":%d},{"coefficient":"%s","sum":%d}]`, encodedCoefficient, sum, encodedCoefficient, sum)
-	encodedConstantTerm, nonEncodedConstantTerm := backend_helpers.RandomEncodedFelt()
+	mulTerms := fmt.Sprintf(`[["%s",%d,%d],["%s",%d,%d]]`, encodedCoefficient, multiplicand, multiplier, encodedCoefficient, multiplicand, multiplier)
+	addTerms := fmt.Sprintf(`[["%s",%d],["%s",%d]]`, encodedCoefficient, sum, encodedCoefficient, sum)
+	encodedConstantTerm, nonEncodedConstantTerm := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	arithmetic_opcode := fmt.Sprintf(`{"Arithmetic": {"mul_terms":%s,"linear_combinations":%s,"q_c":"%s"}}`, mulTerms, addTerms, encodedConstantTerm)
 	arithmetic_opcodes := fmt.Sprintf(`[%s,%s]`, arithmetic_opcode, arithmetic_opcode)
 
@@ -54,3 +55,32 @@ func TestArithmeticOpcodesTermUnmarshalJSON(t *testing.T) {
 		assert.Equal(t, nonEncodedConstantTerm, op.QC)
 	}
 }
+
+func TestArithmeticOpcodeMarshalJSON(t *testing.T) {
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
+	multiplicand := rand.Uint32()
+	multiplier := rand.Uint32()
+	sum := rand.Uint32()
+	mulTerms := fmt.Sprintf(`[["%s",%d,%d]]`, encodedCoefficient, multiplicand, multiplier)
+	addTerms := fmt.Sprintf(`[["%s",%d],["%s",%d]]`, encodedCoefficient, sum, encodedCoefficient, sum)
+	encodedConstantTerm, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
+	arithmetic_opcode := fmt.Sprintf(`{"Arithmetic":{"mul_terms":%s,"linear_combinations":%s,"q_c":"%s"}}`, mulTerms, addTerms, encodedConstantTerm)
+
+	var r ArithmeticOpcode
+	err := json.Unmarshal([]byte(arithmetic_opcode), &r)
+	assert.NoError(t, err)
+	serializedOpcode, err := json.Marshal(r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, arithmetic_opcode, string(serializedOpcode))
+}
+
+func TestOpcodesMarshalJSON(t *testing.T) {
+	encodedConstantTerm, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
+	opcodes := fmt.Sprintf(`[{"Arithmetic":{"mul_terms":[],"linear_combinations":[],"q_c":"%s"}},{"BlackBoxFuncCall":{"name":"RANGE","inputs":[{"witness":1,"num_bits":32}],"outputs":[]}},{"Directive":{"Invert":{"x":3,"result":4}}}]`, encodedConstantTerm)
+
+	serializedOpcodes, err := json.Marshal(UncheckedDeserializeOpcodes(opcodes))
+
+	assert.NoError(t, err)
+	assert.Equal(t, opcodes, string(serializedOpcodes))
+}
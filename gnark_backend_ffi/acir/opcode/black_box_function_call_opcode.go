@@ -46,17 +46,60 @@ var (
 
 type BlackBoxFunction struct {
 	Name    blackBoxFunctionName
-	Inputs  functionInputs
+	Inputs  FunctionInputs
 	Outputs common.Witnesses
 }
 
-type functionInputs = []functionInput
+type FunctionInputs = []FunctionInput
 
-type functionInput struct {
+// FunctionInput is a witness given to a black box function, of NumBits bits.
+type FunctionInput struct {
 	Witness common.Witness
 	NumBits uint32
 }
 
+func blackBoxFunctionNameString(name blackBoxFunctionName) string {
+	for nameString, value := range blackBoxFunctionsNameMap {
+		if value == name {
+			return nameString
+		}
+	}
+	return ""
+}
+
+// BlackBoxFunctionByName returns the black box function Noir names name.
+func BlackBoxFunctionByName(name string) (blackBoxFunctionName, bool) {
+	bbf, ok := blackBoxFunctionsNameMap[name]
+	return bbf, ok
+}
+
+// FunctionName returns the name Noir uses for the black box function.
+func (bbf BlackBoxFunction) FunctionName() string {
+	return blackBoxFunctionNameString(bbf.Name)
+}
+
+type blackBoxFunctionJSON struct {
+	Name    string           `json:"name"`
+	Inputs  FunctionInputs   `json:"inputs"`
+	Outputs common.Witnesses `json:"outputs"`
+}
+
+func (bbf BlackBoxFunction) MarshalJSON() ([]byte, error) {
+	blackBoxFunction := blackBoxFunctionJSON{
+		Name:    bbf.FunctionName(),
+		Inputs:  bbf.Inputs,
+		Outputs: bbf.Outputs,
+	}
+	if blackBoxFunction.Inputs == nil {
+		blackBoxFunction.Inputs = FunctionInputs{}
+	}
+	if blackBoxFunction.Outputs == nil {
+		blackBoxFunction.Outputs = common.Witnesses{}
+	}
+
+	return json.Marshal(map[string]blackBoxFunctionJSON{"BlackBoxFuncCall": blackBoxFunction})
+}
+
 func (bbf *BlackBoxFunction) UnmarshalJSON(data []byte) error {
 	var opcodeMap map[string]interface{}
 	err := json.Unmarshal(data, &opcodeMap)
@@ -79,7 +122,7 @@ func (bbf *BlackBoxFunction) UnmarshalJSON(data []byte) error {
 	}
 
 	var name blackBoxFunctionName
-	var inputs functionInputs
+	var inputs FunctionInputs
 	var outputs common.Witnesses
 
 	if inputsValue, ok := blackBoxFunctionMap["inputs"].([]interface{}); ok {
@@ -96,7 +139,10 @@ func (bbf *BlackBoxFunction) UnmarshalJSON(data []byte) error {
 	}
 
 	if nameValue, ok := blackBoxFunctionMap["name"].(string); ok {
-		name = blackBoxFunctionsNameMap[nameValue]
+		// Unknown names would otherwise silently become AES.
+		if name, ok = blackBoxFunctionsNameMap[nameValue]; !ok {
+			return &json.UnmarshalTypeError{}
+		}
 	} else {
 		return &json.UnmarshalTypeError{}
 	}
@@ -121,7 +167,14 @@ func (bbf *BlackBoxFunction) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-func (fi *functionInput) UnmarshalJSON(data []byte) error {
+func (fi FunctionInput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Witness common.Witness `json:"witness"`
+		NumBits uint32         `json:"num_bits"`
+	}{fi.Witness, fi.NumBits})
+}
+
+func (fi *FunctionInput) UnmarshalJSON(data []byte) error {
 	var functionInputMap map[string]interface{}
 	err := json.Unmarshal(data, &functionInputMap)
 	if err != nil {
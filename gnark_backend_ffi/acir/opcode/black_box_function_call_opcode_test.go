@@ -0,0 +1,25 @@
+package opcode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlackBoxFunctionUnmarshalJSON(t *testing.T) {
+	var b BlackBoxFunction
+	err := json.Unmarshal([]byte(`{"BlackBoxFuncCall":{"name":"RANGE","inputs":[{"witness":1,"num_bits":32}],"outputs":[]}}`), &b)
+
+	assert.NoError(t, err)
+	assert.Equal(t, RANGE, b.Name)
+	assert.Equal(t, FunctionInputs{{Witness: 1, NumBits: 32}}, b.Inputs)
+}
+
+func TestBlackBoxFunctionUnmarshalJSONThrowsErrorRecursiveAggregation(t *testing.T) {
+	// Verifying proofs in circuit isn't supported, see the README.
+	var b BlackBoxFunction
+	err := json.Unmarshal([]byte(`{"BlackBoxFuncCall":{"name":"RecursiveAggregation","inputs":[{"witness":1,"num_bits":254}],"outputs":[2]}}`), &b)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package opcode
+
+import (
+	"encoding/json"
+
+	common "gnark_backend_ffi/internal"
+)
+
+// BrilligOpcode is an unconstrained Brillig call. Its bytecode is run by the
+// ACVM in the Rust backend side to solve witnesses and it adds no
+// constraints, so we only keep the raw call to serialize it back and the
+// witnesses it outputs.
+type BrilligOpcode struct {
+	Brillig json.RawMessage
+	Outputs common.Witnesses
+}
+
+// brilligOutput is either {"Simple": witness} or {"Array": [witness, ...]}.
+type brilligOutput struct {
+	Simple *common.Witness  `json:"Simple"`
+	Array  common.Witnesses `json:"Array"`
+}
+
+func (b BrilligOpcode) MarshalJSON() ([]byte, error) {
+	brillig := b.Brillig
+	if brillig == nil {
+		brillig = json.RawMessage("null")
+	}
+	return json.Marshal(map[string]json.RawMessage{"Brillig": brillig})
+}
+
+func (b *BrilligOpcode) UnmarshalJSON(data []byte) error {
+	opcodeMap, err := unwrapOpcode(data, "Brillig", "inputs", "outputs")
+	if err != nil {
+		return err
+	}
+
+	var outputs []brilligOutput
+	if err := json.Unmarshal(opcodeMap["outputs"], &outputs); err != nil {
+		return err
+	}
+	b.Outputs = nil
+	for _, output := range outputs {
+		if output.Simple != nil {
+			b.Outputs = append(b.Outputs, *output.Simple)
+		}
+		b.Outputs = append(b.Outputs, output.Array...)
+	}
+
+	var brilligMap map[string]json.RawMessage
+	if err := json.Unmarshal(data, &brilligMap); err != nil {
+		return err
+	}
+	b.Brillig = brilligMap["Brillig"]
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package opcode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	common "gnark_backend_ffi/internal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrilligOpcodeUnmarshalJSON(t *testing.T) {
+	brillig := fmt.Sprintf(`{"Brillig":{"inputs":[{"Single":%s}],"outputs":[{"Simple":2},{"Array":[3,4]}],"foreign_call_results":[],"bytecode":[{"Stop":null}],"predicate":null}}`, witnessExpressionJSON(1))
+
+	var o Opcode
+	err := json.Unmarshal([]byte(brillig), &o)
+
+	assert.NoError(t, err)
+	brilligOpcode, ok := o.Data.(*BrilligOpcode)
+	assert.True(t, ok)
+	assert.Equal(t, common.Witnesses{2, 3, 4}, brilligOpcode.Outputs)
+
+	marshaled, err := json.Marshal(o)
+	assert.NoError(t, err)
+	assert.JSONEq(t, brillig, string(marshaled))
+}
+
+func TestOracleOpcodeUnmarshalJSON(t *testing.T) {
+	oracle := fmt.Sprintf(`{"Oracle":{"name":"get_number","inputs":[%s],"input_values":[],"outputs":[2,3],"output_values":[]}}`, witnessExpressionJSON(1))
+
+	var o Opcode
+	err := json.Unmarshal([]byte(oracle), &o)
+
+	assert.NoError(t, err)
+	oracleOpcode, ok := o.Data.(*OracleOpcode)
+	assert.True(t, ok)
+	assert.Equal(t, "get_number", oracleOpcode.Name)
+	assert.Equal(t, common.Witnesses{2, 3}, oracleOpcode.Outputs)
+
+	marshaled, err := json.Marshal(o)
+	assert.NoError(t, err)
+	assert.JSONEq(t, oracle, string(marshaled))
+}
+
+func TestOpcodeUnmarshalJSONNamesUnknownOpcode(t *testing.T) {
+	var o Opcode
+	err := json.Unmarshal([]byte(`{"Unknown":{}}`), &o)
+
+	assert.ErrorContains(t, err, "Unknown opcode")
+}
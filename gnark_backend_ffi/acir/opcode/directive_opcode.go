@@ -2,11 +2,33 @@ package opcode
 
 import "encoding/json"
 
-// DirectiveOpcode is an empty struct because Directives are handled by the
-// PartialWitnessGenerator trait implementation in the Rust backend side.
-// Nevertheless, Directive opcodes objects come in the ACIR JSON and we need to
-// handle them. So this struct exists for serialization purposes.
-type DirectiveOpcode struct{}
+// DirectiveOpcode only keeps the raw Directive because Directives are handled
+// by the PartialWitnessGenerator trait implementation in the Rust backend
+// side. Nevertheless, Directive opcodes objects come in the ACIR JSON and we
+// need to handle them. So this struct exists for serialization purposes.
+type DirectiveOpcode struct {
+	Directive json.RawMessage
+}
+
+// Name returns the kind of the directive, for example Invert or ToRadix.
+func (d DirectiveOpcode) Name() string {
+	var directiveMap map[string]json.RawMessage
+	if json.Unmarshal(d.Directive, &directiveMap) != nil {
+		return ""
+	}
+	for name := range directiveMap {
+		return name
+	}
+	return ""
+}
+
+func (d DirectiveOpcode) MarshalJSON() ([]byte, error) {
+	directive := d.Directive
+	if directive == nil {
+		directive = json.RawMessage("null")
+	}
+	return json.Marshal(map[string]json.RawMessage{"Directive": directive})
+}
 
 // This implementation exists with the only purpose of ensuring that valid
 // Directive opcodes are being skipped and not invalid opcodes (without this
@@ -14,13 +36,14 @@ type DirectiveOpcode struct{}
 // and BlackBoxFunction opcodes and every invalid opcode would pass as a
 // Directive one).
 func (d *DirectiveOpcode) UnmarshalJSON(data []byte) error {
-	var opcodeMap map[string]interface{}
+	var opcodeMap map[string]json.RawMessage
 	err := json.Unmarshal(data, &opcodeMap)
 	if err != nil {
 		return err
 	}
 
-	if _, ok := opcodeMap["Directive"]; ok {
+	if directive, ok := opcodeMap["Directive"]; ok {
+		d.Directive = directive
 		return nil
 	}
 
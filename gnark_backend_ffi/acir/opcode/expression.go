@@ -0,0 +1,101 @@
+package opcode
+
+import (
+	"encoding/json"
+
+	"gnark_backend_ffi/acir/term"
+	backend_helpers "gnark_backend_ffi/internal/backend"
+	"gnark_backend_ffi/internal/felt"
+)
+
+// Expression is Noir's degree two polynomial over witnesses. It is the body
+// of Arithmetic opcodes and the operands of other opcodes.
+type Expression struct {
+	MulTerms    term.MulTerms
+	SimpleTerms term.SimpleTerms
+	QC          felt.Element
+}
+
+// expressionJSON keeps the field order of Noir's Expression.
+type expressionJSON struct {
+	MulTerms           term.MulTerms    `json:"mul_terms"`
+	LinearCombinations term.SimpleTerms `json:"linear_combinations"`
+	QC                 string           `json:"q_c"`
+}
+
+func (e Expression) MarshalJSON() ([]byte, error) {
+	expression := expressionJSON{
+		MulTerms:           e.MulTerms,
+		LinearCombinations: e.SimpleTerms,
+		QC:                 backend_helpers.SerializeFelt(e.QC),
+	}
+	// Noir always serializes the terms as arrays, even when empty.
+	if expression.MulTerms == nil {
+		expression.MulTerms = term.MulTerms{}
+	}
+	if expression.LinearCombinations == nil {
+		expression.LinearCombinations = term.SimpleTerms{}
+	}
+
+	return json.Marshal(expression)
+}
+
+// IsConstant returns true if the expression has no witnesses.
+func (e Expression) IsConstant() bool {
+	return len(e.MulTerms) == 0 && len(e.SimpleTerms) == 0
+}
+
+func (e *Expression) UnmarshalJSON(data []byte) error {
+	var gateMap map[string]interface{}
+	err := json.Unmarshal(data, &gateMap)
+	if err != nil {
+		return err
+	}
+
+	var mulTerms term.MulTerms
+	var addTerms term.SimpleTerms
+	var constantTerm felt.Element
+
+	// Deserialize mul terms.
+	if mulTermsValue, ok := gateMap["mul_terms"].([]interface{}); ok {
+		mulTermsJSON, err := json.Marshal(mulTermsValue)
+		if err != nil {
+			return err
+		}
+		err = json.Unmarshal(mulTermsJSON, &mulTerms)
+		if err != nil {
+			return err
+		}
+	} else {
+		return &json.UnmarshalTypeError{}
+	}
+
+	// Deserialize add terms.
+	if addTermsValue, ok := gateMap["linear_combinations"].([]interface{}); ok {
+		addTermsJSON, err := json.Marshal(addTermsValue)
+		if err != nil {
+			return err
+		}
+		err = json.Unmarshal(addTermsJSON, &addTerms)
+		if err != nil {
+			return err
+		}
+	} else {
+		return &json.UnmarshalTypeError{}
+	}
+
+	// Deserialize constant term.
+	if encodedConstantTerm, ok := gateMap["q_c"].(string); ok {
+		if constantTerm, err = backend_helpers.DeserializeUncheckedFelt(encodedConstantTerm); err != nil {
+			return err
+		}
+	} else {
+		return &json.UnmarshalTypeError{}
+	}
+
+	e.MulTerms = mulTerms
+	e.SimpleTerms = addTerms
+	e.QC = constantTerm
+
+	return nil
+}
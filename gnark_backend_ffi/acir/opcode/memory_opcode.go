@@ -0,0 +1,210 @@
+package opcode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	common "gnark_backend_ffi/internal"
+)
+
+type BlockID = uint32
+
+// MemoryOperation reads Value from, or writes it to, the position Index of a
+// memory block. Operation is the constant 0 for reads and 1 for writes.
+type MemoryOperation struct {
+	Operation Expression
+	Index     Expression
+	Value     Expression
+}
+
+type memoryOperationJSON struct {
+	Operation Expression `json:"operation"`
+	Index     Expression `json:"index"`
+	Value     Expression `json:"value"`
+}
+
+// IsWrite tells whether the operation is a write, failing if the operation
+// is not the constant 0 or 1.
+func (m MemoryOperation) IsWrite() (bool, error) {
+	switch {
+	case !m.Operation.IsConstant():
+		return false, fmt.Errorf("memory operation must be a constant")
+	case m.Operation.QC.IsZero():
+		return false, nil
+	case m.Operation.QC.IsOne():
+		return true, nil
+	default:
+		return false, fmt.Errorf("memory operation must be 0 (read) or 1 (write), found %s", m.Operation.QC.String())
+	}
+}
+
+func (m MemoryOperation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(memoryOperationJSON(m))
+}
+
+func (m *MemoryOperation) UnmarshalJSON(data []byte) error {
+	var operationMap map[string]json.RawMessage
+	err := json.Unmarshal(data, &operationMap)
+	if err != nil {
+		return err
+	}
+
+	for key, expression := range map[string]*Expression{"operation": &m.Operation, "index": &m.Index, "value": &m.Value} {
+		value, ok := operationMap[key]
+		if !ok {
+			return &json.UnmarshalTypeError{}
+		}
+		if err := json.Unmarshal(value, expression); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MemoryInitOpcode initializes the memory block BlockID with the values of
+// the Init witnesses.
+type MemoryInitOpcode struct {
+	BlockID BlockID
+	Init    common.Witnesses
+}
+
+func (m MemoryInitOpcode) MarshalJSON() ([]byte, error) {
+	init := m.Init
+	if init == nil {
+		init = common.Witnesses{}
+	}
+	return json.Marshal(map[string]interface{}{"MemoryInit": struct {
+		BlockID BlockID          `json:"block_id"`
+		Init    common.Witnesses `json:"init"`
+	}{m.BlockID, init}})
+}
+
+func (m *MemoryInitOpcode) UnmarshalJSON(data []byte) error {
+	opcodeMap, err := unwrapOpcode(data, "MemoryInit", "block_id", "init")
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(opcodeMap["block_id"], &m.BlockID); err != nil {
+		return err
+	}
+	return json.Unmarshal(opcodeMap["init"], &m.Init)
+}
+
+// MemoryOpOpcode performs Op on the memory block BlockID. If there is a
+// Predicate the operation only takes place when it evaluates to 1.
+type MemoryOpOpcode struct {
+	BlockID   BlockID
+	Op        MemoryOperation
+	Predicate *Expression
+}
+
+func (m MemoryOpOpcode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{"MemoryOp": struct {
+		BlockID   BlockID         `json:"block_id"`
+		Op        MemoryOperation `json:"op"`
+		Predicate *Expression     `json:"predicate,omitempty"`
+	}{m.BlockID, m.Op, m.Predicate}})
+}
+
+func (m *MemoryOpOpcode) UnmarshalJSON(data []byte) error {
+	opcodeMap, err := unwrapOpcode(data, "MemoryOp", "block_id", "op")
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(opcodeMap["block_id"], &m.BlockID); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(opcodeMap["op"], &m.Op); err != nil {
+		return err
+	}
+	// Older versions of Noir don't emit the predicate.
+	if predicate, ok := opcodeMap["predicate"]; ok {
+		return json.Unmarshal(predicate, &m.Predicate)
+	}
+	return nil
+}
+
+// MemoryBlockOpcode is the memory representation of older versions of Noir,
+// which emit the whole trace of a block in a single Block, ROM or RAM
+// opcode. The first Len operations of the trace initialize the block.
+type MemoryBlockOpcode struct {
+	Kind  string
+	ID    BlockID
+	Len   uint32
+	Trace []MemoryOperation
+}
+
+var memoryBlockKinds = []string{"Block", "ROM", "RAM"}
+
+// ReadOnly tells whether the block can't be written after its
+// initialization.
+func (m MemoryBlockOpcode) ReadOnly() bool {
+	return m.Kind == "ROM"
+}
+
+func (m MemoryBlockOpcode) MarshalJSON() ([]byte, error) {
+	trace := m.Trace
+	if trace == nil {
+		trace = []MemoryOperation{}
+	}
+	return json.Marshal(map[string]interface{}{m.Kind: struct {
+		ID    BlockID           `json:"id"`
+		Len   uint32            `json:"len"`
+		Trace []MemoryOperation `json:"trace"`
+	}{m.ID, m.Len, trace}})
+}
+
+func (m *MemoryBlockOpcode) UnmarshalJSON(data []byte) error {
+	for _, kind := range memoryBlockKinds {
+		opcodeMap, err := unwrapOpcode(data, kind, "id", "len", "trace")
+		if err != nil {
+			continue
+		}
+
+		m.Kind = kind
+		if err := json.Unmarshal(opcodeMap["id"], &m.ID); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(opcodeMap["len"], &m.Len); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(opcodeMap["trace"], &m.Trace); err != nil {
+			return err
+		}
+		if int(m.Len) > len(m.Trace) {
+			return fmt.Errorf("memory block %d has length %d but only %d operations", m.ID, m.Len, len(m.Trace))
+		}
+		return nil
+	}
+
+	return &json.UnmarshalTypeError{}
+}
+
+// unwrapOpcode returns the fields of an opcode serialized as
+// {"<name>": {...}}, failing if any of the required fields is missing.
+func unwrapOpcode(data []byte, name string, requiredFields ...string) (map[string]json.RawMessage, error) {
+	var opcodeMap map[string]json.RawMessage
+	err := json.Unmarshal(data, &opcodeMap)
+	if err != nil {
+		return nil, err
+	}
+
+	opcodeValue, ok := opcodeMap[name]
+	if !ok {
+		return nil, &json.UnmarshalTypeError{}
+	}
+	var fieldsMap map[string]json.RawMessage
+	if err := json.Unmarshal(opcodeValue, &fieldsMap); err != nil {
+		return nil, err
+	}
+	for _, field := range requiredFields {
+		if _, ok := fieldsMap[field]; !ok {
+			return nil, &json.UnmarshalTypeError{}
+		}
+	}
+
+	return fieldsMap, nil
+}
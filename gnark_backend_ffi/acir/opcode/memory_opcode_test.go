@@ -0,0 +1,116 @@
+package opcode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	common "gnark_backend_ffi/internal"
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	zero = "0000000000000000000000000000000000000000000000000000000000000000"
+	one  = "0000000000000000000000000000000000000000000000000000000000000001"
+)
+
+func constantExpressionJSON(constant string) string {
+	return fmt.Sprintf(`{"mul_terms":[],"linear_combinations":[],"q_c":"%s"}`, constant)
+}
+
+func witnessExpressionJSON(witness int) string {
+	return fmt.Sprintf(`{"mul_terms":[],"linear_combinations":[["%s",%d]],"q_c":"%s"}`, one, witness, zero)
+}
+
+func encodedMemoryOperation(operation string, index string, value string) string {
+	return fmt.Sprintf(`{"operation":%s,"index":%s,"value":%s}`, operation, index, value)
+}
+
+func TestMemoryInitOpcodeUnmarshalJSON(t *testing.T) {
+	memoryInit := `{"MemoryInit":{"block_id":3,"init":[1,2,3]}}`
+
+	var o Opcode
+	err := json.Unmarshal([]byte(memoryInit), &o)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &MemoryInitOpcode{BlockID: 3, Init: common.Witnesses{1, 2, 3}}, o.Data)
+
+	marshaled, err := json.Marshal(o)
+	assert.NoError(t, err)
+	assert.JSONEq(t, memoryInit, string(marshaled))
+}
+
+func TestMemoryOpOpcodeUnmarshalJSON(t *testing.T) {
+	read := encodedMemoryOperation(constantExpressionJSON(zero), witnessExpressionJSON(4), witnessExpressionJSON(5))
+	memoryOps := fmt.Sprintf(`[{"MemoryOp":{"block_id":0,"op":%s}},{"MemoryOp":{"block_id":0,"op":%s,"predicate":%s}}]`, read, read, witnessExpressionJSON(6))
+
+	opcodes := UncheckedDeserializeOpcodes(memoryOps)
+
+	assert.Len(t, opcodes, 2)
+	for _, o := range opcodes {
+		memoryOp, ok := o.Data.(*MemoryOpOpcode)
+		assert.True(t, ok)
+		isWrite, err := memoryOp.Op.IsWrite()
+		assert.NoError(t, err)
+		assert.False(t, isWrite)
+		assert.Equal(t, uint32(4), memoryOp.Op.Index.SimpleTerms[0].VariableIndex)
+		assert.Equal(t, uint32(5), memoryOp.Op.Value.SimpleTerms[0].VariableIndex)
+	}
+	assert.Nil(t, opcodes[0].Data.(*MemoryOpOpcode).Predicate)
+	assert.Equal(t, uint32(6), opcodes[1].Data.(*MemoryOpOpcode).Predicate.SimpleTerms[0].VariableIndex)
+
+	marshaled, err := json.Marshal(opcodes)
+	assert.NoError(t, err)
+	assert.JSONEq(t, memoryOps, string(marshaled))
+}
+
+func TestMemoryBlockOpcodeUnmarshalJSON(t *testing.T) {
+	write := encodedMemoryOperation(constantExpressionJSON(one), constantExpressionJSON(zero), witnessExpressionJSON(1))
+	read := encodedMemoryOperation(constantExpressionJSON(zero), witnessExpressionJSON(2), witnessExpressionJSON(3))
+
+	for _, kind := range []string{"Block", "ROM", "RAM"} {
+		memoryBlock := fmt.Sprintf(`{"%s":{"id":1,"len":1,"trace":[%s,%s]}}`, kind, write, read)
+
+		var o Opcode
+		err := json.Unmarshal([]byte(memoryBlock), &o)
+
+		assert.NoError(t, err)
+		block, ok := o.Data.(*MemoryBlockOpcode)
+		assert.True(t, ok)
+		assert.Equal(t, kind, block.Kind)
+		assert.Equal(t, kind == "ROM", block.ReadOnly())
+		assert.Equal(t, uint32(1), block.ID)
+		assert.Len(t, block.Trace, 2)
+		isWrite, err := block.Trace[0].IsWrite()
+		assert.NoError(t, err)
+		assert.True(t, isWrite)
+
+		marshaled, err := json.Marshal(o)
+		assert.NoError(t, err)
+		assert.JSONEq(t, memoryBlock, string(marshaled))
+	}
+}
+
+func TestMemoryBlockOpcodeUnmarshalJSONShortTrace(t *testing.T) {
+	memoryBlock := `{"RAM":{"id":1,"len":2,"trace":[]}}`
+
+	var o MemoryBlockOpcode
+	err := json.Unmarshal([]byte(memoryBlock), &o)
+
+	assert.Error(t, err)
+}
+
+func TestMemoryOperationIsWrite(t *testing.T) {
+	var nonBoolean MemoryOperation
+	nonBoolean.Operation.QC = felt.NewElement(2)
+	_, err := nonBoolean.IsWrite()
+	assert.Error(t, err)
+
+	var nonConstant MemoryOperation
+	err = json.Unmarshal([]byte(encodedMemoryOperation(witnessExpressionJSON(1), witnessExpressionJSON(2), witnessExpressionJSON(3))), &nonConstant)
+	assert.NoError(t, err)
+	_, err = nonConstant.IsWrite()
+	assert.Error(t, err)
+}
@@ -2,14 +2,20 @@ package opcode
 
 import (
 	"encoding/json"
+	"fmt"
 )
 
 type Opcode struct {
 	Data interface{}
 }
 
-// An opcode is either an Arithmetic opcode, a BlackBoxFunction opcode or a
-// Directive opcode.
+func (o Opcode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Data)
+}
+
+// An opcode is either an Arithmetic opcode, a BlackBoxFunction opcode, a
+// Directive opcode, one of the memory opcodes or one of the unconstrained
+// Brillig and Oracle opcodes.
 func (o *Opcode) UnmarshalJSON(b []byte) error {
 	arithmetic_opcode := &ArithmeticOpcode{}
 	err := json.Unmarshal(b, arithmetic_opcode)
@@ -32,5 +38,47 @@ func (o *Opcode) UnmarshalJSON(b []byte) error {
 		return nil
 	}
 
+	memoryInitOpcode := &MemoryInitOpcode{}
+	err = json.Unmarshal(b, memoryInitOpcode)
+	if err == nil {
+		o.Data = memoryInitOpcode
+		return nil
+	}
+
+	memoryOpOpcode := &MemoryOpOpcode{}
+	err = json.Unmarshal(b, memoryOpOpcode)
+	if err == nil {
+		o.Data = memoryOpOpcode
+		return nil
+	}
+
+	memoryBlockOpcode := &MemoryBlockOpcode{}
+	err = json.Unmarshal(b, memoryBlockOpcode)
+	if err == nil {
+		o.Data = memoryBlockOpcode
+		return nil
+	}
+
+	brilligOpcode := &BrilligOpcode{}
+	err = json.Unmarshal(b, brilligOpcode)
+	if err == nil {
+		o.Data = brilligOpcode
+		return nil
+	}
+
+	oracleOpcode := &OracleOpcode{}
+	err = json.Unmarshal(b, oracleOpcode)
+	if err == nil {
+		o.Data = oracleOpcode
+		return nil
+	}
+
+	// Name the opcode, the error of the last attempt says nothing about it.
+	var opcodeMap map[string]json.RawMessage
+	if json.Unmarshal(b, &opcodeMap) == nil && len(opcodeMap) == 1 {
+		for name := range opcodeMap {
+			return fmt.Errorf("unknown or malformed %s opcode: %w", name, err)
+		}
+	}
 	return err
 }
@@ -0,0 +1,47 @@
+package opcode
+
+import (
+	"encoding/json"
+
+	common "gnark_backend_ffi/internal"
+)
+
+// OracleOpcode asks the caller of the ACVM for the values of its outputs.
+// Like Brillig calls, oracles are solved in the Rust backend side and add no
+// constraints, so we only keep the raw opcode, the name of the oracle and the
+// witnesses it outputs.
+type OracleOpcode struct {
+	Oracle  json.RawMessage
+	Name    string
+	Outputs common.Witnesses
+}
+
+func (o OracleOpcode) MarshalJSON() ([]byte, error) {
+	oracle := o.Oracle
+	if oracle == nil {
+		oracle = json.RawMessage("null")
+	}
+	return json.Marshal(map[string]json.RawMessage{"Oracle": oracle})
+}
+
+func (o *OracleOpcode) UnmarshalJSON(data []byte) error {
+	opcodeMap, err := unwrapOpcode(data, "Oracle", "name", "inputs", "outputs")
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(opcodeMap["name"], &o.Name); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(opcodeMap["outputs"], &o.Outputs); err != nil {
+		return err
+	}
+
+	var oracleMap map[string]json.RawMessage
+	if err := json.Unmarshal(data, &oracleMap); err != nil {
+		return err
+	}
+	o.Oracle = oracleMap["Oracle"]
+
+	return nil
+}
@@ -0,0 +1,346 @@
+package acir
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	"gnark_backend_ffi/acir/opcode"
+	common "gnark_backend_ffi/internal"
+
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// Fprint writes a human readable rendering of the circuit to w: one line per
+// opcode followed by a summary of the opcodes it uses.
+func Fprint(w io.Writer, circuit ACIR) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "current witness: w%d\n", circuit.CurrentWitness)
+	publicInputs := make([]string, 0, len(circuit.PublicInputs))
+	for _, publicInput := range circuit.PublicInputs {
+		publicInputs = append(publicInputs, fmt.Sprintf("w%d", publicInput))
+	}
+	fmt.Fprintf(&buf, "public inputs: [%s]\n", strings.Join(publicInputs, ", "))
+
+	width := len(fmt.Sprint(len(circuit.Opcodes)))
+	for i, op := range circuit.Opcodes {
+		fmt.Fprintf(&buf, "%*d: %s", width, i, FormatOpcode(op, circuit.CurveID()))
+		if message, ok := circuit.AssertMessage(i); ok {
+			fmt.Fprintf(&buf, " // assert %q", message)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString(Summarize(circuit).String())
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// FormatOpcode renders an arithmetic opcode as the equation it enforces, for
+// example 2·w3·w4 − w5 + 7 = 0, and the rest of the opcodes as calls with
+// their witnesses. Coefficients in the upper half of the scalar field of the
+// curve are printed as negative numbers.
+func FormatOpcode(op opcode.Opcode, curveID ecc.ID) string {
+	scalarField := curveID.ScalarField()
+	switch op := op.Data.(type) {
+	case *opcode.ArithmeticOpcode:
+		return formatExpression(opcode.Expression(*op), scalarField) + " = 0"
+	case *opcode.BlackBoxFunction:
+		return formatBlackBoxFunction(op)
+	case *opcode.DirectiveOpcode:
+		return "DIRECTIVE " + formatDirectiveValue("", op.Directive, scalarField)
+	case *opcode.MemoryInitOpcode:
+		return fmt.Sprintf("MEMORY INIT b%d = %s", op.BlockID, formatWitnesses(op.Init))
+	case *opcode.MemoryOpOpcode:
+		memoryOperation := "MEMORY " + formatMemoryOperation(op.BlockID, op.Op, scalarField)
+		if op.Predicate != nil {
+			memoryOperation += " if " + formatExpression(*op.Predicate, scalarField)
+		}
+		return memoryOperation
+	case *opcode.MemoryBlockOpcode:
+		memoryOperations := make([]string, 0, len(op.Trace))
+		for _, memoryOperation := range op.Trace {
+			memoryOperations = append(memoryOperations, formatMemoryOperation(op.ID, memoryOperation, scalarField))
+		}
+		return fmt.Sprintf("MEMORY %s b%d (len %d) [%s]", op.Kind, op.ID, op.Len, strings.Join(memoryOperations, ", "))
+	case *opcode.BrilligOpcode:
+		return "BRILLIG → " + formatWitnesses(op.Outputs)
+	case *opcode.OracleOpcode:
+		return fmt.Sprintf("ORACLE %s → %s", op.Name, formatWitnesses(op.Outputs))
+	default:
+		return fmt.Sprintf("unknown opcode %T", op)
+	}
+}
+
+// Summary counts the opcodes of a circuit by type and the black box
+// function calls by function.
+type Summary struct {
+	Opcodes           map[string]int
+	BlackBoxFunctions map[string]int
+	Directives        map[string]int
+}
+
+func Summarize(circuit ACIR) Summary {
+	summary := Summary{
+		Opcodes:           make(map[string]int),
+		BlackBoxFunctions: make(map[string]int),
+		Directives:        make(map[string]int),
+	}
+	for _, op := range circuit.Opcodes {
+		switch op := op.Data.(type) {
+		case *opcode.ArithmeticOpcode:
+			summary.Opcodes["Arithmetic"]++
+		case *opcode.BlackBoxFunction:
+			summary.Opcodes["BlackBoxFuncCall"]++
+			summary.BlackBoxFunctions[op.FunctionName()]++
+		case *opcode.DirectiveOpcode:
+			summary.Opcodes["Directive"]++
+			summary.Directives[op.Name()]++
+		case *opcode.MemoryInitOpcode:
+			summary.Opcodes["MemoryInit"]++
+		case *opcode.MemoryOpOpcode:
+			summary.Opcodes["MemoryOp"]++
+		case *opcode.MemoryBlockOpcode:
+			summary.Opcodes[op.Kind]++
+		case *opcode.BrilligOpcode:
+			summary.Opcodes["Brillig"]++
+		case *opcode.OracleOpcode:
+			summary.Opcodes["Oracle"]++
+		}
+	}
+	return summary
+}
+
+func (s Summary) String() string {
+	var buf bytes.Buffer
+	total := 0
+	for _, count := range s.Opcodes {
+		total += count
+	}
+	fmt.Fprintf(&buf, "opcodes: %d\n", total)
+	writeCounts(&buf, s.Opcodes, "  ")
+	writeCounts(&buf, s.BlackBoxFunctions, "  BlackBoxFuncCall ")
+	writeCounts(&buf, s.Directives, "  Directive ")
+	return buf.String()
+}
+
+func writeCounts(buf *bytes.Buffer, counts map[string]int, prefix string) {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(buf, "%s%s: %d\n", prefix, name, counts[name])
+	}
+}
+
+func formatWitnesses(witnesses common.Witnesses) string {
+	formattedWitnesses := make([]string, 0, len(witnesses))
+	for _, witness := range witnesses {
+		formattedWitnesses = append(formattedWitnesses, fmt.Sprintf("w%d", witness))
+	}
+	return "[" + strings.Join(formattedWitnesses, ", ") + "]"
+}
+
+func formatMemoryOperation(blockID opcode.BlockID, memoryOperation opcode.MemoryOperation, scalarField *big.Int) string {
+	index := formatExpression(memoryOperation.Index, scalarField)
+	value := formatExpression(memoryOperation.Value, scalarField)
+	isWrite, err := memoryOperation.IsWrite()
+	switch {
+	case err != nil:
+		return fmt.Sprintf("b%d[%s] ?? %s", blockID, index, value)
+	case isWrite:
+		return fmt.Sprintf("b%d[%s] := %s", blockID, index, value)
+	default:
+		return fmt.Sprintf("%s = b%d[%s]", value, blockID, index)
+	}
+}
+
+func formatExpression(a opcode.Expression, scalarField *big.Int) string {
+	var buf strings.Builder
+	writeTerm := func(coefficient felt.Element, variables string) {
+		magnitude, negative := signedCoefficient(coefficient, scalarField)
+		if magnitude.Sign() == 0 {
+			return
+		}
+		switch {
+		case buf.Len() == 0 && negative:
+			buf.WriteString("−")
+		case buf.Len() > 0 && negative:
+			buf.WriteString(" − ")
+		case buf.Len() > 0:
+			buf.WriteString(" + ")
+		}
+		if variables == "" {
+			buf.WriteString(magnitude.String())
+			return
+		}
+		if magnitude.Cmp(big.NewInt(1)) != 0 {
+			buf.WriteString(magnitude.String() + "·")
+		}
+		buf.WriteString(variables)
+	}
+
+	for _, mulTerm := range a.MulTerms {
+		writeTerm(mulTerm.Coefficient, fmt.Sprintf("w%d·w%d", mulTerm.MultiplicandIndex, mulTerm.MultiplierIndex))
+	}
+	for _, simpleTerm := range a.SimpleTerms {
+		writeTerm(simpleTerm.Coefficient, fmt.Sprintf("w%d", simpleTerm.VariableIndex))
+	}
+	writeTerm(a.QC, "")
+
+	if buf.Len() == 0 {
+		return "0"
+	}
+	return buf.String()
+}
+
+// signedCoefficient maps the upper half of the field to negative numbers so
+// -1 is printed as such and not as p - 1.
+func signedCoefficient(coefficient felt.Element, scalarField *big.Int) (magnitude *big.Int, negative bool) {
+	magnitude = new(big.Int)
+	coefficient.BigInt(magnitude)
+	half := new(big.Int).Rsh(scalarField, 1)
+	if magnitude.Cmp(half) > 0 {
+		magnitude.Sub(scalarField, magnitude)
+		negative = true
+	}
+	return
+}
+
+func formatBlackBoxFunction(bbf *opcode.BlackBoxFunction) string {
+	inputs := make([]string, 0, len(bbf.Inputs))
+	for _, input := range bbf.Inputs {
+		inputs = append(inputs, fmt.Sprintf("w%d:%d", input.Witness, input.NumBits))
+	}
+	outputs := make([]string, 0, len(bbf.Outputs))
+	for _, output := range bbf.Outputs {
+		outputs = append(outputs, fmt.Sprintf("w%d", output))
+	}
+
+	call := fmt.Sprintf("BLACKBOX %s(%s)", bbf.FunctionName(), strings.Join(inputs, ", "))
+	if len(outputs) == 0 {
+		return call
+	}
+	return fmt.Sprintf("%s → [%s]", call, strings.Join(outputs, ", "))
+}
+
+// Directive fields that hold plain numbers instead of witness indices.
+var directiveNumberFields = map[string]bool{"bit_size": true, "radix": true, "tuple": true, "sort_by": true}
+
+// formatDirectiveValue renders the raw JSON of a directive. Objects become
+// Name(field: value, ...) keeping the field order, expressions are rendered
+// as such and numbers are witnesses unless the field says otherwise.
+func formatDirectiveValue(key string, raw json.RawMessage, scalarField *big.Int) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return "?"
+	}
+
+	switch raw[0] {
+	case '{':
+		fields, err := orderedFields(raw)
+		if err != nil {
+			return string(raw)
+		}
+		if _, ok := fields.get("mul_terms"); ok {
+			var expression opcode.Expression
+			if err := json.Unmarshal(raw, &expression); err != nil {
+				return string(raw)
+			}
+			return formatExpression(expression, scalarField)
+		}
+		// Enum variants are single entry objects.
+		if len(fields) == 1 {
+			return fields[0].key + formatDirectiveArguments(fields[0].key, fields[0].value, scalarField)
+		}
+		return formatDirectiveArguments(key, raw, scalarField)
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return string(raw)
+		}
+		formattedItems := make([]string, 0, len(items))
+		for _, item := range items {
+			formattedItems = append(formattedItems, formatDirectiveValue(key, item, scalarField))
+		}
+		return "[" + strings.Join(formattedItems, ", ") + "]"
+	case 'n':
+		return "none"
+	case 't', 'f', '"':
+		return string(raw)
+	default:
+		if directiveNumberFields[key] {
+			return string(raw)
+		}
+		return "w" + string(raw)
+	}
+}
+
+func formatDirectiveArguments(name string, raw json.RawMessage, scalarField *big.Int) string {
+	fields, err := orderedFields(raw)
+	if err != nil {
+		return "(" + formatDirectiveValue(name, raw, scalarField) + ")"
+	}
+	arguments := make([]string, 0, len(fields))
+	for _, field := range fields {
+		arguments = append(arguments, field.key+": "+formatDirectiveValue(field.key, field.value, scalarField))
+	}
+	return "(" + strings.Join(arguments, ", ") + ")"
+}
+
+type orderedField struct {
+	key   string
+	value json.RawMessage
+}
+
+type orderedFieldList []orderedField
+
+func (l orderedFieldList) get(key string) (json.RawMessage, bool) {
+	for _, field := range l {
+		if field.key == key {
+			return field.value, true
+		}
+	}
+	return nil, false
+}
+
+// orderedFields decodes a JSON object keeping the order of its fields, which
+// a map would lose.
+func orderedFields(raw json.RawMessage) (orderedFieldList, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected object, found %v", token)
+	}
+
+	var fields orderedFieldList
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected key, found %v", token)
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		fields = append(fields, orderedField{key: key, value: value})
+	}
+
+	return fields, nil
+}
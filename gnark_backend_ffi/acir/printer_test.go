@@ -0,0 +1,78 @@
+package acir
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"gnark_backend_ffi/acir/opcode"
+
+	"github.com/consensys/gnark-crypto/ecc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFprint(t *testing.T) {
+	var a ACIR
+	assert.NoError(t, json.Unmarshal([]byte(circuitJSON), &a))
+
+	var buf bytes.Buffer
+	err := Fprint(&buf, a)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `current witness: w4
+public inputs: [w2]
+0: w1 − w2 − w3 − 1 = 0
+1: DIRECTIVE Invert(x: w3, result: w4)
+2: w3·w4 − 1 = 0
+3: BLACKBOX RANGE(w1:32)
+opcodes: 4
+  Arithmetic: 2
+  BlackBoxFuncCall: 1
+  Directive: 1
+  BlackBoxFuncCall RANGE: 1
+  Directive Invert: 1
+`, buf.String())
+}
+
+func TestFormatOpcode(t *testing.T) {
+	opcodes := opcode.UncheckedDeserializeOpcodes(`[
+		{"Arithmetic":{"mul_terms":[["0000000000000000000000000000000000000000000000000000000000000002",3,4]],"linear_combinations":[["` + minusOne + `",5]],"q_c":"0000000000000000000000000000000000000000000000000000000000000007"}},
+		{"Arithmetic":{"mul_terms":[],"linear_combinations":[],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},
+		{"Arithmetic":{"mul_terms":[],"linear_combinations":[["` + minusOne + `",1]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},
+		{"BlackBoxFuncCall":{"name":"SHA256","inputs":[{"witness":1,"num_bits":8},{"witness":2,"num_bits":8}],"outputs":[3,4]}},
+		{"Directive":{"Quotient":{"a":{"mul_terms":[],"linear_combinations":[["` + one + `",1]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"},"b":{"mul_terms":[],"linear_combinations":[],"q_c":"0000000000000000000000000000000000000000000000000000000000000003"},"q":2,"r":3,"predicate":null}}},
+		{"Directive":{"ToRadix":{"a":{"mul_terms":[],"linear_combinations":[["` + one + `",1]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"},"b":[2,3],"radix":2,"is_little_endian":true}}}
+	]`)
+
+	assert.Equal(t, "2·w3·w4 − w5 + 7 = 0", FormatOpcode(opcodes[0], ecc.BN254))
+	assert.Equal(t, "0 = 0", FormatOpcode(opcodes[1], ecc.BN254))
+	assert.Equal(t, "−w1 = 0", FormatOpcode(opcodes[2], ecc.BN254))
+	assert.Equal(t, "BLACKBOX SHA256(w1:8, w2:8) → [w3, w4]", FormatOpcode(opcodes[3], ecc.BN254))
+	assert.Equal(t, "DIRECTIVE Quotient(a: w1, b: 3, q: w2, r: w3, predicate: none)", FormatOpcode(opcodes[4], ecc.BN254))
+	assert.Equal(t, "DIRECTIVE ToRadix(a: w1, b: [w2, w3], radix: 2, is_little_endian: true)", FormatOpcode(opcodes[5], ecc.BN254))
+}
+
+func TestFormatMemoryOpcode(t *testing.T) {
+	zero := "0000000000000000000000000000000000000000000000000000000000000000"
+	witness := func(w string) string {
+		return `{"mul_terms":[],"linear_combinations":[["` + one + `",` + w + `]],"q_c":"` + zero + `"}`
+	}
+	constant := func(c string) string {
+		return `{"mul_terms":[],"linear_combinations":[],"q_c":"` + c + `"}`
+	}
+	read := `{"operation":` + constant(zero) + `,"index":` + witness("4") + `,"value":` + witness("5") + `}`
+	write := `{"operation":` + constant(one) + `,"index":` + constant(zero) + `,"value":` + witness("1") + `}`
+	opcodes := opcode.UncheckedDeserializeOpcodes(`[
+		{"MemoryInit":{"block_id":0,"init":[1,2,3]}},
+		{"MemoryOp":{"block_id":0,"op":` + read + `}},
+		{"MemoryOp":{"block_id":0,"op":` + write + `,"predicate":` + witness("6") + `}},
+		{"ROM":{"id":1,"len":1,"trace":[` + write + `,` + read + `]}}
+	]`)
+
+	assert.Equal(t, "MEMORY INIT b0 = [w1, w2, w3]", FormatOpcode(opcodes[0], ecc.BN254))
+	assert.Equal(t, "MEMORY w5 = b0[w4]", FormatOpcode(opcodes[1], ecc.BN254))
+	assert.Equal(t, "MEMORY b0[0] := w1 if w6", FormatOpcode(opcodes[2], ecc.BN254))
+	assert.Equal(t, "MEMORY ROM b1 (len 1) [b1[0] := w1, w5 = b1[w4]]", FormatOpcode(opcodes[3], ecc.BN254))
+	assert.Equal(t, map[string]int{"MemoryInit": 1, "MemoryOp": 2, "ROM": 1}, Summarize(ACIR{Opcodes: opcodes}).Opcodes)
+}
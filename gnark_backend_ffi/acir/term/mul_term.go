@@ -6,18 +6,25 @@ import (
 
 	common "gnark_backend_ffi/internal"
 	backend_helpers "gnark_backend_ffi/internal/backend"
-
-	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"gnark_backend_ffi/internal/felt"
 )
 
 type MulTerms = []MulTerm
 
 type MulTerm struct {
-	Coefficient       fr_bn254.Element
+	Coefficient       felt.Element
 	MultiplicandIndex common.Witness
 	MultiplierIndex   common.Witness
 }
 
+func (m MulTerm) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{
+		backend_helpers.SerializeFelt(m.Coefficient),
+		m.MultiplicandIndex,
+		m.MultiplierIndex,
+	})
+}
+
 func (m *MulTerm) UnmarshalJSON(data []byte) error {
 	var mulTerm []interface{}
 	err := json.Unmarshal(data, &mulTerm)
@@ -26,13 +33,16 @@ func (m *MulTerm) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	var coefficient fr_bn254.Element
+	var coefficient felt.Element
 	var multiplicand common.Witness
 	var multiplier common.Witness
 
 	// Deserialize coefficient.
 	if coefficientValue, ok := mulTerm[0].(string); ok {
-		coefficient = backend_helpers.DeserializeFelt(coefficientValue)
+		if coefficient, err = backend_helpers.DeserializeUncheckedFelt(coefficientValue); err != nil {
+			log.Print(err)
+			return err
+		}
 	} else {
 		log.Print("Error: couldn't deserialize coefficient.")
 		return &json.UnmarshalTypeError{}
@@ -4,20 +4,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 
 	backend_helpers "gnark_backend_ffi/internal/backend"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/stretchr/testify/assert"
 )
 
 // TODO: Test error cases.
 
 func TestMulTermUnmarshalJSON(t *testing.T) {
-	encodedCoefficient, nonEncodedCoefficient := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, nonEncodedCoefficient := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	multiplicand := rand.Uint32()
 	multiplier := rand.Uint32()
-	mulTerm := fmt.Sprintf(`{"coefficient":"%s","multiplicand":%d,"multiplier":%d}`, encodedCoefficient, multiplicand, multiplier)
+	mulTerm := fmt.Sprintf(`["%s",%d,%d]`, encodedCoefficient, multiplicand, multiplier)
 
 	var m MulTerm
 	err := json.Unmarshal([]byte(mulTerm), &m)
@@ -29,10 +31,10 @@ func TestMulTermUnmarshalJSON(t *testing.T) {
 }
 
 func TestMulTermsUnmarshalJSON(t *testing.T) {
-	encodedCoefficient, nonEncodedCoefficient := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, nonEncodedCoefficient := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	multiplicand := rand.Uint32()
 	multiplier := rand.Uint32()
-	mulTerms := fmt.Sprintf(`[{"coefficient":"%s","multiplicand":%d,"multiplier":%d},{"coefficient":"%s","multiplicand":%d,"multiplier":%d}]`, encodedCoefficient, multiplicand, multiplier, encodedCoefficient, multiplicand, multiplier)
+	mulTerms := fmt.Sprintf(`[["%s",%d,%d],["%s",%d,%d]]`, encodedCoefficient, multiplicand, multiplier, encodedCoefficient, multiplicand, multiplier)
 
 	var m []MulTerm
 	err := json.Unmarshal([]byte(mulTerms), &m)
@@ -46,7 +48,7 @@ func TestMulTermsUnmarshalJSON(t *testing.T) {
 }
 
 func TestMulTermUnmarshalJSONThrowsErrorWrongJSONFormatCoefficient(t *testing.T) {
-	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	multiplicand := rand.Uint32()
 	multiplier := rand.Uint32()
 	mulTerm := fmt.Sprintf(`{"coefficien":"%s","multiplicand":%d,"multiplier":%d}`, encodedCoefficient, multiplicand, multiplier)
@@ -57,7 +59,7 @@ func TestMulTermUnmarshalJSONThrowsErrorWrongJSONFormatCoefficient(t *testing.T)
 }
 
 func TestMulTermUnmarshalJSONThrowsErrorWrongJSONFormatMultiplicand(t *testing.T) {
-	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	multiplicand := rand.Uint32()
 	multiplier := rand.Uint32()
 	mulTerm := fmt.Sprintf(`{"coefficient":"%s","multipliand":%d,"multiplier":%d}`, encodedCoefficient, multiplicand, multiplier)
@@ -68,7 +70,7 @@ func TestMulTermUnmarshalJSONThrowsErrorWrongJSONFormatMultiplicand(t *testing.T
 }
 
 func TestMulTermUnmarshalJSONThrowsErrorWrongJSONFormatMultiplier(t *testing.T) {
-	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	multiplicand := rand.Uint32()
 	multiplier := rand.Uint32()
 	mulTerm := fmt.Sprintf(`{"coefficient":"%s","multiplicand":%d,"ultiplier":%d}`, encodedCoefficient, multiplicand, multiplier)
@@ -89,3 +91,32 @@ func TestMulTermUnmarshalJSONThrowsErrorOddCoefficientLength(t *testing.T) {
 	err := json.Unmarshal([]byte(mulTerm), &m)
 	assert.Error(t, err)
 }
+
+func TestMulTermMarshalJSON(t *testing.T) {
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
+	multiplicand := rand.Uint32()
+	multiplier := rand.Uint32()
+	mulTerm := fmt.Sprintf(`["%s",%d,%d]`, encodedCoefficient, multiplicand, multiplier)
+
+	m := UncheckedDeserializeMulTerm(mulTerm)
+	serializedMulTerm, err := json.Marshal(m)
+
+	assert.NoError(t, err)
+	assert.Equal(t, mulTerm, string(serializedMulTerm))
+}
+
+func TestMulTermMarshalJSONCanonicalCoefficient(t *testing.T) {
+	m := UncheckedDeserializeMulTerm(`["` + strings.Repeat("00", 47) + `0A",1,2]`)
+	serializedMulTerm, err := json.Marshal(m)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `["000000000000000000000000000000000000000000000000000000000000000a",1,2]`, string(serializedMulTerm))
+}
+
+func TestMulTermUnmarshalJSONThrowsErrorWrongCoefficientLength(t *testing.T) {
+	for _, coefficient := range []string{"0A", strings.Repeat("00", 33), "0g"} {
+		var m MulTerm
+		err := json.Unmarshal([]byte(`["`+coefficient+`",1,2]`), &m)
+		assert.Error(t, err, coefficient)
+	}
+}
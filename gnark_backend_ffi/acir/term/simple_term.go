@@ -6,17 +6,23 @@ import (
 
 	common "gnark_backend_ffi/internal"
 	backend_helpers "gnark_backend_ffi/internal/backend"
-
-	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"gnark_backend_ffi/internal/felt"
 )
 
 type SimpleTerms = []SimpleTerm
 
 type SimpleTerm struct {
-	Coefficient   fr_bn254.Element
+	Coefficient   felt.Element
 	VariableIndex common.Witness
 }
 
+func (m SimpleTerm) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{
+		backend_helpers.SerializeFelt(m.Coefficient),
+		m.VariableIndex,
+	})
+}
+
 func (m *SimpleTerm) UnmarshalJSON(data []byte) error {
 	var linearTerm []interface{}
 	err := json.Unmarshal(data, &linearTerm)
@@ -25,12 +31,15 @@ func (m *SimpleTerm) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	var coefficient fr_bn254.Element
+	var coefficient felt.Element
 	var variable common.Witness
 
 	// Deserialize coefficient.
 	if coefficientValue, ok := linearTerm[0].(string); ok {
-		coefficient = backend_helpers.DeserializeFelt(coefficientValue)
+		if coefficient, err = backend_helpers.DeserializeUncheckedFelt(coefficientValue); err != nil {
+			log.Print(err)
+			return err
+		}
 	} else {
 		log.Print("Error: couldn't deserialize coefficient.")
 		return &json.UnmarshalTypeError{}
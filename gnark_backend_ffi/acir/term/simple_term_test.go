@@ -8,13 +8,14 @@ import (
 
 	backend_helpers "gnark_backend_ffi/internal/backend"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestAddTermUnmarshalJSON(t *testing.T) {
-	encodedCoefficient, nonEncodedCoefficient := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, nonEncodedCoefficient := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	sum := rand.Uint32()
-	addTerm := fmt.Sprintf(`{"coefficient":"%s","sum":%d}`, encodedCoefficient, sum)
+	addTerm := fmt.Sprintf(`["%s",%d]`, encodedCoefficient, sum)
 
 	var a SimpleTerm
 	err := json.Unmarshal([]byte(addTerm), &a)
@@ -25,9 +26,9 @@ func TestAddTermUnmarshalJSON(t *testing.T) {
 }
 
 func TestAddTermsUnmarshalJSON(t *testing.T) {
-	encodedCoefficient, nonEncodedCoefficient := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, nonEncodedCoefficient := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	sum := rand.Uint32()
-	addTerms := fmt.Sprintf(`[{"coefficient":"%s","sum":%d},{"coefficient":"%s","sum":%d}]`, encodedCoefficient, sum, encodedCoefficient, sum)
+	addTerms := fmt.Sprintf(`[["%s",%d],["%s",%d]]`, encodedCoefficient, sum, encodedCoefficient, sum)
 
 	var a []SimpleTerm
 	err := json.Unmarshal([]byte(addTerms), &a)
@@ -40,7 +41,7 @@ func TestAddTermsUnmarshalJSON(t *testing.T) {
 }
 
 func TestAddTermUnmarshalJSONThrowsErrorWrongJSONFormatCoefficient(t *testing.T) {
-	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	sum := rand.Uint32()
 	addTerm := fmt.Sprintf(`{"coeff":"%s","sum":%d}`, encodedCoefficient, sum)
 
@@ -50,7 +51,7 @@ func TestAddTermUnmarshalJSONThrowsErrorWrongJSONFormatCoefficient(t *testing.T)
 }
 
 func TestAddTermUnmarshalJSONThrowsErrorWrongJSONFormatSum(t *testing.T) {
-	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt()
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
 	sum := rand.Uint32()
 	addTerm := fmt.Sprintf(`{"coefficient":"%s","sm":%d}`, encodedCoefficient, sum)
 
@@ -68,3 +69,27 @@ func TestAddTermUnmarshalJSONThrowsErrorOddCoefficientLength(t *testing.T) {
 	err := json.Unmarshal([]byte(addTerm), &a)
 	assert.Error(t, err)
 }
+
+func TestAddTermMarshalJSON(t *testing.T) {
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
+	sum := rand.Uint32()
+	addTerm := fmt.Sprintf(`["%s",%d]`, encodedCoefficient, sum)
+
+	a := UncheckedDeserializeSimpleTerm(addTerm)
+	serializedAddTerm, err := json.Marshal(a)
+
+	assert.NoError(t, err)
+	assert.Equal(t, addTerm, string(serializedAddTerm))
+}
+
+func TestAddTermsMarshalJSON(t *testing.T) {
+	encodedCoefficient, _ := backend_helpers.RandomEncodedFelt(ecc.BN254.ScalarField())
+	sum := rand.Uint32()
+	addTerms := fmt.Sprintf(`[["%s",%d],["%s",%d]]`, encodedCoefficient, sum, encodedCoefficient, sum)
+
+	a := UncheckedDeserializeSimpleTerms(addTerms)
+	serializedAddTerms, err := json.Marshal(a)
+
+	assert.NoError(t, err)
+	assert.Equal(t, addTerms, string(serializedAddTerms))
+}
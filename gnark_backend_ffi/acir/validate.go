@@ -0,0 +1,219 @@
+package acir
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"gnark_backend_ffi/acir/opcode"
+	common "gnark_backend_ffi/internal"
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// ValidationError is a structural problem found in an ACIR. OpcodeIndex is
+// the position of the offending opcode, or -1 if the problem is not related
+// to a particular opcode.
+type ValidationError struct {
+	OpcodeIndex int
+	Message     string
+}
+
+func (e ValidationError) Error() string {
+	if e.OpcodeIndex < 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("opcode %d: %s", e.OpcodeIndex, e.Message)
+}
+
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, validationError := range e {
+		messages = append(messages, validationError.Error())
+	}
+	return fmt.Sprintf("invalid ACIR (%d problems): %s", len(e), strings.Join(messages, "; "))
+}
+
+// Validate checks that every witness referenced by the circuit is in range,
+// that coefficients are canonical elements of the scalar field of the curve
+// and that black box function inputs are sound. It returns all the problems
+// it finds as ValidationErrors, or nil if the circuit is valid.
+func Validate(circuit ACIR) error {
+	v := validator{
+		currentWitness: circuit.CurrentWitness,
+		curveID:        circuit.CurveID(),
+		scalarField:    circuit.CurveID().ScalarField(),
+		feltBits:       circuit.CurveID().ScalarField().BitLen(),
+		opcodeIndex:    -1,
+		memoryBlocks:   make(map[opcode.BlockID]int),
+	}
+
+	seenPublicInputs := make(map[common.Witness]bool, len(circuit.PublicInputs))
+	for _, publicInput := range circuit.PublicInputs {
+		v.checkWitness(publicInput, "public input")
+		if seenPublicInputs[publicInput] {
+			v.report("public input w%d is repeated", publicInput)
+		}
+		seenPublicInputs[publicInput] = true
+	}
+
+	for i, op := range circuit.Opcodes {
+		v.opcodeIndex = i
+		switch op := op.Data.(type) {
+		case *opcode.ArithmeticOpcode:
+			v.checkArithmeticOpcode(op)
+		case *opcode.BlackBoxFunction:
+			v.checkBlackBoxFunction(op)
+		case *opcode.DirectiveOpcode:
+			// Directives are solved by the Rust backend.
+		case *opcode.MemoryInitOpcode:
+			v.checkMemoryInit(op)
+		case *opcode.MemoryOpOpcode:
+			v.checkMemoryOp(op)
+		case *opcode.MemoryBlockOpcode:
+			v.checkMemoryBlock(op)
+		case *opcode.BrilligOpcode:
+			for _, output := range op.Outputs {
+				v.checkWitness(output, "Brillig output")
+			}
+		case *opcode.OracleOpcode:
+			for _, output := range op.Outputs {
+				v.checkWitness(output, "oracle output")
+			}
+		default:
+			v.report("unknown opcode type %T", op)
+		}
+	}
+
+	if len(v.errors) > 0 {
+		return v.errors
+	}
+	return nil
+}
+
+type validator struct {
+	currentWitness common.Witness
+	curveID        ecc.ID
+	scalarField    *big.Int
+	// feltBits is the size of the scalar field of the curve.
+	feltBits    int
+	opcodeIndex int
+	errors      ValidationErrors
+	// Lengths of the memory blocks initialized so far.
+	memoryBlocks map[opcode.BlockID]int
+}
+
+func (v *validator) report(format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{
+		OpcodeIndex: v.opcodeIndex,
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+// Witness indices start at 1, index 0 is never assigned by Noir.
+func (v *validator) checkWitness(witness common.Witness, role string) {
+	if witness == 0 || witness > v.currentWitness {
+		v.report("%s w%d is out of range [1, %d]", role, witness, v.currentWitness)
+	}
+}
+
+func (v *validator) checkArithmeticOpcode(a *opcode.ArithmeticOpcode) {
+	v.checkExpression(opcode.Expression(*a))
+}
+
+func (v *validator) checkExpression(e opcode.Expression) {
+	for i, mulTerm := range e.MulTerms {
+		v.checkWitness(mulTerm.MultiplicandIndex, "multiplicand")
+		v.checkWitness(mulTerm.MultiplierIndex, "multiplier")
+		v.checkCoefficient(mulTerm.Coefficient, fmt.Sprintf("mul term %d", i))
+	}
+	for i, simpleTerm := range e.SimpleTerms {
+		v.checkWitness(simpleTerm.VariableIndex, "linear combination")
+		v.checkCoefficient(simpleTerm.Coefficient, fmt.Sprintf("linear combination %d", i))
+	}
+	v.checkCoefficient(e.QC, "constant term")
+}
+
+// Noir only serializes canonical felts, a larger one was meant for another
+// curve.
+func (v *validator) checkCoefficient(coefficient felt.Element, role string) {
+	if !coefficient.IsCanonical(v.scalarField) {
+		v.report("%s coefficient is not an element of the scalar field of %s", role, v.curveID)
+	}
+}
+
+func (v *validator) checkMemoryOperation(blockID opcode.BlockID, memoryOperation opcode.MemoryOperation) (isWrite bool) {
+	isWrite, err := memoryOperation.IsWrite()
+	if err != nil {
+		v.report("memory block %d: %s", blockID, err)
+	}
+	v.checkExpression(memoryOperation.Index)
+	v.checkExpression(memoryOperation.Value)
+	// Constant indices must be in the block even under a predicate, dynamic
+	// ones are checked by the constraints.
+	if length, ok := v.memoryBlocks[blockID]; ok && memoryOperation.Index.IsConstant() {
+		var index big.Int
+		memoryOperation.Index.QC.BigInt(&index)
+		if !index.IsUint64() || index.Uint64() >= uint64(length) {
+			v.report("memory block %d: index %s is out of bounds [0, %d)", blockID, index.String(), length)
+		}
+	}
+	return
+}
+
+func (v *validator) checkMemoryInit(m *opcode.MemoryInitOpcode) {
+	if _, ok := v.memoryBlocks[m.BlockID]; ok {
+		v.report("memory block %d is initialized twice", m.BlockID)
+	}
+	v.memoryBlocks[m.BlockID] = len(m.Init)
+	for _, value := range m.Init {
+		v.checkWitness(value, "memory initial value")
+	}
+}
+
+func (v *validator) checkMemoryOp(m *opcode.MemoryOpOpcode) {
+	if _, ok := v.memoryBlocks[m.BlockID]; !ok {
+		v.report("memory block %d is used before being initialized", m.BlockID)
+	}
+	v.checkMemoryOperation(m.BlockID, m.Op)
+	if m.Predicate != nil {
+		v.checkExpression(*m.Predicate)
+	}
+}
+
+func (v *validator) checkMemoryBlock(m *opcode.MemoryBlockOpcode) {
+	if _, ok := v.memoryBlocks[m.ID]; ok {
+		v.report("memory block %d is declared twice", m.ID)
+	}
+	v.memoryBlocks[m.ID] = int(m.Len)
+	for i, memoryOperation := range m.Trace {
+		isWrite := v.checkMemoryOperation(m.ID, memoryOperation)
+		if i < int(m.Len) && !isWrite {
+			v.report("memory block %d: operation %d must initialize the block", m.ID, i)
+		}
+		if i >= int(m.Len) && isWrite && m.ReadOnly() {
+			v.report("memory block %d: operation %d writes to a ROM", m.ID, i)
+		}
+	}
+}
+
+func (v *validator) checkBlackBoxFunction(bbf *opcode.BlackBoxFunction) {
+	for _, input := range bbf.Inputs {
+		v.checkWitness(input.Witness, "black box function input")
+		if int(input.NumBits) > v.feltBits {
+			v.report("black box function input w%d has %d bits, more than the %d of a field element", input.Witness, input.NumBits, v.feltBits)
+		}
+		switch bbf.Name {
+		case opcode.AND, opcode.XOR, opcode.RANGE:
+			if input.NumBits == 0 {
+				v.report("black box function input w%d has 0 bits", input.Witness)
+			}
+		}
+	}
+	for _, output := range bbf.Outputs {
+		v.checkWitness(output, "black box function output")
+	}
+}
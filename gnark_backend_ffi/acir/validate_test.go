@@ -0,0 +1,129 @@
+package acir
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	var a ACIR
+	assert.NoError(t, json.Unmarshal([]byte(circuitJSON), &a))
+
+	assert.NoError(t, Validate(a))
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	circuit := `{"current_witness_index":3,"opcodes":[{"Arithmetic":{"mul_terms":[["` + one + `",1,4]],"linear_combinations":[["` + one + `",0]],"q_c":"` + one + `"}},{"Directive":{"Invert":{"x":3,"result":4}}},{"BlackBoxFuncCall":{"name":"RANGE","inputs":[{"witness":2,"num_bits":0},{"witness":3,"num_bits":255}],"outputs":[5]}}],"public_inputs":[2,2,7]}`
+	var a ACIR
+	assert.NoError(t, json.Unmarshal([]byte(circuit), &a))
+
+	err := Validate(a)
+
+	var validationErrors ValidationErrors
+	assert.ErrorAs(t, err, &validationErrors)
+	assert.Equal(t, []int{-1, -1, 0, 0, 2, 2, 2}, opcodeIndices(validationErrors))
+	assert.Contains(t, err.Error(), "public input w2 is repeated")
+	assert.Contains(t, err.Error(), "opcode 0: multiplier w4 is out of range [1, 3]")
+	assert.Contains(t, err.Error(), "opcode 0: linear combination w0 is out of range [1, 3]")
+	assert.Contains(t, err.Error(), "opcode 2: black box function input w2 has 0 bits")
+	assert.Contains(t, err.Error(), "opcode 2: black box function output w5 is out of range [1, 3]")
+}
+
+func TestValidateRejectsNonCanonicalCoefficients(t *testing.T) {
+	// The BN254 modulus, which is an element of the larger scalar field of
+	// BW6-761.
+	modulus := fmt.Sprintf("%064x", ecc.BN254.ScalarField())
+	circuit := `{"current_witness_index":2,"opcodes":[{"Arithmetic":{"mul_terms":[["` + modulus + `",1,2]],"linear_combinations":[["` + one + `",1],["` + modulus + `",2]],"q_c":"` + modulus + `"}}],"public_inputs":[]}`
+	var a ACIR
+	assert.NoError(t, json.Unmarshal([]byte(circuit), &a))
+
+	err := Validate(a)
+
+	var validationErrors ValidationErrors
+	assert.ErrorAs(t, err, &validationErrors)
+	assert.Equal(t, []int{0, 0, 0}, opcodeIndices(validationErrors))
+	assert.Contains(t, err.Error(), "opcode 0: mul term 0 coefficient is not an element of the scalar field of bn254")
+	assert.Contains(t, err.Error(), "opcode 0: linear combination 1 coefficient is not an element of the scalar field of bn254")
+	assert.Contains(t, err.Error(), "opcode 0: constant term coefficient is not an element of the scalar field of bn254")
+
+	a.Curve = ecc.BW6_761
+	assert.NoError(t, Validate(a))
+}
+
+func TestUnmarshalJSONThrowsErrorUnknownBlackBoxFunction(t *testing.T) {
+	circuit := `{"current_witness_index":1,"opcodes":[{"BlackBoxFuncCall":{"name":"Unknown","inputs":[],"outputs":[]}}],"public_inputs":[]}`
+	var a ACIR
+	assert.Error(t, json.Unmarshal([]byte(circuit), &a))
+}
+
+func opcodeIndices(validationErrors ValidationErrors) []int {
+	indices := make([]int, 0, len(validationErrors))
+	for _, validationError := range validationErrors {
+		indices = append(indices, validationError.OpcodeIndex)
+	}
+	return indices
+}
+
+func TestValidateMemoryOpcodes(t *testing.T) {
+	zero := "0000000000000000000000000000000000000000000000000000000000000000"
+	witness := func(w string) string {
+		return `{"mul_terms":[],"linear_combinations":[["` + one + `",` + w + `]],"q_c":"` + zero + `"}`
+	}
+	constant := func(c string) string {
+		return `{"mul_terms":[],"linear_combinations":[],"q_c":"` + c + `"}`
+	}
+	read := `{"operation":` + constant(zero) + `,"index":` + witness("2") + `,"value":` + witness("3") + `}`
+	write := `{"operation":` + constant(one) + `,"index":` + constant(zero) + `,"value":` + witness("1") + `}`
+	badOperation := `{"operation":` + witness("1") + `,"index":` + constant(zero) + `,"value":` + witness("9") + `}`
+	circuit := `{"current_witness_index":3,"opcodes":[
+		{"MemoryOp":{"block_id":0,"op":` + read + `}},
+		{"MemoryInit":{"block_id":0,"init":[1,2]}},
+		{"MemoryOp":{"block_id":0,"op":` + badOperation + `}},
+		{"ROM":{"id":1,"len":1,"trace":[` + read + `,` + write + `]}}
+	],"public_inputs":[]}`
+	var a ACIR
+	assert.NoError(t, json.Unmarshal([]byte(circuit), &a))
+
+	err := Validate(a)
+
+	var validationErrors ValidationErrors
+	assert.ErrorAs(t, err, &validationErrors)
+	assert.Equal(t, []int{0, 2, 2, 3, 3}, opcodeIndices(validationErrors))
+	assert.Contains(t, err.Error(), "opcode 0: memory block 0 is used before being initialized")
+	assert.Contains(t, err.Error(), "opcode 2: memory block 0: memory operation must be a constant")
+	assert.Contains(t, err.Error(), "opcode 2: linear combination w9 is out of range [1, 3]")
+	assert.Contains(t, err.Error(), "opcode 3: memory block 1: operation 0 must initialize the block")
+	assert.Contains(t, err.Error(), "opcode 3: memory block 1: operation 1 writes to a ROM")
+}
+
+func TestValidateMemoryConstantIndexOutOfBounds(t *testing.T) {
+	zero := "0000000000000000000000000000000000000000000000000000000000000000"
+	two := "0000000000000000000000000000000000000000000000000000000000000002"
+	witness := func(w string) string {
+		return `{"mul_terms":[],"linear_combinations":[["` + one + `",` + w + `]],"q_c":"` + zero + `"}`
+	}
+	constant := func(c string) string {
+		return `{"mul_terms":[],"linear_combinations":[],"q_c":"` + c + `"}`
+	}
+	read := `{"operation":` + constant(zero) + `,"index":` + constant(two) + `,"value":` + witness("3") + `}`
+	write := `{"operation":` + constant(one) + `,"index":` + constant(one) + `,"value":` + witness("1") + `}`
+	circuit := `{"current_witness_index":3,"opcodes":[
+		{"MemoryInit":{"block_id":0,"init":[1,2]}},
+		{"MemoryOp":{"block_id":0,"op":` + read + `,"predicate":` + witness("1") + `}},
+		{"RAM":{"id":1,"len":1,"trace":[` + write + `]}}
+	],"public_inputs":[]}`
+	var a ACIR
+	assert.NoError(t, json.Unmarshal([]byte(circuit), &a))
+
+	err := Validate(a)
+
+	var validationErrors ValidationErrors
+	assert.ErrorAs(t, err, &validationErrors)
+	assert.Equal(t, []int{1, 2}, opcodeIndices(validationErrors))
+	assert.Contains(t, err.Error(), "opcode 1: memory block 0: index 2 is out of bounds [0, 2)")
+	assert.Contains(t, err.Error(), "opcode 2: memory block 1: index 1 is out of bounds [0, 1)")
+}
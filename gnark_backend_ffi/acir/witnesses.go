@@ -0,0 +1,75 @@
+package acir
+
+import (
+	"fmt"
+
+	"gnark_backend_ffi/acir/opcode"
+	common "gnark_backend_ffi/internal"
+	"gnark_backend_ffi/internal/felt"
+)
+
+// UnconstrainedWitnesses maps every witness output by a Brillig or Oracle
+// opcode to the index of that opcode. No constraint is built for these
+// opcodes, so the ACVM must have solved their outputs before proving.
+func UnconstrainedWitnesses(circuit ACIR) map[common.Witness]int {
+	unconstrainedWitnesses := make(map[common.Witness]int)
+	for i, op := range circuit.Opcodes {
+		var outputs common.Witnesses
+		switch op := op.Data.(type) {
+		case *opcode.BrilligOpcode:
+			outputs = op.Outputs
+		case *opcode.OracleOpcode:
+			outputs = op.Outputs
+		}
+		for _, output := range outputs {
+			unconstrainedWitnesses[output] = i
+		}
+	}
+	return unconstrainedWitnesses
+}
+
+// CheckWitnesses checks that values assigns exactly the witnesses of the
+// circuit, w1 being the first value, and that the witnesses output by
+// Brillig or Oracle opcodes were solved. solved lists the witnesses the ACVM
+// solved, the others only have a placeholder value. A nil solved means every
+// value was solved. Unsolved outputs are reported against the opcode that
+// should have produced them. It returns the problems as ValidationErrors.
+func CheckWitnesses(circuit ACIR, values felt.Vector, solved common.Witnesses) error {
+	var errors ValidationErrors
+	unconstrainedWitnesses := UnconstrainedWitnesses(circuit)
+
+	if len(values) > int(circuit.CurrentWitness) {
+		errors = append(errors, ValidationError{
+			OpcodeIndex: -1,
+			Message:     fmt.Sprintf("%d values for %d witnesses", len(values), circuit.CurrentWitness),
+		})
+	}
+	var solvedWitnesses map[common.Witness]bool
+	if solved != nil {
+		solvedWitnesses = make(map[common.Witness]bool, len(solved))
+		for _, witness := range solved {
+			solvedWitnesses[witness] = true
+		}
+	}
+	for witness := common.Witness(1); witness <= circuit.CurrentWitness; witness++ {
+		hasValue := int(witness) <= len(values)
+		opcodeIndex, unconstrained := unconstrainedWitnesses[witness]
+		switch {
+		case unconstrained && (!hasValue || solvedWitnesses != nil && !solvedWitnesses[witness]):
+			errors = append(errors, ValidationError{
+				OpcodeIndex: opcodeIndex,
+				Message:     fmt.Sprintf("w%d has no value, it must be solved by %s", witness, FormatOpcode(circuit.Opcodes[opcodeIndex], circuit.CurveID())),
+			})
+		case !hasValue:
+			errors = append(errors, ValidationError{
+				OpcodeIndex: -1,
+				Message:     fmt.Sprintf("w%d has no value", witness),
+			})
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
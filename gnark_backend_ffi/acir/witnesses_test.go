@@ -0,0 +1,56 @@
+package acir
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const unconstrainedCircuitJSON = `{"current_witness_index":4,"opcodes":[{"Brillig":{"inputs":[],"outputs":[{"Simple":2}],"foreign_call_results":[],"bytecode":[],"predicate":null}},{"Oracle":{"name":"get_number","inputs":[],"input_values":[],"outputs":[3],"output_values":[]}}],"public_inputs":[]}`
+
+func TestUnconstrainedWitnesses(t *testing.T) {
+	var a ACIR
+	assert.NoError(t, json.Unmarshal([]byte(unconstrainedCircuitJSON), &a))
+
+	assert.NoError(t, Validate(a))
+	assert.Equal(t, map[uint32]int{2: 0, 3: 1}, UnconstrainedWitnesses(a))
+}
+
+func TestCheckWitnesses(t *testing.T) {
+	var a ACIR
+	assert.NoError(t, json.Unmarshal([]byte(unconstrainedCircuitJSON), &a))
+
+	assert.NoError(t, CheckWitnesses(a, make(felt.Vector, 4), nil))
+	assert.ErrorContains(t, CheckWitnesses(a, make(felt.Vector, 5), nil), "5 values for 4 witnesses")
+
+	err := CheckWitnesses(a, make(felt.Vector, 1), nil)
+
+	var validationErrors ValidationErrors
+	assert.ErrorAs(t, err, &validationErrors)
+	assert.Equal(t, []int{0, 1, -1}, opcodeIndices(validationErrors))
+	assert.Contains(t, err.Error(), "opcode 0: w2 has no value, it must be solved by BRILLIG → [w2]")
+	assert.Contains(t, err.Error(), "opcode 1: w3 has no value, it must be solved by ORACLE get_number → [w3]")
+	assert.Contains(t, err.Error(), "w4 has no value")
+}
+
+func TestCheckWitnessesZeroFilled(t *testing.T) {
+	var a ACIR
+	assert.NoError(t, json.Unmarshal([]byte(unconstrainedCircuitJSON), &a))
+	// The FFI always receives a value for every witness, 0 when the ACVM
+	// didn't solve it.
+	values := make(felt.Vector, 4)
+
+	assert.NoError(t, CheckWitnesses(a, values, []uint32{1, 2, 3, 4}))
+	// Unsolved witnesses that no Brillig or Oracle opcode outputs are fine.
+	assert.NoError(t, CheckWitnesses(a, values, []uint32{2, 3}))
+
+	err := CheckWitnesses(a, values, []uint32{1, 3, 4})
+
+	var validationErrors ValidationErrors
+	assert.ErrorAs(t, err, &validationErrors)
+	assert.Equal(t, []int{0}, opcodeIndices(validationErrors))
+	assert.Contains(t, err.Error(), "opcode 0: w2 has no value, it must be solved by BRILLIG → [w2]")
+}
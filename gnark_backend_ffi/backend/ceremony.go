@@ -0,0 +1,276 @@
+package backend
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+)
+
+// ptau files are a list of sections, each one starting with its type and
+// size. Points are uncompressed with their coordinates in Montgomery form,
+// little-endian.
+const (
+	ptauHeaderSection = 1
+	ptauTauG1Section  = 2
+	ptauTauG2Section  = 3
+)
+
+type ptauSection struct {
+	offset int64
+	size   int64
+}
+
+// ImportPtau reads the first size powers of tau of a snarkjs .ptau file,
+// like the ones of the BN254 Perpetual Powers of Tau ceremony, and checks
+// that they are consistent.
+func ImportPtau(path string, size int) (*kzg.SRS, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sections, err := readPtauSections(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	power, err := readPtauHeader(file, sections)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if nbPowers := 1<<(power+1) - 1; size > nbPowers {
+		return nil, fmt.Errorf("%s has %d powers of tau, %d are needed", path, nbPowers, size)
+	}
+
+	var srs kzg.SRS
+	srs.G1 = make([]bn254.G1Affine, size)
+	r, err := ptauSectionReader(file, sections, ptauTauG1Section)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var buf [4 * fp.Bytes]byte
+	for i := range srs.G1 {
+		if _, err := io.ReadFull(r, buf[:2*fp.Bytes]); err != nil {
+			return nil, fmt.Errorf("%s: tau G1 point %d: %w", path, i, err)
+		}
+		srs.G1[i].X, srs.G1[i].Y = ptauElement(buf[0:]), ptauElement(buf[fp.Bytes:])
+	}
+	if r, err = ptauSectionReader(file, sections, ptauTauG2Section); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range srs.G2 {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, fmt.Errorf("%s: tau G2 point %d: %w", path, i, err)
+		}
+		srs.G2[i].X.A0, srs.G2[i].X.A1 = ptauElement(buf[0:]), ptauElement(buf[fp.Bytes:])
+		srs.G2[i].Y.A0, srs.G2[i].Y.A1 = ptauElement(buf[2*fp.Bytes:]), ptauElement(buf[3*fp.Bytes:])
+	}
+
+	if err := CheckSRS(&srs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &srs, nil
+}
+
+func readPtauSections(file *os.File) (map[uint32]ptauSection, error) {
+	var header struct {
+		Magic      [4]byte
+		Version    uint32
+		NbSections uint32
+	}
+	if err := binary.Read(file, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if string(header.Magic[:]) != "ptau" {
+		return nil, errors.New("not a ptau file")
+	}
+
+	sections := make(map[uint32]ptauSection)
+	offset := int64(binary.Size(header))
+	for i := uint32(0); i < header.NbSections; i++ {
+		var sectionHeader struct {
+			Type uint32
+			Size uint64
+		}
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(file, binary.LittleEndian, &sectionHeader); err != nil {
+			return nil, err
+		}
+		offset += int64(binary.Size(sectionHeader))
+		sections[sectionHeader.Type] = ptauSection{offset: offset, size: int64(sectionHeader.Size)}
+		offset += int64(sectionHeader.Size)
+	}
+	return sections, nil
+}
+
+func ptauSectionReader(file *os.File, sections map[uint32]ptauSection, sectionType uint32) (io.Reader, error) {
+	section, ok := sections[sectionType]
+	if !ok {
+		return nil, fmt.Errorf("missing section %d", sectionType)
+	}
+	return bufio.NewReader(io.NewSectionReader(file, section.offset, section.size)), nil
+}
+
+// readPtauHeader checks that the ptau file is for BN254 and returns its
+// power: it holds 2^(power+1) - 1 G1 powers of tau.
+func readPtauHeader(file *os.File, sections map[uint32]ptauSection) (power uint32, err error) {
+	r, err := ptauSectionReader(file, sections, ptauHeaderSection)
+	if err != nil {
+		return
+	}
+	var n8 uint32
+	if err = binary.Read(r, binary.LittleEndian, &n8); err != nil {
+		return
+	}
+	if n8 != fp.Bytes {
+		return 0, fmt.Errorf("field elements of %d bytes, expected %d", n8, fp.Bytes)
+	}
+	q := make([]byte, n8)
+	if _, err = io.ReadFull(r, q); err != nil {
+		return
+	}
+	reverse(q)
+	if new(big.Int).SetBytes(q).Cmp(fp.Modulus()) != 0 {
+		return 0, errors.New("not a BN254 ptau file")
+	}
+	err = binary.Read(r, binary.LittleEndian, &power)
+	return
+}
+
+func ptauElement(b []byte) (e fp.Element) {
+	for i := range e {
+		e[i] = binary.LittleEndian.Uint64(b[8*i:])
+	}
+	return
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// ignitionManifest is the header of the transcripts of Aztec's Ignition
+// ceremony, which are big-endian. It is followed by the G1 points, the G2
+// points and a BLAKE2b checksum.
+type ignitionManifest struct {
+	TranscriptNumber uint32
+	TotalTranscripts uint32
+	TotalG1Points    uint32
+	TotalG2Points    uint32
+	NbG1Points       uint32
+	NbG2Points       uint32
+	StartFrom        uint32
+}
+
+// ImportIgnition reads the first size powers of tau of the transcripts of
+// Aztec's Ignition ceremony, which must be given in order starting from
+// transcript00.dat, and checks that they are consistent. Transcripts start
+// at [τ]G₁ and only the first one has G2 points, [τ]G₂ being the first.
+// Their checksums aren't verified, the consistency check covers every point
+// that is read.
+func ImportIgnition(transcriptPaths []string, size int) (*kzg.SRS, error) {
+	if size < 2 {
+		return nil, kzg.ErrMinSRSSize
+	}
+	var srs kzg.SRS
+	srs.G1 = make([]bn254.G1Affine, 1, size)
+	_, _, srs.G1[0], srs.G2[0] = bn254.Generators()
+
+	hasG2 := false
+	for i, path := range transcriptPaths {
+		if len(srs.G1) == size {
+			break
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		err = readIgnitionTranscript(file, i, &srs, size, &hasG2)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if len(srs.G1) < size {
+		return nil, fmt.Errorf("the transcripts have %d powers of tau, %d are needed", len(srs.G1), size)
+	}
+	if !hasG2 {
+		return nil, errors.New("the transcripts have no G2 points")
+	}
+
+	if err := CheckSRS(&srs); err != nil {
+		return nil, err
+	}
+	return &srs, nil
+}
+
+func readIgnitionTranscript(file *os.File, transcriptNumber int, srs *kzg.SRS, size int, hasG2 *bool) error {
+	r := bufio.NewReader(file)
+	var manifest ignitionManifest
+	if err := binary.Read(r, binary.BigEndian, &manifest); err != nil {
+		return err
+	}
+	if int(manifest.TranscriptNumber) != transcriptNumber {
+		return fmt.Errorf("transcript %d given as transcript %d", manifest.TranscriptNumber, transcriptNumber)
+	}
+	if int(manifest.StartFrom) != len(srs.G1)-1 {
+		return fmt.Errorf("transcript starts from power %d, expected %d", manifest.StartFrom+1, len(srs.G1))
+	}
+
+	var buf [4 * fp.Bytes]byte
+	nbG1Points := int(manifest.NbG1Points)
+	if remaining := size - len(srs.G1); nbG1Points > remaining {
+		nbG1Points = remaining
+	}
+	for i := 0; i < nbG1Points; i++ {
+		if _, err := io.ReadFull(r, buf[:2*fp.Bytes]); err != nil {
+			return fmt.Errorf("G1 point %d: %w", i, err)
+		}
+		var p bn254.G1Affine
+		p.X, p.Y = ignitionElement(buf[0:]), ignitionElement(buf[fp.Bytes:])
+		srs.G1 = append(srs.G1, p)
+	}
+
+	if manifest.NbG2Points == 0 || *hasG2 {
+		return nil
+	}
+	if _, err := r.Discard(int(manifest.NbG1Points-uint32(nbG1Points)) * 2 * fp.Bytes); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return fmt.Errorf("G2 point 0: %w", err)
+	}
+	srs.G2[1].X.A0, srs.G2[1].X.A1 = ignitionElement(buf[0:]), ignitionElement(buf[fp.Bytes:])
+	srs.G2[1].Y.A0, srs.G2[1].Y.A1 = ignitionElement(buf[2*fp.Bytes:]), ignitionElement(buf[3*fp.Bytes:])
+	*hasG2 = true
+	return nil
+}
+
+// ignitionElement decodes a field element of Ignition: four 64-bit limbs,
+// least significant first, each one big-endian and not in Montgomery form.
+func ignitionElement(b []byte) (e fp.Element) {
+	var canonical [fp.Bytes]byte
+	for i := 0; i < 4; i++ {
+		copy(canonical[8*(3-i):8*(4-i)], b[8*i:8*(i+1)])
+	}
+	e.SetBytes(canonical[:])
+	return
+}
+
+// CheckSRS checks that the BN254 SRS holds consecutive powers of the same
+// τ, see checkSRSPowers.
+func CheckSRS(srs *kzg.SRS) error {
+	return srsCurves[ecc.BN254].checkSRS(srs)
+}
@@ -0,0 +1,185 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePtauElement(buf *bytes.Buffer, e fp.Element) {
+	for _, limb := range e {
+		binary.Write(buf, binary.LittleEndian, limb)
+	}
+}
+
+func writePtauSection(buf *bytes.Buffer, sectionType uint32, data []byte) {
+	binary.Write(buf, binary.LittleEndian, sectionType)
+	binary.Write(buf, binary.LittleEndian, uint64(len(data)))
+	buf.Write(data)
+}
+
+// writePtau writes the powers of tau of srs as a ptau file of the given
+// power, which must hold len(srs.G1) = 2^(power+1) - 1 points.
+func writePtau(t *testing.T, path string, power uint32, srs *kzg.SRS) {
+	var header, tauG1, tauG2 bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, uint32(fp.Bytes))
+	q := fp.Modulus().Bytes()
+	reverse(q)
+	header.Write(q)
+	binary.Write(&header, binary.LittleEndian, power)
+	binary.Write(&header, binary.LittleEndian, power)
+	for _, p := range srs.G1 {
+		encodePtauElement(&tauG1, p.X)
+		encodePtauElement(&tauG1, p.Y)
+	}
+	for _, p := range srs.G2 {
+		encodePtauElement(&tauG2, p.X.A0)
+		encodePtauElement(&tauG2, p.X.A1)
+		encodePtauElement(&tauG2, p.Y.A0)
+		encodePtauElement(&tauG2, p.Y.A1)
+	}
+
+	var ptau bytes.Buffer
+	ptau.WriteString("ptau")
+	binary.Write(&ptau, binary.LittleEndian, uint32(1))
+	binary.Write(&ptau, binary.LittleEndian, uint32(4))
+	// Sections don't need to be in order.
+	writePtauSection(&ptau, ptauTauG2Section, tauG2.Bytes())
+	writePtauSection(&ptau, ptauHeaderSection, header.Bytes())
+	writePtauSection(&ptau, 4, []byte{1, 2, 3})
+	writePtauSection(&ptau, ptauTauG1Section, tauG1.Bytes())
+	assert.NoError(t, os.WriteFile(path, ptau.Bytes(), 0644))
+}
+
+func encodeIgnitionElement(buf *bytes.Buffer, e fp.Element) {
+	canonical := e.Bytes()
+	for i := 3; i >= 0; i-- {
+		buf.Write(canonical[8*i : 8*(i+1)])
+	}
+}
+
+// writeIgnition splits the powers of tau of srs, without the generator, in
+// transcripts of at most nbPoints G1 points.
+func writeIgnition(t *testing.T, dir string, nbPoints int, srs *kzg.SRS) (paths []string) {
+	powers := srs.G1[1:]
+	nbTranscripts := (len(powers) + nbPoints - 1) / nbPoints
+	for i := 0; i < nbTranscripts; i++ {
+		points := powers[i*nbPoints:]
+		if len(points) > nbPoints {
+			points = points[:nbPoints]
+		}
+		manifest := ignitionManifest{
+			TranscriptNumber: uint32(i),
+			TotalTranscripts: uint32(nbTranscripts),
+			TotalG1Points:    uint32(len(powers)),
+			TotalG2Points:    1,
+			NbG1Points:       uint32(len(points)),
+			StartFrom:        uint32(i * nbPoints),
+		}
+		if i == 0 {
+			manifest.NbG2Points = 1
+		}
+
+		var transcript bytes.Buffer
+		binary.Write(&transcript, binary.BigEndian, manifest)
+		for _, p := range points {
+			encodeIgnitionElement(&transcript, p.X)
+			encodeIgnitionElement(&transcript, p.Y)
+		}
+		if i == 0 {
+			encodeIgnitionElement(&transcript, srs.G2[1].X.A0)
+			encodeIgnitionElement(&transcript, srs.G2[1].X.A1)
+			encodeIgnitionElement(&transcript, srs.G2[1].Y.A0)
+			encodeIgnitionElement(&transcript, srs.G2[1].Y.A1)
+		}
+		transcript.Write(make([]byte, 64))
+
+		path := filepath.Join(dir, "transcript0"+string(rune('0'+i))+".dat")
+		assert.NoError(t, os.WriteFile(path, transcript.Bytes(), 0644))
+		paths = append(paths, path)
+	}
+	return
+}
+
+func TestImportPtau(t *testing.T) {
+	tau := big.NewInt(1234)
+	srs, err := kzg.NewSRS(15, tau)
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "pot3.ptau")
+	writePtau(t, path, 3, srs)
+
+	importedSRS, err := ImportPtau(path, 15)
+	assert.NoError(t, err)
+	assert.Equal(t, srs, importedSRS)
+
+	expectedSRS, err := kzg.NewSRS(6, tau)
+	assert.NoError(t, err)
+	importedSRS, err = ImportPtau(path, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSRS, importedSRS)
+
+	_, err = ImportPtau(path, 16)
+	assert.ErrorContains(t, err, "has 15 powers of tau, 16 are needed")
+}
+
+func TestImportInconsistentPtau(t *testing.T) {
+	srs, err := kzg.NewSRS(7, big.NewInt(1234))
+	assert.NoError(t, err)
+	srs.G1[3], srs.G1[4] = srs.G1[4], srs.G1[3]
+	path := filepath.Join(t.TempDir(), "pot2.ptau")
+	writePtau(t, path, 2, srs)
+
+	_, err = ImportPtau(path, 7)
+	assert.ErrorContains(t, err, "not consecutive powers")
+	// The truncated SRS doesn't have the swapped points.
+	_, err = ImportPtau(path, 3)
+	assert.NoError(t, err)
+}
+
+func TestImportIgnition(t *testing.T) {
+	tau := big.NewInt(4321)
+	srs, err := kzg.NewSRS(11, tau)
+	assert.NoError(t, err)
+	paths := writeIgnition(t, t.TempDir(), 4, srs)
+	assert.Len(t, paths, 3)
+
+	importedSRS, err := ImportIgnition(paths, 11)
+	assert.NoError(t, err)
+	assert.Equal(t, srs, importedSRS)
+
+	expectedSRS, err := kzg.NewSRS(7, tau)
+	assert.NoError(t, err)
+	importedSRS, err = ImportIgnition(paths, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSRS, importedSRS)
+
+	_, err = ImportIgnition(paths, 12)
+	assert.ErrorContains(t, err, "the transcripts have 11 powers of tau, 12 are needed")
+	_, err = ImportIgnition([]string{paths[1], paths[0]}, 11)
+	assert.ErrorContains(t, err, "transcript 1 given as transcript 0")
+}
+
+func TestCheckSRS(t *testing.T) {
+	srs, err := kzg.NewSRS(8, big.NewInt(99))
+	assert.NoError(t, err)
+	assert.NoError(t, CheckSRS(srs))
+
+	otherSRS, err := kzg.NewSRS(8, big.NewInt(100))
+	assert.NoError(t, err)
+	mixedSRS := &kzg.SRS{G1: srs.G1, G2: otherSRS.G2}
+	assert.Error(t, CheckSRS(mixedSRS))
+
+	var notOnCurve bn254.G1Affine
+	notOnCurve.X.SetOne()
+	notOnCurve.Y.SetOne()
+	srs.G1[5] = notOnCurve
+	assert.ErrorContains(t, CheckSRS(srs), "G1 point 5 is not a valid point")
+}
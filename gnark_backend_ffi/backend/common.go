@@ -1,34 +1,31 @@
 package backend
 
 import (
-	"bytes"
-	"crypto/rand"
-	"encoding/hex"
+	"encoding/binary"
 	"fmt"
 	"gnark_backend_ffi/acir"
-	"io/ioutil"
+	"gnark_backend_ffi/internal/felt"
 	"log"
 	"math/big"
-	"os"
 
-	"github.com/consensys/gnark-crypto/ecc"
-	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
-	kzgg "github.com/consensys/gnark-crypto/kzg"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 )
 
-func BuildWitnesses(scalarField *big.Int, publicVariables fr_bn254.Vector, privateVariables fr_bn254.Vector, nbPublicVariables int, nbSecretVariables int) witness.Witness {
+// BuildWitnesses fills a witness with the public and then the secret
+// variables.
+//
+// Deprecated: it sends every value through a channel, use NewWitness instead.
+func BuildWitnesses(scalarField *big.Int, publicVariables felt.Vector, privateVariables felt.Vector, nbPublicVariables int, nbSecretVariables int) witness.Witness {
 	witnessValues := make(chan any)
 
 	go func() {
 		defer close(witnessValues)
 		for _, publicVariable := range publicVariables {
-			witnessValues <- publicVariable
+			witnessValues <- publicVariable.BigInt(new(big.Int))
 		}
 		for _, privateVariable := range privateVariables {
-			witnessValues <- privateVariable
+			witnessValues <- privateVariable.BigInt(new(big.Int))
 		}
 	}()
 
@@ -42,103 +39,79 @@ func BuildWitnesses(scalarField *big.Int, publicVariables fr_bn254.Vector, priva
 	return witness
 }
 
-func HandleValues(a acir.ACIR, cs constraint.ConstraintSystem, values fr_bn254.Vector) (publicVariables fr_bn254.Vector, secretVariables fr_bn254.Vector, indexMap map[string]int) {
-	indexMap = make(map[string]int)
-	var index int
-	for i, value := range values {
-		i++
-		for _, publicInput := range a.PublicInputs {
-			if uint32(i) == publicInput {
-				index = cs.AddPublicVariable(fmt.Sprintf("public_%d", i))
-				publicVariables = append(publicVariables, value)
-				indexMap[fmt.Sprint(i)] = index
-			}
-		}
-
-	}
-	for i, value := range values {
-		i++
-		if len(a.PublicInputs) > 0 {
-			for _, publicInput := range a.PublicInputs {
-				if uint32(i) != publicInput {
-					index = cs.AddSecretVariable(fmt.Sprintf("secret_%d", i))
-					secretVariables = append(secretVariables, value)
-					indexMap[fmt.Sprint(i)] = index
-				}
+// NewWitness builds the same witness as BuildWitnesses without a goroutine:
+// the values are written in gnark's binary witness protocol, which the
+// witness decodes in one pass. Values are reduced modulo the scalar field.
+func NewWitness(scalarField *big.Int, publicVariables felt.Vector, secretVariables felt.Vector) (witness.Witness, error) {
+	elementSize := (scalarField.BitLen() + 7) / 8
+	nbVariables := len(publicVariables) + len(secretVariables)
+	// [uint32(nbPublic) | uint32(nbSecret) | uint32(nbVariables) | variables]
+	encodedWitness := make([]byte, 12, 12+nbVariables*elementSize)
+	binary.BigEndian.PutUint32(encodedWitness[0:4], uint32(len(publicVariables)))
+	binary.BigEndian.PutUint32(encodedWitness[4:8], uint32(len(secretVariables)))
+	binary.BigEndian.PutUint32(encodedWitness[8:12], uint32(nbVariables))
+	value := new(big.Int)
+	encodedVariable := make([]byte, elementSize)
+	for _, variables := range []felt.Vector{publicVariables, secretVariables} {
+		for i := range variables {
+			variables[i].BigInt(value)
+			if value.Cmp(scalarField) >= 0 {
+				value.Mod(value, scalarField)
 			}
-		} else {
-			index = cs.AddSecretVariable(fmt.Sprintf("secret_%d", i))
-			secretVariables = append(secretVariables, value)
-			indexMap[fmt.Sprint(i)] = index
+			encodedWitness = append(encodedWitness, value.FillBytes(encodedVariable)...)
 		}
 	}
-	return
-}
 
-func getFilePath() (string, error) {
-	userConfigDir, err := os.UserConfigDir()
+	w, err := witness.New(scalarField)
 	if err != nil {
-		return userConfigDir, err
+		return nil, err
 	}
-	return userConfigDir + "/noir-lang/srs.hex", nil
-}
-
-func LoadSRS() (srs kzgg.SRS, err error) {
-	filepath, err := getFilePath()
-	if err != nil {
-		return
+	if err := w.UnmarshalBinary(encodedWitness); err != nil {
+		return nil, err
 	}
+	return w, nil
+}
 
-	srsEncoded, err := os.ReadFile(filepath)
-	if err != nil {
-		return
+// publicWitnesses returns the set of the public inputs of the circuit.
+func publicWitnesses(a acir.ACIR) map[uint32]bool {
+	public := make(map[uint32]bool, len(a.PublicInputs))
+	for _, publicInput := range a.PublicInputs {
+		public[publicInput] = true
 	}
-	decodedSrs, err := hex.DecodeString(string(srsEncoded))
-	if err != nil {
-		return
-	}
-
-	srs = kzgg.NewSRS(ecc.BN254)
-	srs.ReadFrom(bytes.NewReader(decodedSrs))
-
-	return
+	return public
 }
 
-func SaveSRS(srs kzgg.SRS) (err error) {
-	// Make a hex encode of the SRS.
-	var serializedSRS bytes.Buffer
-	srs.WriteTo(&serializedSRS)
-	encodedSRS := hex.EncodeToString(serializedSRS.Bytes())
-
-	// Save the encoded SRS in a file named srs.hex in the user config dir.
-	// We need to save the encoded SRS because the struct VerifyingKey has a pointer
-	// to a SRS struct but we can't rely on a pointer because memory is volatile.
-	// When we deserialize the VerifyingKey we will deserialize the SRS and insert
-	// a valid pointer.
-	filepath, err := getFilePath()
-	if err != nil {
-		return err
+// PublicValues returns the values of the public inputs in the order
+// HandleValues adds them as public variables: by increasing witness index.
+// They are the public witness of the circuit.
+func PublicValues(a acir.ACIR, values felt.Vector) (publicVariables felt.Vector) {
+	public := publicWitnesses(a)
+	for i, value := range values {
+		if public[uint32(i+1)] {
+			publicVariables = append(publicVariables, value)
+		}
 	}
-	err = ioutil.WriteFile(filepath, []byte(encodedSRS), 0644)
-
 	return
 }
 
-func TryLoadSRS(curveID ecc.ID) (srs kzgg.SRS, err error) {
-	// Load SRS if it is already generated.
-	srs, err = LoadSRS()
-	if err != nil {
-		// SRS wasn't generated so we generate it.
-		alpha, err2 := rand.Int(rand.Reader, curveID.ScalarField())
-		if err2 != nil {
-			err = err2
-			return
+// HandleValues adds a variable for every witness, the public inputs first,
+// and returns their values and the index of the variable of every witness.
+func HandleValues(a acir.ACIR, cs constraint.ConstraintSystem, values felt.Vector) (publicVariables felt.Vector, secretVariables felt.Vector, indexMap map[string]int) {
+	indexMap = make(map[string]int)
+	public := publicWitnesses(a)
+	for i, value := range values {
+		i++
+		if public[uint32(i)] {
+			indexMap[fmt.Sprint(i)] = cs.AddPublicVariable(fmt.Sprintf("public_%d", i))
+			publicVariables = append(publicVariables, value)
 		}
-		srs, err = kzg.NewSRS(1_000_000, alpha)
-		if err != nil {
-			return
+	}
+	for i, value := range values {
+		i++
+		if !public[uint32(i)] {
+			indexMap[fmt.Sprint(i)] = cs.AddSecretVariable(fmt.Sprintf("secret_%d", i))
+			secretVariables = append(secretVariables, value)
 		}
-		SaveSRS(srs)
 	}
 	return
 }
@@ -0,0 +1,37 @@
+package backend
+
+import (
+	"fmt"
+	"testing"
+
+	"gnark_backend_ffi/acir"
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleValues(t *testing.T) {
+	values := felt.Vector{felt.NewElement(1), felt.NewElement(2), felt.NewElement(3), felt.NewElement(4)}
+	for _, publicInputs := range [][]uint32{{}, {2}, {3, 1}} {
+		sparseR1CS, err := NewSparseR1CS(ecc.BN254, 0)
+		assert.NoError(t, err)
+		circuit := acir.ACIR{CurrentWitness: 4, PublicInputs: publicInputs}
+
+		publicVariables, secretVariables, indexMap := HandleValues(circuit, sparseR1CS, values)
+
+		// Every witness has exactly one variable and the public inputs are
+		// bound to the public ones.
+		system := System(sparseR1CS)
+		assert.Len(t, system.Public, len(publicInputs))
+		assert.Len(t, system.Secret, len(values)-len(publicInputs))
+		assert.Equal(t, PublicValues(circuit, values), publicVariables)
+		assert.Len(t, secretVariables, len(values)-len(publicInputs))
+		assert.Len(t, indexMap, len(values))
+		for _, publicInput := range publicInputs {
+			index := indexMap[fmt.Sprint(publicInput)]
+			assert.Less(t, index, len(system.Public))
+			assert.Equal(t, fmt.Sprintf("public_%d", publicInput), system.Public[index])
+		}
+	}
+}
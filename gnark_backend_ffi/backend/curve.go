@@ -0,0 +1,104 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	cs_bls12377 "github.com/consensys/gnark/constraint/bls12-377"
+	cs_bls12381 "github.com/consensys/gnark/constraint/bls12-381"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	cs_bw6761 "github.com/consensys/gnark/constraint/bw6-761"
+)
+
+// SupportedCurves are the curves circuits can be proven on. BLS12-377 and
+// BW6-761 form gnark's 2-chain: BLS12-377 proofs can be verified in BW6-761
+// circuits.
+var SupportedCurves = []ecc.ID{ecc.BN254, ecc.BLS12_381, ecc.BLS12_377, ecc.BW6_761}
+
+// ParseCurve parses the name of a supported curve as ecc.ID.String writes
+// it, which for bn254 and bls12_381 is also the name of the feature of the
+// Rust crate.
+func ParseCurve(name string) (ecc.ID, error) {
+	names := make([]string, 0, len(SupportedCurves))
+	for _, curveID := range SupportedCurves {
+		if curveID.String() == name {
+			return curveID, nil
+		}
+		names = append(names, curveID.String())
+	}
+	return ecc.UNKNOWN, fmt.Errorf("unsupported curve %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// SparseR1CS is the sparse R1CS of any of the supported curves.
+type SparseR1CS interface {
+	constraint.SparseR1CS
+	CurveID() ecc.ID
+}
+
+// NewSparseR1CS returns an empty sparse R1CS over the scalar field of the
+// curve.
+func NewSparseR1CS(curveID ecc.ID, capacity int) (SparseR1CS, error) {
+	switch curveID {
+	case ecc.BN254:
+		return cs_bn254.NewSparseR1CS(capacity), nil
+	case ecc.BLS12_381:
+		return cs_bls12381.NewSparseR1CS(capacity), nil
+	case ecc.BLS12_377:
+		return cs_bls12377.NewSparseR1CS(capacity), nil
+	case ecc.BW6_761:
+		return cs_bw6761.NewSparseR1CS(capacity), nil
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", curveID)
+	}
+}
+
+// System returns the variables, hints and debug info of the sparse R1CS.
+func System(sparseR1CS SparseR1CS) *constraint.System {
+	switch cs := sparseR1CS.(type) {
+	case *cs_bn254.SparseR1CS:
+		return &cs.System
+	case *cs_bls12381.SparseR1CS:
+		return &cs.System
+	case *cs_bls12377.SparseR1CS:
+		return &cs.System
+	case *cs_bw6761.SparseR1CS:
+		return &cs.System
+	default:
+		panic(fmt.Sprintf("unsupported sparse R1CS %T", sparseR1CS))
+	}
+}
+
+// Coefficients returns the coefficients the terms of the sparse R1CS refer
+// to by their coefficient ID.
+func Coefficients(sparseR1CS SparseR1CS) []constraint.Coeff {
+	switch cs := sparseR1CS.(type) {
+	case *cs_bn254.SparseR1CS:
+		return toCoeffs(cs, cs.Coefficients)
+	case *cs_bls12381.SparseR1CS:
+		return toCoeffs(cs, cs.Coefficients)
+	case *cs_bls12377.SparseR1CS:
+		return toCoeffs(cs, cs.Coefficients)
+	case *cs_bw6761.SparseR1CS:
+		return toCoeffs(cs, cs.Coefficients)
+	default:
+		panic(fmt.Sprintf("unsupported sparse R1CS %T", sparseR1CS))
+	}
+}
+
+func toCoeffs[E any](engine constraint.CoeffEngine, elements []E) []constraint.Coeff {
+	coeffs := make([]constraint.Coeff, len(elements))
+	for i := range elements {
+		coeffs[i] = engine.FromInterface(elements[i])
+	}
+	return coeffs
+}
+
+// ScalarFieldBytes is the size of the canonical encoding of the elements of
+// the scalar field of the curve.
+func ScalarFieldBytes(curveID ecc.ID) int {
+	return felt.Size(curveID.ScalarField())
+}
@@ -0,0 +1,43 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCurve(t *testing.T) {
+	curveID, err := ParseCurve("bn254")
+	assert.NoError(t, err)
+	assert.Equal(t, ecc.BN254, curveID)
+
+	curveID, err = ParseCurve("bls12_381")
+	assert.NoError(t, err)
+	assert.Equal(t, ecc.BLS12_381, curveID)
+
+	curveID, err = ParseCurve("bw6_761")
+	assert.NoError(t, err)
+	assert.Equal(t, ecc.BW6_761, curveID)
+
+	_, err = ParseCurve("bls12-381")
+	assert.ErrorContains(t, err, "expected one of bn254, bls12_381, bls12_377, bw6_761")
+}
+
+func TestNewSparseR1CS(t *testing.T) {
+	for _, curveID := range SupportedCurves {
+		sparseR1CS, err := NewSparseR1CS(curveID, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, curveID, sparseR1CS.CurveID())
+		assert.Equal(t, curveID.ScalarField(), sparseR1CS.Field())
+
+		qC := sparseR1CS.FromInterface(-1)
+		K := sparseR1CS.MakeTerm(&qC, 0)
+		coefficients := Coefficients(sparseR1CS)
+		assert.Equal(t, qC, coefficients[K.CoeffID()])
+		assert.Empty(t, System(sparseR1CS).Public)
+	}
+
+	_, err := NewSparseR1CS(ecc.BLS24_315, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,177 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"gnark_backend_ffi/acir"
+
+	"github.com/consensys/gnark/constraint"
+)
+
+// Fingerprint is a SHA-256 digest that identifies a circuit.
+type Fingerprint [sha256.Size]byte
+
+func (f Fingerprint) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+// CircuitFingerprint identifies both the ACIR a key was generated for and
+// the constraint system it was lowered to. It also records the mode of the
+// SRS the key was set up with.
+type CircuitFingerprint struct {
+	ACIR       Fingerprint
+	SparseR1CS Fingerprint
+	SRSMode    SRSMode
+}
+
+// Keys are prefixed with this magic followed by a version byte, the SRS mode
+// and both digests.
+var fingerprintMagic = []byte("NGFP")
+
+const fingerprintVersion = 1
+
+const fingerprintHeaderSize = 4 + 1 + 1 + 2*sha256.Size
+
+var srsModeBytes = map[SRSMode]byte{TrustedSRS: 1, DevSRS: 2}
+
+// FingerprintACIR hashes the canonical JSON serialization of what the
+// constraints depend on: the witnesses, the opcodes and the public inputs.
+// Assert messages and debug symbols are left out so attaching them doesn't
+// change the fingerprint.
+func FingerprintACIR(circuit acir.ACIR) (fingerprint Fingerprint, err error) {
+	serializedACIR, err := json.Marshal(acir.ACIR{
+		CurrentWitness: circuit.CurrentWitness,
+		Opcodes:        circuit.Opcodes,
+		PublicInputs:   circuit.PublicInputs,
+	})
+	if err != nil {
+		return
+	}
+	fingerprint = sha256.Sum256(serializedACIR)
+	return
+}
+
+// FingerprintSparseR1CS hashes the variables layout and the constraints of a
+// sparse R1CS, resolving coefficient IDs to their values so the digest does
+// not depend on the order of the coefficients table.
+func FingerprintSparseR1CS(sparseR1CS SparseR1CS) (fingerprint Fingerprint) {
+	hasher := sha256.New()
+	writeUint32 := func(value int) {
+		binary.Write(hasher, binary.BigEndian, uint32(value))
+	}
+	coefficients := Coefficients(sparseR1CS)
+	coefficient := make([]byte, ScalarFieldBytes(sparseR1CS.CurveID()))
+	writeCoefficient := func(coefficientID int) {
+		hasher.Write(sparseR1CS.ToBigInt(&coefficients[coefficientID]).FillBytes(coefficient))
+	}
+
+	constraints, _ := sparseR1CS.GetConstraints()
+	writeUint32(sparseR1CS.GetNbPublicVariables())
+	writeUint32(sparseR1CS.GetNbSecretVariables())
+	writeUint32(sparseR1CS.GetNbInternalVariables())
+	writeUint32(len(constraints))
+	for _, c := range constraints {
+		for _, t := range []constraint.Term{c.L, c.R, c.O, c.M[0], c.M[1]} {
+			writeUint32(int(t.VID))
+			writeCoefficient(int(t.CID))
+		}
+		writeCoefficient(c.K)
+	}
+
+	copy(fingerprint[:], hasher.Sum(nil))
+	return
+}
+
+// EmbedInKey prefixes a hex encoded key with the fingerprint.
+func (f CircuitFingerprint) EmbedInKey(encodedKey string) string {
+	header := make([]byte, 0, fingerprintHeaderSize)
+	header = append(header, fingerprintMagic...)
+	header = append(header, fingerprintVersion)
+	header = append(header, srsModeBytes[f.SRSMode])
+	header = append(header, f.ACIR[:]...)
+	header = append(header, f.SparseR1CS[:]...)
+	return hex.EncodeToString(header) + encodedKey
+}
+
+// ExtractKeyFingerprint splits a hex encoded key into its fingerprint and
+// the key itself. Keys without a fingerprint can't be checked against the
+// circuit so they are rejected.
+func ExtractKeyFingerprint(encodedKey string) (*CircuitFingerprint, string, error) {
+	encodedHeaderSize := 2 * fingerprintHeaderSize
+	if len(encodedKey) < encodedHeaderSize {
+		return nil, "", fmt.Errorf("key has no circuit fingerprint")
+	}
+	header, err := hex.DecodeString(encodedKey[:encodedHeaderSize])
+	if err != nil {
+		return nil, "", err
+	}
+	if !bytes.HasPrefix(header, fingerprintMagic) {
+		return nil, "", fmt.Errorf("key has no circuit fingerprint")
+	}
+	header = header[len(fingerprintMagic):]
+	if header[0] != fingerprintVersion {
+		return nil, "", fmt.Errorf("unsupported key fingerprint version %d", header[0])
+	}
+	header = header[1:]
+
+	var fingerprint CircuitFingerprint
+	for mode, modeByte := range srsModeBytes {
+		if header[0] == modeByte {
+			fingerprint.SRSMode = mode
+		}
+	}
+	if fingerprint.SRSMode == "" {
+		return nil, "", fmt.Errorf("unknown key SRS mode %d", header[0])
+	}
+	header = header[1:]
+	copy(fingerprint.ACIR[:], header[:sha256.Size])
+	copy(fingerprint.SparseR1CS[:], header[sha256.Size:])
+
+	return &fingerprint, encodedKey[encodedHeaderSize:], nil
+}
+
+// CheckACIR fails if the key was generated for another circuit.
+func (f *CircuitFingerprint) CheckACIR(circuit acir.ACIR) error {
+	fingerprint, err := FingerprintACIR(circuit)
+	if err != nil {
+		return err
+	}
+	if fingerprint != f.ACIR {
+		return fmt.Errorf("key was generated for circuit %s but the circuit is %s", f.ACIR, fingerprint)
+	}
+	return nil
+}
+
+// CheckSparseR1CS fails if the key was generated for another constraint
+// system, even when the ACIR is the same (for example because the number of
+// values changed or the lowering did).
+func (f *CircuitFingerprint) CheckSparseR1CS(sparseR1CS SparseR1CS) error {
+	fingerprint := FingerprintSparseR1CS(sparseR1CS)
+	if fingerprint != f.SparseR1CS {
+		return fmt.Errorf("key was generated for constraint system %s but the constraint system is %s", f.SparseR1CS, fingerprint)
+	}
+	return nil
+}
+
+// CheckSRSMode fails if the key was set up with an SRS of another mode than
+// the current one: a DevSRS key can't be used in TrustedSRS mode, and the
+// SRSs of both modes differ anyway. A key that doesn't record its mode may
+// have been set up with an insecure SRS so it fails too.
+func (f *CircuitFingerprint) CheckSRSMode(mode SRSMode) error {
+	if f.SRSMode == "" {
+		return fmt.Errorf("key doesn't record the mode of its SRS")
+	}
+	if f.SRSMode != mode {
+		return fmt.Errorf("key was set up with a %s SRS but the SRS mode is %s", f.SRSMode, mode)
+	}
+	if f.SRSMode == DevSRS {
+		log.Print("Warning: key was set up with an insecure dev SRS, its proofs can be forged.")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gnark_backend_ffi/acir"
+
+	"github.com/consensys/gnark/constraint"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	"github.com/stretchr/testify/assert"
+)
+
+const circuitJSON = `{"current_witness_index":2,"opcodes":[{"Arithmetic":{"mul_terms":[],"linear_combinations":[["0000000000000000000000000000000000000000000000000000000000000001",1],["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",2]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}}],"public_inputs":[2]}`
+
+func uncheckedDeserializeACIR(acirJSON string) (a acir.ACIR) {
+	if err := json.Unmarshal([]byte(acirJSON), &a); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func sparseR1CSWithConstant(k int) *cs_bn254.SparseR1CS {
+	sparseR1CS := cs_bn254.NewSparseR1CS(1)
+	x := sparseR1CS.AddSecretVariable("x")
+	one := sparseR1CS.FromInterface(1)
+	qC := sparseR1CS.FromInterface(k)
+	K := sparseR1CS.MakeTerm(&qC, 0)
+	K.MarkConstant()
+	sparseR1CS.AddConstraint(constraint.SparseR1C{L: sparseR1CS.MakeTerm(&one, x), K: K.CoeffID()})
+	return sparseR1CS
+}
+
+func TestFingerprintACIR(t *testing.T) {
+	a := uncheckedDeserializeACIR(circuitJSON)
+	fingerprint, err := FingerprintACIR(a)
+	assert.NoError(t, err)
+
+	sameFingerprint, err := FingerprintACIR(uncheckedDeserializeACIR(circuitJSON))
+	assert.NoError(t, err)
+	assert.Equal(t, fingerprint, sameFingerprint)
+
+	a.PublicInputs = nil
+	otherFingerprint, err := FingerprintACIR(a)
+	assert.NoError(t, err)
+	assert.NotEqual(t, fingerprint, otherFingerprint)
+}
+
+func TestFingerprintACIRIgnoresDebugInfo(t *testing.T) {
+	a := uncheckedDeserializeACIR(circuitJSON)
+	fingerprint, err := FingerprintACIR(a)
+	assert.NoError(t, err)
+
+	a.AssertMessages = []acir.AssertMessage{{Location: acir.AcirLocation(0), Message: "x != y"}}
+	a.DebugInfo = &acir.DebugInfo{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"locations":{"0":[{"span":{"start":25,"end":38},"file":0}]}}`), a.DebugInfo))
+	debugFingerprint, err := FingerprintACIR(a)
+	assert.NoError(t, err)
+	assert.Equal(t, fingerprint, debugFingerprint)
+}
+
+func TestFingerprintSparseR1CS(t *testing.T) {
+	assert.Equal(t, FingerprintSparseR1CS(sparseR1CSWithConstant(1)), FingerprintSparseR1CS(sparseR1CSWithConstant(1)))
+	assert.NotEqual(t, FingerprintSparseR1CS(sparseR1CSWithConstant(1)), FingerprintSparseR1CS(sparseR1CSWithConstant(2)))
+
+	// Keys embed the fingerprint, it must not change from a version to the
+	// next.
+	assert.Equal(t, "0a14fd453052cbba72855485aea768fd6c11d139719d208bdec32bee05cd57e5", FingerprintSparseR1CS(sparseR1CSWithConstant(-1)).String())
+	assert.Equal(t, "d350e8edae2040a031d264f51a190e3c3b5c843b51773026bbc1871878de6fc0", FingerprintSparseR1CS(sparseR1CSWithConstant(5)).String())
+}
+
+func TestEmbedAndExtractKeyFingerprint(t *testing.T) {
+	a := uncheckedDeserializeACIR(circuitJSON)
+	acirFingerprint, err := FingerprintACIR(a)
+	assert.NoError(t, err)
+	fingerprint := CircuitFingerprint{ACIR: acirFingerprint, SparseR1CS: FingerprintSparseR1CS(sparseR1CSWithConstant(1)), SRSMode: DevSRS}
+
+	extractedFingerprint, encodedKey, err := ExtractKeyFingerprint(fingerprint.EmbedInKey("00ff"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "00ff", encodedKey)
+	assert.Equal(t, fingerprint, *extractedFingerprint)
+	assert.NoError(t, extractedFingerprint.CheckACIR(a))
+	assert.NoError(t, extractedFingerprint.CheckSparseR1CS(sparseR1CSWithConstant(1)))
+	assert.Error(t, extractedFingerprint.CheckSparseR1CS(sparseR1CSWithConstant(2)))
+	a.CurrentWitness++
+	assert.Error(t, extractedFingerprint.CheckACIR(a))
+}
+
+func TestExtractKeyFingerprintRejectsKeysWithout(t *testing.T) {
+	for _, encodedKey := range []string{"00ff", strings.Repeat("00", fingerprintHeaderSize) + "00ff"} {
+		_, _, err := ExtractKeyFingerprint(encodedKey)
+		assert.ErrorContains(t, err, "key has no circuit fingerprint")
+	}
+}
+
+func TestExtractKeyFingerprintRejectsOtherVersions(t *testing.T) {
+	encodedKey := CircuitFingerprint{SRSMode: TrustedSRS}.EmbedInKey("00ff")
+	// The version byte follows the magic.
+	versionAt := 2 * len(fingerprintMagic)
+	encodedKey = encodedKey[:versionAt] + "02" + encodedKey[versionAt+2:]
+
+	_, _, err := ExtractKeyFingerprint(encodedKey)
+	assert.ErrorContains(t, err, "unsupported key fingerprint version 2")
+}
+
+func TestExtractKeyFingerprintRejectsUnknownSRSMode(t *testing.T) {
+	fingerprint := CircuitFingerprint{SRSMode: DevSRS}
+	encodedKey := fingerprint.EmbedInKey("00ff")
+	// The mode byte follows the magic and the version.
+	modeAt := 2 * (len(fingerprintMagic) + 1)
+	encodedKey = encodedKey[:modeAt] + "07" + encodedKey[modeAt+2:]
+
+	_, _, err := ExtractKeyFingerprint(encodedKey)
+	assert.ErrorContains(t, err, "unknown key SRS mode 7")
+}
+
+func TestCheckSRSMode(t *testing.T) {
+	trustedKey := &CircuitFingerprint{SRSMode: TrustedSRS}
+	devKey := &CircuitFingerprint{SRSMode: DevSRS}
+
+	assert.NoError(t, trustedKey.CheckSRSMode(TrustedSRS))
+	assert.NoError(t, devKey.CheckSRSMode(DevSRS))
+	assert.ErrorContains(t, devKey.CheckSRSMode(TrustedSRS), "key was set up with a dev SRS but the SRS mode is trusted")
+	assert.Error(t, trustedKey.CheckSRSMode(DevSRS))
+	assert.ErrorContains(t, (&CircuitFingerprint{}).CheckSRSMode(TrustedSRS), "key doesn't record the mode of its SRS")
+}
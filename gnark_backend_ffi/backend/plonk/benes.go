@@ -0,0 +1,160 @@
+package plonk_backend
+
+import (
+	"github.com/consensys/gnark/constraint"
+)
+
+// A Beneš network routes n = 2^k values into any order with switches that
+// either let a pair of values through or swap them. It has n/2 switches on
+// its inputs, n/2 on its outputs and two networks of n/2 values in between:
+// the first value of every input pair goes to the upper one and the second
+// to the lower one, and every output pair takes a value from each. Its
+// switches are listed in that order: inputs, upper network, lower network,
+// outputs.
+
+// nbBenesSwitches is the number of switches of the Beneš network of n
+// values.
+func nbBenesSwitches(n int) int {
+	switch {
+	case n < 2:
+		return 0
+	case n == 2:
+		return 1
+	}
+	return n + 2*nbBenesSwitches(n/2)
+}
+
+// benesSwitches returns the switches that route the input sources[j] to the
+// output j, 1 for the ones that swap their values. The number of sources
+// must be a power of two.
+func benesSwitches(sources []int) []uint {
+	n := len(sources)
+	switch n {
+	case 1:
+		return nil
+	case 2:
+		if sources[0] == 1 {
+			return []uint{1}
+		}
+		return []uint{0}
+	}
+
+	destinations := make([]int, n)
+	for output, input := range sources {
+		destinations[input] = output
+	}
+
+	// The two inputs of a switch go to different networks and the two
+	// outputs of a switch come from different networks, so the inputs are
+	// split following the cycles these constraints make.
+	lower := make([]bool, n)
+	split := make([]bool, n)
+	for start := 0; start < n; start += 2 {
+		for input := start; !split[input]; {
+			split[input], split[input^1] = true, true
+			lower[input^1] = true
+			// The output paired with the one of the lower input must come
+			// from the upper network.
+			input = sources[destinations[input^1]^1]
+		}
+	}
+
+	inputSwitches := make([]uint, n/2)
+	outputSwitches := make([]uint, n/2)
+	upperSources := make([]int, n/2)
+	lowerSources := make([]int, n/2)
+	for i := 0; i < n/2; i++ {
+		if lower[2*i] {
+			inputSwitches[i] = 1
+		}
+		// output is the one of the pair the upper network feeds.
+		output := 2 * i
+		if lower[sources[output]] {
+			output++
+			outputSwitches[i] = 1
+		}
+		upperSources[i] = sources[output] / 2
+		lowerSources[i] = sources[output^1] / 2
+	}
+
+	switches := make([]uint, 0, nbBenesSwitches(n))
+	switches = append(switches, inputSwitches...)
+	switches = append(switches, benesSwitches(upperSources)...)
+	switches = append(switches, benesSwitches(lowerSources)...)
+	return append(switches, outputSwitches...)
+}
+
+// routeBenes constrains the outputs of the Beneš network with the given
+// switches, which must be boolean. It routes rows of several variables,
+// fields[f][i] is the field f of the row i.
+func routeBenes(sparseR1CS constraint.SparseR1CS, fields [][]int, switches []int) [][]int {
+	outputs, _ := routeBenesNetwork(sparseR1CS, fields, switches)
+	return outputs
+}
+
+// routeBenesNetwork returns the outputs and the switches of the networks
+// that come after this one.
+func routeBenesNetwork(sparseR1CS constraint.SparseR1CS, fields [][]int, switches []int) ([][]int, []int) {
+	n := len(fields[0])
+	if n < 2 {
+		return fields, switches
+	}
+	if n == 2 {
+		first, second := benesSwitch(sparseR1CS, switches[0], row(fields, 0), row(fields, 1))
+		return fromRows(first, second), switches[1:]
+	}
+
+	upper := make([][]int, len(fields))
+	lower := make([][]int, len(fields))
+	for i := 0; i < n/2; i++ {
+		first, second := benesSwitch(sparseR1CS, switches[i], row(fields, 2*i), row(fields, 2*i+1))
+		for f := range fields {
+			upper[f] = append(upper[f], first[f])
+			lower[f] = append(lower[f], second[f])
+		}
+	}
+	switches = switches[n/2:]
+	upper, switches = routeBenesNetwork(sparseR1CS, upper, switches)
+	lower, switches = routeBenesNetwork(sparseR1CS, lower, switches)
+
+	outputs := make([][]int, len(fields))
+	for i := 0; i < n/2; i++ {
+		first, second := benesSwitch(sparseR1CS, switches[i], row(upper, i), row(lower, i))
+		for f := range fields {
+			outputs[f] = append(outputs[f], first[f], second[f])
+		}
+	}
+	return outputs, switches[n/2:]
+}
+
+// row returns the fields of the row i.
+func row(fields [][]int, i int) []int {
+	row := make([]int, len(fields))
+	for f := range fields {
+		row[f] = fields[f][i]
+	}
+	return row
+}
+
+// fromRows returns the fields of the rows first and second.
+func fromRows(first []int, second []int) [][]int {
+	fields := make([][]int, len(first))
+	for f := range first {
+		fields[f] = []int{first[f], second[f]}
+	}
+	return fields
+}
+
+// benesSwitch returns a + s⋅(b - a) and b - s⋅(b - a) for every variable of
+// the rows a and b.
+func benesSwitch(sparseR1CS constraint.SparseR1CS, s int, a []int, b []int) ([]int, []int) {
+	first := make([]int, len(a))
+	second := make([]int, len(a))
+	for f := range a {
+		difference := newLinearCombination(sparseR1CS, coeff(1), b[f], coeff(-1), a[f])
+		swap := newProduct(sparseR1CS, s, difference)
+		first[f] = newLinearCombination(sparseR1CS, coeff(1), a[f], coeff(1), swap)
+		second[f] = newLinearCombination(sparseR1CS, coeff(1), b[f], coeff(-1), swap)
+	}
+	return first, second
+}
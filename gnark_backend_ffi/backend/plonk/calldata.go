@@ -0,0 +1,143 @@
+package plonk_backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"gnark_backend_ffi/acir"
+	"gnark_backend_ffi/backend"
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/plonk"
+	"golang.org/x/crypto/sha3"
+)
+
+// SolidityVerifyMethod is the method of the verifier ExportSolidity writes
+// that SolidityCalldata calls.
+const SolidityVerifyMethod = "verify_serialized_proof(uint256[],uint256[])"
+
+// solidityProofSize is the number of uint256 of a proof for the verifier.
+const solidityProofSize = 26
+
+// The raw encoding of a BN254 proof, gnark's WriteRawTo:
+// LRO, Z, H[0..2], the batched opening proof of h, the linearized
+// polynomial, l, r, o, s1 and s2 at ζ, and the opening proof of z at ζω.
+const (
+	rawG1Size         = bn254.SizeOfG1AffineUncompressed
+	rawClaimedValues  = 7
+	rawBatchedProofAt = 7 * rawG1Size
+	rawClaimedAt      = rawBatchedProofAt + rawG1Size + 4
+	rawZShiftedAt     = rawClaimedAt + rawClaimedValues*fr_bn254.Bytes
+	rawProofSize      = rawZShiftedAt + rawG1Size + fr_bn254.Bytes
+)
+
+// SolidityProof returns the proof as the serialized_proof the verifier
+// ExportSolidity writes takes: the commitments to l, r, o, z and the three
+// parts of the quotient, the values at ζ of l, r and o, of z at ζω, of the
+// quotient and the linearized polynomial, of s1 and s2, and the opening
+// proofs at ζ and ζω. Points are their x and y coordinates, the point at
+// infinity (0, 0).
+func SolidityProof(proof plonk.Proof, curveID ecc.ID) ([]*big.Int, error) {
+	if curveID != ecc.BN254 {
+		return nil, fmt.Errorf("solidity verifiers are only generated for %s, not %s", ecc.BN254, curveID)
+	}
+	var rawProof bytes.Buffer
+	if _, err := proof.WriteRawTo(&rawProof); err != nil {
+		return nil, err
+	}
+	encoded := rawProof.Bytes()
+	if len(encoded) != rawProofSize {
+		return nil, fmt.Errorf("proof of %d bytes, expected %d", len(encoded), rawProofSize)
+	}
+	if claimedValues := binary.BigEndian.Uint32(encoded[rawClaimedAt-4:]); claimedValues != rawClaimedValues {
+		return nil, fmt.Errorf("proof opens %d polynomials at ζ, expected %d", claimedValues, rawClaimedValues)
+	}
+
+	solidityProof := make([]*big.Int, 0, solidityProofSize)
+	point := func(at int) {
+		x := append([]byte{}, encoded[at:at+fr_bn254.Bytes]...)
+		// The top bits of x flag the point at infinity, whose coordinates
+		// are 0.
+		x[0] &^= 0b11 << 6
+		y := encoded[at+fr_bn254.Bytes : at+rawG1Size]
+		solidityProof = append(solidityProof, new(big.Int).SetBytes(x), new(big.Int).SetBytes(y))
+	}
+	scalar := func(at int) {
+		solidityProof = append(solidityProof, new(big.Int).SetBytes(encoded[at:at+fr_bn254.Bytes]))
+	}
+	claimedValue := func(i int) {
+		scalar(rawClaimedAt + i*fr_bn254.Bytes)
+	}
+
+	// LRO, Z and H.
+	for i := 0; i < 7; i++ {
+		point(i * rawG1Size)
+	}
+	// l, r and o at ζ.
+	claimedValue(2)
+	claimedValue(3)
+	claimedValue(4)
+	scalar(rawZShiftedAt + rawG1Size)
+	// The quotient and the linearized polynomial at ζ.
+	claimedValue(0)
+	claimedValue(1)
+	// s1 and s2 at ζ.
+	claimedValue(5)
+	claimedValue(6)
+	point(rawBatchedProofAt)
+	point(rawZShiftedAt)
+	return solidityProof, nil
+}
+
+// SolidityPublicInputs returns the public inputs of the circuit for the
+// values in the order the verifier expects them, the order of the public
+// variables of the sparse R1CS: by increasing witness index.
+func SolidityPublicInputs(circuit acir.ACIR, values felt.Vector) []*big.Int {
+	publicVariables := backend.PublicValues(circuit, values)
+	publicInputs := make([]*big.Int, len(publicVariables))
+	for i := range publicVariables {
+		publicInputs[i] = publicVariables[i].BigInt(new(big.Int))
+	}
+	return publicInputs
+}
+
+// SolidityCalldata returns the ABI encoded calldata of a call of
+// SolidityVerifyMethod that verifies the proof of the circuit for the
+// values. Only the public values matter.
+func SolidityCalldata(circuit acir.ACIR, proof plonk.Proof, values felt.Vector) ([]byte, error) {
+	solidityProof, err := SolidityProof(proof, circuit.CurveID())
+	if err != nil {
+		return nil, err
+	}
+	return encodeCall(SolidityVerifyMethod, SolidityPublicInputs(circuit, values), solidityProof), nil
+}
+
+// encodeCall ABI encodes a call of a method whose parameters are all
+// uint256[]: the selector, the offsets of the arrays and then every array
+// as its length followed by its elements.
+func encodeCall(method string, arrays ...[]*big.Int) []byte {
+	keccak := sha3.NewLegacyKeccak256()
+	keccak.Write([]byte(method))
+	calldata := keccak.Sum(nil)[:4]
+
+	word := func(v *big.Int) {
+		calldata = append(calldata, v.FillBytes(make([]byte, 32))...)
+	}
+	offset := 32 * len(arrays)
+	for _, array := range arrays {
+		word(big.NewInt(int64(offset)))
+		offset += 32 * (1 + len(array))
+	}
+	for _, array := range arrays {
+		word(big.NewInt(int64(len(array))))
+		for _, v := range array {
+			word(v)
+		}
+	}
+	return calldata
+}
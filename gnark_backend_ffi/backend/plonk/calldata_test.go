@@ -0,0 +1,99 @@
+package plonk_backend
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/stretchr/testify/assert"
+)
+
+const verifierABI = `[{"type":"function","name":"verify_serialized_proof","stateMutability":"view",
+	"inputs":[{"name":"public_inputs","type":"uint256[]"},{"name":"serialized_proof","type":"uint256[]"}],
+	"outputs":[{"name":"","type":"bool"}]}]`
+
+func TestSolidityProof(t *testing.T) {
+	withDevSRS(t)
+	circuit := witnessCircuit(t)
+	values := memoryValues(2, 5, 3)
+	pk, _, fingerprint := Preprocess(circuit, values)
+	proof := ProveWithPK(circuit, pk, &fingerprint, values, ecc.BN254)
+
+	solidityProof, err := SolidityProof(proof, ecc.BN254)
+	assert.NoError(t, err)
+	assert.Len(t, solidityProof, solidityProofSize)
+	// The 7 commitments and then, after the 9 values, the 2 opening proofs.
+	for _, i := range []int{0, 2, 4, 6, 8, 10, 12, 22, 24} {
+		var point bn254.G1Affine
+		point.X.SetBigInt(solidityProof[i])
+		point.Y.SetBigInt(solidityProof[i+1])
+		assert.True(t, point.IsOnCurve(), "point at %d", i)
+	}
+	for _, v := range solidityProof[14:22] {
+		assert.Equal(t, -1, v.Cmp(ecc.BN254.ScalarField()))
+	}
+
+	_, err = SolidityProof(proof, ecc.BLS12_381)
+	assert.Error(t, err)
+}
+
+func TestSolidityPublicInputs(t *testing.T) {
+	circuit := witnessCircuit(t)
+	circuit.PublicInputs = []uint32{3, 1}
+
+	// By increasing witness index, not in the order of the circuit.
+	assert.Equal(t, []*big.Int{big.NewInt(2), big.NewInt(3)}, SolidityPublicInputs(circuit, memoryValues(2, 5, 3)))
+}
+
+func TestSolidityCalldataMatchesABI(t *testing.T) {
+	withDevSRS(t)
+	circuit := witnessCircuit(t)
+	values := memoryValues(2, 5, 3)
+	pk, _, fingerprint := Preprocess(circuit, values)
+	proof := ProveWithPK(circuit, pk, &fingerprint, values, ecc.BN254)
+
+	calldata, err := SolidityCalldata(circuit, proof, memoryValues(0, 5, 0))
+	assert.NoError(t, err)
+
+	contractABI, err := abi.JSON(strings.NewReader(verifierABI))
+	assert.NoError(t, err)
+	assert.Equal(t, contractABI.Methods["verify_serialized_proof"].Sig, SolidityVerifyMethod)
+	solidityProof, err := SolidityProof(proof, ecc.BN254)
+	assert.NoError(t, err)
+	expectedCalldata, err := contractABI.Pack("verify_serialized_proof", []*big.Int{big.NewInt(5)}, solidityProof)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedCalldata, calldata)
+}
+
+func TestSolidityCalldataOnEVM(t *testing.T) {
+	withDevSRS(t)
+
+	for verifierName, publicInputs := range map[string][]uint32{"verifier": {2}, "verifier_2_inputs": {3, 1}} {
+		t.Run(verifierName, func(t *testing.T) {
+			circuit := witnessCircuit(t)
+			circuit.PublicInputs = publicInputs
+			values := memoryValues(2, 5, 3)
+			pk, vk, fingerprint := Preprocess(circuit, values)
+			proof := ProveWithPK(circuit, pk, &fingerprint, values, ecc.BN254)
+			verifier := deploySolidityVerifier(t, verifierName, vk, &fingerprint)
+
+			verify := func(values felt.Vector) bool {
+				calldata, err := SolidityCalldata(circuit, proof, values)
+				assert.NoError(t, err)
+				output, err := verifier.CallData(calldata)
+				assert.NoError(t, err)
+				assert.Len(t, output, 32)
+				return new(big.Int).SetBytes(output).Sign() != 0
+			}
+			assert.True(t, verify(values))
+			assert.False(t, verify(memoryValues(2, 6, 4)))
+			// The public inputs are in the order of the witnesses.
+			assert.Equal(t, len(publicInputs) == 1, verify(memoryValues(3, 5, 2)))
+		})
+	}
+}
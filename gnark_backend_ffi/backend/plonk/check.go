@@ -0,0 +1,214 @@
+package plonk_backend
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"gnark_backend_ffi/acir"
+	"gnark_backend_ffi/backend"
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/constraint"
+)
+
+// UnsatisfiedConstraint is a sparse R1C that doesn't hold for a witness.
+// L, R, O and M are its terms evaluated (M being qM⋅xa⋅xb) and K its
+// constant, so L + R + O + M + K is not zero.
+type UnsatisfiedConstraint struct {
+	ConstraintIndex int
+	OpcodeIndex     int
+	// Opcode describes the opcode the constraint was built from.
+	Opcode        string
+	L, R, O, M, K felt.Element
+}
+
+func (u UnsatisfiedConstraint) String() string {
+	return fmt.Sprintf("constraint %d (%s): L=%s R=%s O=%s M=%s K=%s", u.ConstraintIndex, u.Opcode, u.L.String(), u.R.String(), u.O.String(), u.M.String(), u.K.String())
+}
+
+// UnsatisfiedConstraints is the report of CheckConstraints, it is an error so
+// it can be returned as such.
+type UnsatisfiedConstraints []UnsatisfiedConstraint
+
+func (u UnsatisfiedConstraints) Error() string {
+	messages := make([]string, 0, len(u))
+	for _, unsatisfiedConstraint := range u {
+		messages = append(messages, unsatisfiedConstraint.String())
+	}
+	return fmt.Sprintf("%d unsatisfied constraints:\n%s", len(u), strings.Join(messages, "\n"))
+}
+
+// CheckConstraints builds the sparse R1CS of the circuit and evaluates every
+// constraint against the values, without stopping at the first failure like
+// gnark's solver does. Internal variables are solved the way gnark does it:
+// from the O wire of the constraint that introduces them or from their hint.
+// The error is only for constraint systems that can't be evaluated.
+func CheckConstraints(circuit acir.ACIR, values felt.Vector) (UnsatisfiedConstraints, error) {
+	sparseR1CS, publicVariables, secretVariables, origins := BuildTracedSparseR1CS(circuit, values)
+	return checkSparseR1CS(circuit, sparseR1CS, publicVariables, secretVariables, origins)
+}
+
+// checkSparseR1CS checks a sparse R1CS built by BuildTracedSparseR1CS.
+func checkSparseR1CS(circuit acir.ACIR, sparseR1CS backend.SparseR1CS, publicVariables felt.Vector, secretVariables felt.Vector, origins []int) (UnsatisfiedConstraints, error) {
+	witness := make(felt.Vector, 0, len(publicVariables)+len(secretVariables))
+	witness = append(witness, publicVariables...)
+	witness = append(witness, secretVariables...)
+	evaluator, err := newConstraintEvaluator(sparseR1CS, witness)
+	if err != nil {
+		return nil, err
+	}
+
+	constraints, _ := sparseR1CS.GetConstraints()
+	var unsatisfiedConstraints UnsatisfiedConstraints
+	for i, c := range constraints {
+		unsatisfiedConstraint, err := evaluator.check(c)
+		if err != nil {
+			return nil, fmt.Errorf("constraint %d (%s): %w", i, circuit.DescribeOpcode(origins[i]), err)
+		}
+		if unsatisfiedConstraint != nil {
+			unsatisfiedConstraint.ConstraintIndex = i
+			unsatisfiedConstraint.OpcodeIndex = origins[i]
+			unsatisfiedConstraint.Opcode = circuit.DescribeOpcode(origins[i])
+			unsatisfiedConstraints = append(unsatisfiedConstraints, *unsatisfiedConstraint)
+		}
+	}
+
+	return unsatisfiedConstraints, nil
+}
+
+type constraintEvaluator struct {
+	sparseR1CS    backend.SparseR1CS
+	coefficients  []constraint.Coeff
+	hints         map[int]*constraint.Hint
+	values        []constraint.Coeff
+	solved        []bool
+	hintFunctions map[hint.ID]hint.Function
+}
+
+func newConstraintEvaluator(sparseR1CS backend.SparseR1CS, witness felt.Vector) (*constraintEvaluator, error) {
+	nbVariables := sparseR1CS.GetNbPublicVariables() + sparseR1CS.GetNbSecretVariables() + sparseR1CS.GetNbInternalVariables()
+	if len(witness) != sparseR1CS.GetNbPublicVariables()+sparseR1CS.GetNbSecretVariables() {
+		return nil, fmt.Errorf("invalid witness size, got %d, expected %d", len(witness), sparseR1CS.GetNbPublicVariables()+sparseR1CS.GetNbSecretVariables())
+	}
+
+	e := &constraintEvaluator{
+		sparseR1CS:    sparseR1CS,
+		coefficients:  backend.Coefficients(sparseR1CS),
+		hints:         backend.System(sparseR1CS).MHints,
+		values:        make([]constraint.Coeff, nbVariables),
+		solved:        make([]bool, nbVariables),
+		hintFunctions: make(map[hint.ID]hint.Function),
+	}
+	for i := range witness {
+		e.values[i] = sparseR1CS.FromInterface(witness[i].BigInt(new(big.Int)))
+		e.solved[i] = true
+	}
+	for _, hintFunction := range hint.GetRegistered() {
+		e.hintFunctions[hint.UUID(hintFunction)] = hintFunction
+	}
+	return e, nil
+}
+
+func (e *constraintEvaluator) term(t constraint.Term) constraint.Coeff {
+	value := e.coefficients[t.CoeffID()]
+	e.sparseR1CS.Mul(&value, &e.values[t.WireID()])
+	return value
+}
+
+// felt returns the canonical value of a coefficient.
+func (e *constraintEvaluator) felt(c constraint.Coeff) felt.Element {
+	return felt.FromBigInt(e.sparseR1CS.ToBigInt(&c))
+}
+
+// solve computes the wire of a term if it is still unknown.
+func (e *constraintEvaluator) solve(t constraint.Term) error {
+	if t.CoeffID() == constraint.CoeffIdZero {
+		return nil
+	}
+	return e.solveWire(t.WireID())
+}
+
+// solveWire computes a wire that is still unknown, only hint outputs can be
+// solved this way.
+func (e *constraintEvaluator) solveWire(wire int) error {
+	if e.solved[wire] {
+		return nil
+	}
+	h, ok := e.hints[wire]
+	if !ok {
+		return fmt.Errorf("variable %d can't be solved", wire)
+	}
+	hintFunction, ok := e.hintFunctions[h.ID]
+	if !ok {
+		return fmt.Errorf("missing hint function for variable %d", wire)
+	}
+
+	inputs := make([]*big.Int, len(h.Inputs))
+	for i, linearExpression := range h.Inputs {
+		var input constraint.Coeff
+		for _, t := range linearExpression {
+			if t.IsConstant() {
+				e.sparseR1CS.Add(&input, &e.coefficients[t.CoeffID()])
+				continue
+			}
+			if err := e.solveWire(t.WireID()); err != nil {
+				return err
+			}
+			value := e.term(t)
+			e.sparseR1CS.Add(&input, &value)
+		}
+		inputs[i] = e.sparseR1CS.ToBigInt(&input)
+	}
+	outputs := make([]*big.Int, len(h.Wires))
+	for i := range outputs {
+		outputs[i] = new(big.Int)
+	}
+	if err := hintFunction(e.sparseR1CS.Field(), inputs, outputs); err != nil {
+		return err
+	}
+	for i, hintWire := range h.Wires {
+		e.values[hintWire] = e.sparseR1CS.FromInterface(outputs[i])
+		e.solved[hintWire] = true
+	}
+	return nil
+}
+
+// check solves the O wire of the constraint if it is unknown and returns the
+// evaluated constraint if it doesn't hold.
+func (e *constraintEvaluator) check(c constraint.SparseR1C) (*UnsatisfiedConstraint, error) {
+	for _, t := range []constraint.Term{c.L, c.R, c.M[0], c.M[1]} {
+		if err := e.solve(t); err != nil {
+			return nil, err
+		}
+	}
+
+	l, r := e.term(c.L), e.term(c.R)
+	m, m1 := e.term(c.M[0]), e.term(c.M[1])
+	e.sparseR1CS.Mul(&m, &m1)
+	k := e.coefficients[c.K]
+
+	sum := l
+	e.sparseR1CS.Add(&sum, &r)
+	e.sparseR1CS.Add(&sum, &m)
+	e.sparseR1CS.Add(&sum, &k)
+	if _, hasHint := e.hints[c.O.WireID()]; c.O.CoeffID() != constraint.CoeffIdZero && !e.solved[c.O.WireID()] && !hasHint {
+		// qO⋅xc = -(L + R + M + K)
+		xc := e.coefficients[c.O.CoeffID()]
+		e.sparseR1CS.Inverse(&xc)
+		e.sparseR1CS.Mul(&xc, &sum)
+		e.sparseR1CS.Neg(&xc)
+		e.values[c.O.WireID()] = xc
+		e.solved[c.O.WireID()] = true
+	} else if err := e.solve(c.O); err != nil {
+		return nil, err
+	}
+	o := e.term(c.O)
+
+	e.sparseR1CS.Add(&sum, &o)
+	if sum == (constraint.Coeff{}) {
+		return nil, nil
+	}
+	return &UnsatisfiedConstraint{L: e.felt(l), R: e.felt(r), O: e.felt(o), M: e.felt(m), K: e.felt(k)}, nil
+}
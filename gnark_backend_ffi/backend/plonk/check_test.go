@@ -0,0 +1,53 @@
+package plonk_backend
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"gnark_backend_ffi/acir"
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckConstraints(t *testing.T) {
+	// w1 − w2 = 0 and w2 − w3 = 0.
+	var circuit acir.ACIR
+	err := json.Unmarshal([]byte(`{"current_witness_index":3,"opcodes":[
+		{"Arithmetic":{"mul_terms":[],"linear_combinations":[["`+one+`",1],["`+minusOne+`",2]],"q_c":"`+zero+`"}},
+		{"Arithmetic":{"mul_terms":[],"linear_combinations":[["`+one+`",2],["`+minusOne+`",3]],"q_c":"`+zero+`"}}
+	],"public_inputs":[],"assert_messages":[[{"Acir":1},"y != z"]]}`), &circuit)
+	assert.NoError(t, err)
+
+	unsatisfiedConstraints, err := CheckConstraints(circuit, memoryValues(1, 1, 1))
+	assert.NoError(t, err)
+	assert.Empty(t, unsatisfiedConstraints)
+
+	unsatisfiedConstraints, err = CheckConstraints(circuit, memoryValues(2, 3, 4))
+	assert.NoError(t, err)
+	assert.Len(t, unsatisfiedConstraints, 2)
+	assert.Equal(t, 0, unsatisfiedConstraints[0].OpcodeIndex)
+	assert.Equal(t, 1, unsatisfiedConstraints[1].ConstraintIndex)
+	assert.Equal(t, 1, unsatisfiedConstraints[1].OpcodeIndex)
+	assert.Equal(t, felt.NewElement(3), unsatisfiedConstraints[1].L)
+	minusFour := new(big.Int).Sub(ecc.BN254.ScalarField(), big.NewInt(4))
+	assert.Equal(t, felt.FromBigInt(minusFour), unsatisfiedConstraints[1].R)
+	assert.Contains(t, unsatisfiedConstraints.Error(), `constraint 1 (opcode 1: w2 − w3 = 0, assert "y != z"): L=3 R=`)
+}
+
+func TestCheckConstraintsSolvesInternalVariables(t *testing.T) {
+	circuit := memoryOpCircuit()
+
+	unsatisfiedConstraints, err := CheckConstraints(circuit, memoryValues(10, 20, 30, 1, 20, 99, 99, 10))
+	assert.NoError(t, err)
+	assert.Empty(t, unsatisfiedConstraints)
+
+	// A wrong read only breaks the gate of the memory argument that compares
+	// it, which is traced to the opcode that initialized the block.
+	unsatisfiedConstraints, err = CheckConstraints(circuit, memoryValues(10, 20, 30, 1, 30, 99, 99, 10))
+	assert.NoError(t, err)
+	assert.Len(t, unsatisfiedConstraints, 1)
+	assert.Equal(t, 0, unsatisfiedConstraints[0].OpcodeIndex)
+}
@@ -0,0 +1,462 @@
+package plonk_backend
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"math/bits"
+	"sort"
+
+	acir_opcode "gnark_backend_ffi/acir/opcode"
+
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/constraint"
+)
+
+// Memory blocks are lowered with a memory argument. Until a block is
+// accessed at a dynamic index or under a predicate, accesses are free: they
+// only read or rebind the variable of the cell. From then on every access is
+// recorded as its index, time, value and whether it writes, and once the
+// block is complete its records are routed through a Beneš network into their
+// order by index and time, where every read must see the value of the
+// previous record of its cell. A hint computes the switches of the network,
+// so the argument needs no in-circuit randomness, and it costs O(r⋅log(r))
+// constraints for r records instead of O(len) per access.
+//
+// Accesses whose predicate is 0 are turned into reads of a dummy cell past
+// the end of the block, which always holds 0.
+
+// memoryBlock keeps the variables holding the current value of every cell of
+// a block, and the records of its memory argument once it needs one.
+type memoryBlock struct {
+	values   []int
+	readOnly bool
+	records  []memoryRecord
+	// opcodeIndex is the opcode that initialized the block, the constraints
+	// of the memory argument are traced to it.
+	opcodeIndex int
+	constants   map[int64]int
+}
+
+// memoryRecord is an access to a block. write is 1 if the access writes the
+// cell and isWrite tells whether it may.
+type memoryRecord struct {
+	index, value, write int
+	isWrite             bool
+}
+
+// noVariable marks a missing predicate or cell.
+const noVariable = -1
+
+// sparseGate is qL⋅xa + qR⋅xb + qO⋅xc + qM⋅(xa⋅xb) + qC == 0. A nil
+// coefficient is zero, they are reduced in the field of the sparse R1CS.
+type sparseGate struct {
+	xa, xb, xc         int
+	qL, qR, qO, qM, qC *big.Int
+}
+
+func addSparseGate(sparseR1CS constraint.SparseR1CS, g sparseGate) {
+	coefficient := func(q *big.Int) constraint.Coeff {
+		if q == nil {
+			return constraint.Coeff{}
+		}
+		return sparseR1CS.FromInterface(q)
+	}
+	qL := coefficient(g.qL)
+	qR := coefficient(g.qR)
+	qO := coefficient(g.qO)
+	qM1 := coefficient(g.qM)
+	qM2 := sparseR1CS.FromInterface(1)
+	qC := coefficient(g.qC)
+
+	K := sparseR1CS.MakeTerm(&qC, 0)
+	K.MarkConstant()
+
+	sparseR1CS.AddConstraint(constraint.SparseR1C{
+		L: sparseR1CS.MakeTerm(&qL, g.xa),
+		R: sparseR1CS.MakeTerm(&qR, g.xb),
+		O: sparseR1CS.MakeTerm(&qO, g.xc),
+		M: [2]constraint.Term{sparseR1CS.MakeTerm(&qM1, g.xa), sparseR1CS.MakeTerm(&qM2, g.xb)},
+		K: K.CoeffID(),
+	})
+}
+
+func coeff(i int64) *big.Int {
+	return big.NewInt(i)
+}
+
+// The following helpers return a new internal variable constrained to the
+// result. The new variable is always the O wire so the solver computes it.
+
+func newProduct(sparseR1CS constraint.SparseR1CS, xa int, xb int) int {
+	xc := sparseR1CS.AddInternalVariable()
+	addSparseGate(sparseR1CS, sparseGate{xa: xa, xb: xb, xc: xc, qM: coeff(1), qO: coeff(-1)})
+	return xc
+}
+
+func newLinearCombination(sparseR1CS constraint.SparseR1CS, qL *big.Int, xa int, qR *big.Int, xb int) int {
+	xc := sparseR1CS.AddInternalVariable()
+	addSparseGate(sparseR1CS, sparseGate{xa: xa, xb: xb, xc: xc, qL: qL, qR: qR, qO: coeff(-1)})
+	return xc
+}
+
+func assertEqual(sparseR1CS constraint.SparseR1CS, xa int, xb int) {
+	addSparseGate(sparseR1CS, sparseGate{xa: xa, xb: xb, qL: coeff(1), qR: coeff(-1)})
+}
+
+// expressionVariable returns a variable constrained to the value of the
+// expression, chaining a gate per term.
+func expressionVariable(e acir_opcode.Expression, sparseR1CS constraint.SparseR1CS, indexMap map[string]int) int {
+	if len(e.MulTerms) == 0 && len(e.SimpleTerms) == 1 && e.QC.IsZero() && e.SimpleTerms[0].Coefficient.IsOne() {
+		return indexMap[fmt.Sprint(int(e.SimpleTerms[0].VariableIndex))]
+	}
+
+	// The constant goes in the first gate of the chain.
+	accumulator := noVariable
+	accumulate := func(g sparseGate) {
+		g.xc = sparseR1CS.AddInternalVariable()
+		g.qO = coeff(-1)
+		if accumulator == noVariable {
+			g.qC = e.QC.BigInt(new(big.Int))
+		}
+		addSparseGate(sparseR1CS, g)
+		accumulator = g.xc
+	}
+
+	for _, mulTerm := range e.MulTerms {
+		xa := indexMap[fmt.Sprint(int(mulTerm.MultiplicandIndex))]
+		xb := indexMap[fmt.Sprint(int(mulTerm.MultiplierIndex))]
+		if accumulator == noVariable {
+			accumulate(sparseGate{xa: xa, xb: xb, qM: mulTerm.Coefficient.BigInt(new(big.Int))})
+			continue
+		}
+		product := sparseR1CS.AddInternalVariable()
+		addSparseGate(sparseR1CS, sparseGate{xa: xa, xb: xb, xc: product, qM: mulTerm.Coefficient.BigInt(new(big.Int)), qO: coeff(-1)})
+		accumulate(sparseGate{xa: accumulator, xb: product, qL: coeff(1), qR: coeff(1)})
+	}
+	for _, simpleTerm := range e.SimpleTerms {
+		x := indexMap[fmt.Sprint(int(simpleTerm.VariableIndex))]
+		if accumulator == noVariable {
+			accumulate(sparseGate{xa: x, qL: simpleTerm.Coefficient.BigInt(new(big.Int))})
+			continue
+		}
+		accumulate(sparseGate{xa: accumulator, xb: x, qL: coeff(1), qR: simpleTerm.Coefficient.BigInt(new(big.Int))})
+	}
+	if accumulator == noVariable {
+		accumulate(sparseGate{})
+	}
+
+	return accumulator
+}
+
+// constantIndex returns the position a constant index expression points
+// to, acir.Validate checks that it is inside the block.
+func (b *memoryBlock) constantIndex(index acir_opcode.Expression) int {
+	var position big.Int
+	index.QC.BigInt(&position)
+	return int(position.Uint64())
+}
+
+// constant returns a variable constrained to c, shared by the records of the
+// block.
+func (b *memoryBlock) constant(sparseR1CS constraint.SparseR1CS, c int64) int {
+	if b.constants == nil {
+		b.constants = make(map[int64]int)
+	}
+	if variable, ok := b.constants[c]; ok {
+		return variable
+	}
+	variable := sparseR1CS.AddInternalVariable()
+	addSparseGate(sparseR1CS, sparseGate{xc: variable, qO: coeff(-1), qC: coeff(c)})
+	b.constants[c] = variable
+	return variable
+}
+
+// access lowers a memory operation on the block. If predicate is not
+// noVariable the operation only takes place when it is 1, Noir guarantees
+// predicates are boolean.
+func (b *memoryBlock) access(op acir_opcode.MemoryOperation, predicate int, sparseR1CS constraint.SparseR1CS, indexMap map[string]int) {
+	isWrite, err := op.IsWrite()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if isWrite && b.readOnly {
+		log.Fatal("write to a read only memory block")
+	}
+	value := expressionVariable(op.Value, sparseR1CS, indexMap)
+
+	if b.records == nil && op.Index.IsConstant() && predicate == noVariable {
+		position := b.constantIndex(op.Index)
+		if isWrite {
+			b.values[position] = value
+		} else {
+			assertEqual(sparseR1CS, value, b.values[position])
+		}
+		return
+	}
+
+	if b.records == nil {
+		// The cells are initialized with their current values, then the
+		// dummy cell with 0.
+		for j, cell := range b.values {
+			b.records = append(b.records, memoryRecord{index: b.constant(sparseR1CS, int64(j)), value: cell, write: b.constant(sparseR1CS, 1), isWrite: true})
+		}
+		dummy := b.constant(sparseR1CS, int64(len(b.values)))
+		b.records = append(b.records, memoryRecord{index: dummy, value: b.constant(sparseR1CS, 0), write: b.constant(sparseR1CS, 1), isWrite: true})
+	}
+	b.records = append(b.records, b.record(op.Index, value, isWrite, predicate, sparseR1CS, indexMap))
+}
+
+// record returns the record of an access, the one of a read of the dummy
+// cell if predicate is 0.
+func (b *memoryBlock) record(index acir_opcode.Expression, value int, isWrite bool, predicate int, sparseR1CS constraint.SparseR1CS, indexMap map[string]int) memoryRecord {
+	length := int64(len(b.values))
+	r := memoryRecord{value: value, isWrite: isWrite}
+	if isWrite {
+		r.write = b.constant(sparseR1CS, 1)
+	} else {
+		r.write = b.constant(sparseR1CS, 0)
+	}
+
+	if index.IsConstant() {
+		r.index = b.constant(sparseR1CS, int64(b.constantIndex(index)))
+		if predicate != noVariable {
+			// predicate⋅(index - len) + len
+			r.index = sparseR1CS.AddInternalVariable()
+			addSparseGate(sparseR1CS, sparseGate{xa: predicate, xc: r.index, qL: coeff(int64(b.constantIndex(index)) - length), qO: coeff(-1), qC: coeff(length)})
+		}
+	} else {
+		r.index = expressionVariable(index, sparseR1CS, indexMap)
+		// The index is in [0, len] by the memory argument, it must not be
+		// the one of the dummy cell: (index - len)⋅inverse = 1, or the
+		// predicate.
+		offset := sparseR1CS.AddInternalVariable()
+		addSparseGate(sparseR1CS, sparseGate{xa: r.index, xc: offset, qL: coeff(1), qO: coeff(-1), qC: coeff(-length)})
+		if predicate != noVariable {
+			offset = newProduct(sparseR1CS, predicate, offset)
+			r.index = sparseR1CS.AddInternalVariable()
+			addSparseGate(sparseR1CS, sparseGate{xa: offset, xc: r.index, qL: coeff(1), qO: coeff(-1), qC: coeff(length)})
+		}
+		one := sparseR1CS.FromInterface(1)
+		inverse, err := sparseR1CS.AddSolverHint(hint.InvZero, []constraint.LinearExpression{{sparseR1CS.MakeTerm(&one, offset)}}, 1)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if predicate != noVariable {
+			addSparseGate(sparseR1CS, sparseGate{xa: offset, xb: inverse[0], xc: predicate, qM: coeff(1), qO: coeff(-1)})
+		} else {
+			addSparseGate(sparseR1CS, sparseGate{xa: offset, xb: inverse[0], qM: coeff(1), qC: coeff(-1)})
+		}
+	}
+
+	if predicate != noVariable {
+		r.value = newProduct(sparseR1CS, predicate, value)
+		if isWrite {
+			r.write = predicate
+		}
+	}
+	return r
+}
+
+// checkRecords lowers the memory argument of the block, if it needs one.
+func (b *memoryBlock) checkRecords(sparseR1CS constraint.SparseR1CS) {
+	if b.records == nil {
+		return
+	}
+
+	length := len(b.values)
+	readOnly := true
+	for _, r := range b.records[length+1:] {
+		readOnly = readOnly && !r.isWrite
+	}
+	// The records are padded to a power of two with reads of the dummy cell.
+	nbRecords := 1
+	for nbRecords < len(b.records) {
+		nbRecords *= 2
+	}
+	for len(b.records) < nbRecords {
+		b.records = append(b.records, memoryRecord{index: b.constant(sparseR1CS, int64(length)), value: b.constant(sparseR1CS, 0), write: b.constant(sparseR1CS, 0)})
+	}
+
+	// Read only blocks don't need the time of their records: every record
+	// of a cell holds the value it was initialized with.
+	nbTimeBits := 0
+	fields := make([][]int, 2, 4)
+	for _, r := range b.records {
+		fields[0] = append(fields[0], r.index)
+		fields[1] = append(fields[1], r.value)
+	}
+	if !readOnly {
+		nbTimeBits = bits.Len(uint(nbRecords))
+		times := make([]int, 0, nbRecords)
+		writes := make([]int, 0, nbRecords)
+		for t, r := range b.records {
+			times = append(times, b.constant(sparseR1CS, int64(t)))
+			writes = append(writes, r.write)
+		}
+		fields = append(fields, times, writes)
+	}
+
+	inputs := []constraint.LinearExpression{{constantTerm(sparseR1CS, int64(nbTimeBits))}}
+	one := sparseR1CS.FromInterface(1)
+	for _, index := range fields[0] {
+		inputs = append(inputs, constraint.LinearExpression{sparseR1CS.MakeTerm(&one, index)})
+	}
+	nbSwitches := nbBenesSwitches(nbRecords)
+	outputs, err := sparseR1CS.AddSolverHint(sortMemoryRecords, inputs, nbSwitches+(nbRecords-1)*nbTimeBits)
+	if err != nil {
+		log.Fatal(err)
+	}
+	switches, timeBits := outputs[:nbSwitches], outputs[nbSwitches:]
+	for _, s := range switches {
+		assertBoolean(sparseR1CS, s)
+	}
+	sorted := routeBenes(sparseR1CS, fields, switches)
+
+	// The indices go from 0 to the dummy cell by steps of 0 or 1, so every
+	// index is in [0, len].
+	indices, values := sorted[0], sorted[1]
+	addSparseGate(sparseR1CS, sparseGate{xa: indices[0], qL: coeff(1)})
+	addSparseGate(sparseR1CS, sparseGate{xa: indices[nbRecords-1], qL: coeff(1), qC: coeff(int64(-length))})
+	for i := 1; i < nbRecords; i++ {
+		step := newLinearCombination(sparseR1CS, coeff(1), indices[i], coeff(-1), indices[i-1])
+		assertBoolean(sparseR1CS, step)
+
+		// (1 - step)⋅(1 - write)⋅(value - previous value) = 0, a read sees the
+		// previous value of its cell. The first record of every cell is its
+		// initialization, which comes first in time.
+		change := newLinearCombination(sparseR1CS, coeff(1), values[i], coeff(-1), values[i-1])
+		if !readOnly {
+			write := sorted[3][i]
+			readChange := sparseR1CS.AddInternalVariable()
+			addSparseGate(sparseR1CS, sparseGate{xa: write, xb: change, xc: readChange, qR: coeff(1), qM: coeff(-1), qO: coeff(-1)})
+			change = readChange
+		}
+		addSparseGate(sparseR1CS, sparseGate{xa: step, xb: change, qR: coeff(1), qM: coeff(-1)})
+
+		if !readOnly {
+			// The records of a cell are ordered by time:
+			// (1 - step)⋅(time - previous time - 1) is in [0, 2^nbTimeBits).
+			times := sorted[2]
+			elapsed := newLinearCombination(sparseR1CS, coeff(1), times[i], coeff(-1), times[i-1])
+			gap := sparseR1CS.AddInternalVariable()
+			addSparseGate(sparseR1CS, sparseGate{xa: step, xb: elapsed, xc: gap, qL: coeff(1), qR: coeff(1), qM: coeff(-1), qO: coeff(-1), qC: coeff(-1)})
+			assertBits(sparseR1CS, gap, timeBits[(i-1)*nbTimeBits:i*nbTimeBits])
+		}
+	}
+}
+
+// constantTerm returns the term of the constant c.
+func constantTerm(sparseR1CS constraint.SparseR1CS, c int64) constraint.Term {
+	qC := sparseR1CS.FromInterface(c)
+	term := sparseR1CS.MakeTerm(&qC, 0)
+	term.MarkConstant()
+	return term
+}
+
+func assertBoolean(sparseR1CS constraint.SparseR1CS, x int) {
+	addSparseGate(sparseR1CS, sparseGate{xa: x, xb: x, qL: coeff(-1), qM: coeff(1)})
+}
+
+// assertBits constrains the given bits, which come from a hint, to be the
+// little-endian decomposition of x.
+func assertBits(sparseR1CS constraint.SparseR1CS, x int, xBits []int) {
+	sum := noVariable
+	for j, bit := range xBits {
+		assertBoolean(sparseR1CS, bit)
+		if sum == noVariable {
+			sum = bit
+			continue
+		}
+		sum = newLinearCombination(sparseR1CS, coeff(1), sum, new(big.Int).Lsh(big.NewInt(1), uint(j)), bit)
+	}
+	assertEqual(sparseR1CS, sum, x)
+}
+
+// sortMemoryRecords orders the records of a memory argument by index and
+// time. Its inputs are the number of bits of the time gaps, 0 for read only
+// blocks, followed by the index of every record in time order. It outputs
+// the switches of the Beneš network that sorts the records followed, for
+// every sorted record but the first, by the bits of its time gap: its time
+// minus the one of the previous record of the same cell minus 1, or 0 if it
+// is the first record of its cell.
+func sortMemoryRecords(field *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	nbTimeBits := int(inputs[0].Uint64())
+	indices := inputs[1:]
+	order := make([]int, len(indices))
+	for t := range order {
+		order[t] = t
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return indices[order[i]].Cmp(indices[order[j]]) < 0
+	})
+
+	switches := benesSwitches(order)
+	if len(outputs) != len(switches)+(len(order)-1)*nbTimeBits {
+		return fmt.Errorf("sorting %d memory records needs %d outputs, not %d", len(order), len(switches)+(len(order)-1)*nbTimeBits, len(outputs))
+	}
+	for i, s := range switches {
+		outputs[i].SetUint64(uint64(s))
+	}
+	timeBits := outputs[len(switches):]
+	for i := 1; i < len(order); i++ {
+		gap := 0
+		if indices[order[i]].Cmp(indices[order[i-1]]) == 0 {
+			gap = order[i] - order[i-1] - 1
+		}
+		for j := 0; j < nbTimeBits; j++ {
+			timeBits[(i-1)*nbTimeBits+j].SetUint64(uint64(gap>>j) & 1)
+		}
+	}
+	return nil
+}
+
+func init() {
+	hint.Register(sortMemoryRecords)
+}
+
+func handleMemoryInitOpcode(m *acir_opcode.MemoryInitOpcode, memoryBlocks map[acir_opcode.BlockID]*memoryBlock, indexMap map[string]int, opcodeIndex int) {
+	block := &memoryBlock{values: make([]int, len(m.Init)), opcodeIndex: opcodeIndex}
+	for j, witness := range m.Init {
+		block.values[j] = indexMap[fmt.Sprint(int(witness))]
+	}
+	memoryBlocks[m.BlockID] = block
+}
+
+func handleMemoryOpOpcode(m *acir_opcode.MemoryOpOpcode, memoryBlocks map[acir_opcode.BlockID]*memoryBlock, sparseR1CS constraint.SparseR1CS, indexMap map[string]int) {
+	block, ok := memoryBlocks[m.BlockID]
+	if !ok {
+		log.Fatalf("memory block %d is used before being initialized", m.BlockID)
+	}
+	predicate := noVariable
+	if m.Predicate != nil {
+		predicate = expressionVariable(*m.Predicate, sparseR1CS, indexMap)
+	}
+	block.access(m.Op, predicate, sparseR1CS, indexMap)
+}
+
+// handleMemoryBlockOpcode lowers the whole trace of a block, the first Len
+// operations are writes to constant indices that initialize it.
+func handleMemoryBlockOpcode(m *acir_opcode.MemoryBlockOpcode, memoryBlocks map[acir_opcode.BlockID]*memoryBlock, sparseR1CS constraint.SparseR1CS, indexMap map[string]int, opcodeIndex int) {
+	block := &memoryBlock{values: make([]int, m.Len), opcodeIndex: opcodeIndex}
+	for j := range block.values {
+		block.values[j] = noVariable
+	}
+	for _, op := range m.Trace[:m.Len] {
+		if !op.Index.IsConstant() {
+			log.Fatalf("memory block %d must be initialized at constant indices", m.ID)
+		}
+		block.values[block.constantIndex(op.Index)] = expressionVariable(op.Value, sparseR1CS, indexMap)
+	}
+	for j, value := range block.values {
+		if value == noVariable {
+			log.Fatalf("memory block %d position %d is not initialized", m.ID, j)
+		}
+	}
+
+	block.readOnly = m.ReadOnly()
+	for _, op := range m.Trace[m.Len:] {
+		block.access(op, noVariable, sparseR1CS, indexMap)
+	}
+	memoryBlocks[m.ID] = block
+}
@@ -0,0 +1,241 @@
+package plonk_backend
+
+import (
+	"math/rand"
+	"testing"
+
+	"gnark_backend_ffi/acir"
+	acir_opcode "gnark_backend_ffi/acir/opcode"
+	"gnark_backend_ffi/acir/term"
+	"gnark_backend_ffi/backend"
+	common "gnark_backend_ffi/internal"
+
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func constantExpression(constant uint64) (e acir_opcode.Expression) {
+	e.QC = felt.NewElement(constant)
+	return
+}
+
+func witnessExpression(witness common.Witness) acir_opcode.Expression {
+	return acir_opcode.Expression{SimpleTerms: []term.SimpleTerm{{Coefficient: felt.One(), VariableIndex: witness}}}
+}
+
+func memoryRead(index acir_opcode.Expression, value common.Witness) acir_opcode.MemoryOperation {
+	return acir_opcode.MemoryOperation{Operation: constantExpression(0), Index: index, Value: witnessExpression(value)}
+}
+
+func memoryWrite(index acir_opcode.Expression, value common.Witness) acir_opcode.MemoryOperation {
+	return acir_opcode.MemoryOperation{Operation: constantExpression(1), Index: index, Value: witnessExpression(value)}
+}
+
+// The array [w1, w2, w3] is read at w4 into w5, then w6 is written at w4,
+// read back into w7 and the first position is read into w8.
+var memoryTrace = []acir_opcode.MemoryOperation{
+	memoryRead(witnessExpression(4), 5),
+	memoryWrite(witnessExpression(4), 6),
+	memoryRead(witnessExpression(4), 7),
+	memoryRead(constantExpression(0), 8),
+}
+
+func memoryOpCircuit() acir.ACIR {
+	opcodes := []acir_opcode.Opcode{{Data: &acir_opcode.MemoryInitOpcode{BlockID: 0, Init: common.Witnesses{1, 2, 3}}}}
+	for _, op := range memoryTrace {
+		opcodes = append(opcodes, acir_opcode.Opcode{Data: &acir_opcode.MemoryOpOpcode{BlockID: 0, Op: op}})
+	}
+	return acir.ACIR{CurrentWitness: 9, Opcodes: opcodes}
+}
+
+func memoryBlockCircuit() acir.ACIR {
+	trace := []acir_opcode.MemoryOperation{
+		memoryWrite(constantExpression(0), 1),
+		memoryWrite(constantExpression(1), 2),
+		memoryWrite(constantExpression(2), 3),
+	}
+	trace = append(trace, memoryTrace...)
+	return acir.ACIR{
+		CurrentWitness: 9,
+		Opcodes:        []acir_opcode.Opcode{{Data: &acir_opcode.MemoryBlockOpcode{Kind: "RAM", ID: 0, Len: 3, Trace: trace}}},
+	}
+}
+
+func memoryValues(values ...uint64) felt.Vector {
+	vector := make(felt.Vector, len(values))
+	for i, value := range values {
+		vector[i] = felt.NewElement(value)
+	}
+	return vector
+}
+
+func isSolved(circuit acir.ACIR, values felt.Vector) error {
+	sparseR1CS, publicVariables, secretVariables := BuildSparseR1CS(circuit, values)
+	witness := backend.BuildWitnesses(sparseR1CS.CurveID().ScalarField(), publicVariables, secretVariables, sparseR1CS.GetNbPublicVariables(), sparseR1CS.GetNbSecretVariables())
+	return sparseR1CS.IsSolved(witness)
+}
+
+func TestMemoryOpcodes(t *testing.T) {
+	for name, circuit := range map[string]acir.ACIR{"MemoryOp": memoryOpCircuit(), "RAM": memoryBlockCircuit()} {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, isSolved(circuit, memoryValues(10, 20, 30, 1, 20, 99, 99, 10)))
+			assert.NoError(t, isSolved(circuit, memoryValues(10, 20, 30, 2, 30, 99, 99, 10)))
+			assert.NoError(t, isSolved(circuit, memoryValues(10, 20, 30, 0, 10, 99, 99, 99)))
+			// Wrong read.
+			assert.Error(t, isSolved(circuit, memoryValues(10, 20, 30, 1, 30, 99, 99, 10)))
+			// The write must be visible.
+			assert.Error(t, isSolved(circuit, memoryValues(10, 20, 30, 1, 20, 99, 20, 10)))
+			// The write must only change the cell it points to.
+			assert.Error(t, isSolved(circuit, memoryValues(10, 20, 30, 1, 20, 99, 99, 99)))
+			// Out of bounds.
+			assert.Error(t, isSolved(circuit, memoryValues(10, 20, 30, 3, 0, 99, 99, 10)))
+		})
+	}
+}
+
+func TestMemoryOpWithPredicate(t *testing.T) {
+	// w5 is written at w4 when w6 is 1, then w4 is read into w7.
+	predicate := witnessExpression(6)
+	circuit := acir.ACIR{
+		CurrentWitness: 8,
+		Opcodes: []acir_opcode.Opcode{
+			{Data: &acir_opcode.MemoryInitOpcode{BlockID: 0, Init: common.Witnesses{1, 2, 3}}},
+			{Data: &acir_opcode.MemoryOpOpcode{BlockID: 0, Op: memoryWrite(witnessExpression(4), 5), Predicate: &predicate}},
+			{Data: &acir_opcode.MemoryOpOpcode{BlockID: 0, Op: memoryRead(witnessExpression(4), 7)}},
+		},
+	}
+
+	assert.NoError(t, isSolved(circuit, memoryValues(10, 20, 30, 1, 99, 1, 99)))
+	assert.NoError(t, isSolved(circuit, memoryValues(10, 20, 30, 1, 99, 0, 20)))
+	assert.Error(t, isSolved(circuit, memoryValues(10, 20, 30, 1, 99, 0, 99)))
+}
+
+func TestMemoryOpWithPredicateOutOfBounds(t *testing.T) {
+	// w5 is read at w4 when w6 is 1, w4 may be out of bounds when it is 0.
+	predicate := witnessExpression(6)
+	circuit := acir.ACIR{
+		CurrentWitness: 6,
+		Opcodes: []acir_opcode.Opcode{
+			{Data: &acir_opcode.MemoryInitOpcode{BlockID: 0, Init: common.Witnesses{1, 2, 3}}},
+			{Data: &acir_opcode.MemoryOpOpcode{BlockID: 0, Op: memoryRead(witnessExpression(4), 5), Predicate: &predicate}},
+		},
+	}
+
+	assert.NoError(t, isSolved(circuit, memoryValues(10, 20, 30, 2, 30, 1)))
+	assert.NoError(t, isSolved(circuit, memoryValues(10, 20, 30, 7, 0, 0)))
+	assert.Error(t, isSolved(circuit, memoryValues(10, 20, 30, 7, 0, 1)))
+	assert.Error(t, isSolved(circuit, memoryValues(10, 20, 30, 3, 0, 1)))
+}
+
+func TestROMBlock(t *testing.T) {
+	// The ROM [w1, w2] is read at w3 into w4 and at w5 into w6.
+	circuit := acir.ACIR{
+		CurrentWitness: 6,
+		Opcodes: []acir_opcode.Opcode{{Data: &acir_opcode.MemoryBlockOpcode{Kind: "ROM", ID: 0, Len: 2, Trace: []acir_opcode.MemoryOperation{
+			memoryWrite(constantExpression(0), 1),
+			memoryWrite(constantExpression(1), 2),
+			memoryRead(witnessExpression(3), 4),
+			memoryRead(witnessExpression(5), 6),
+		}}}},
+	}
+
+	assert.NoError(t, isSolved(circuit, memoryValues(10, 20, 1, 20, 0, 10)))
+	assert.NoError(t, isSolved(circuit, memoryValues(10, 20, 1, 20, 1, 20)))
+	assert.Error(t, isSolved(circuit, memoryValues(10, 20, 1, 10, 0, 10)))
+	assert.Error(t, isSolved(circuit, memoryValues(10, 20, 2, 0, 0, 10)))
+}
+
+func TestMemoryWritesAreOrderedByTime(t *testing.T) {
+	// w2 then w3 are written at w4, which is read into w5.
+	circuit := acir.ACIR{
+		CurrentWitness: 5,
+		Opcodes: []acir_opcode.Opcode{
+			{Data: &acir_opcode.MemoryInitOpcode{BlockID: 0, Init: common.Witnesses{1}}},
+			{Data: &acir_opcode.MemoryOpOpcode{BlockID: 0, Op: memoryWrite(witnessExpression(4), 2)}},
+			{Data: &acir_opcode.MemoryOpOpcode{BlockID: 0, Op: memoryWrite(witnessExpression(4), 3)}},
+			{Data: &acir_opcode.MemoryOpOpcode{BlockID: 0, Op: memoryRead(witnessExpression(4), 5)}},
+		},
+	}
+
+	assert.NoError(t, isSolved(circuit, memoryValues(10, 20, 30, 0, 30)))
+	assert.Error(t, isSolved(circuit, memoryValues(10, 20, 30, 0, 20)))
+	assert.Error(t, isSolved(circuit, memoryValues(10, 20, 30, 0, 10)))
+}
+
+func TestMemoryArgumentCost(t *testing.T) {
+	// Reads a block of n cells n+1 times at dynamic indices, which makes 2n+2
+	// records with the dummy cell, so the network isn't padded.
+	const n = 255
+	values := make([]uint64, 0, 3*n+2)
+	init := make(common.Witnesses, n)
+	for j := range init {
+		init[j] = common.Witness(j + 1)
+		values = append(values, uint64(100+j))
+	}
+	opcodes := []acir_opcode.Opcode{{Data: &acir_opcode.MemoryInitOpcode{BlockID: 0, Init: init}}}
+	for j := 0; j <= n; j++ {
+		index, value := common.Witness(n+2*j+1), common.Witness(n+2*j+2)
+		opcodes = append(opcodes, acir_opcode.Opcode{Data: &acir_opcode.MemoryOpOpcode{BlockID: 0, Op: memoryRead(witnessExpression(index), value)}})
+		position := (7 * j) % n
+		values = append(values, uint64(position), uint64(100+position))
+	}
+	circuit := acir.ACIR{CurrentWitness: common.Witness(len(values)), Opcodes: opcodes}
+
+	assert.NoError(t, isSolved(circuit, memoryValues(values...)))
+	sparseR1CS, _, _ := BuildSparseR1CS(circuit, memoryValues(values...))
+	// A linear scan costs several constraints per cell and access.
+	assert.Less(t, sparseR1CS.GetNbConstraints(), n*(n+1))
+}
+
+// permuteBenes routes values through the Beneš network with the given
+// switches, as routeBenes constrains it.
+func permuteBenes(values []int, switches []uint) ([]int, []uint) {
+	n := len(values)
+	if n < 2 {
+		return values, switches
+	}
+	swap := func(s uint, a int, b int) (int, int) {
+		if s == 1 {
+			return b, a
+		}
+		return a, b
+	}
+	if n == 2 {
+		first, second := swap(switches[0], values[0], values[1])
+		return []int{first, second}, switches[1:]
+	}
+
+	upper := make([]int, n/2)
+	lower := make([]int, n/2)
+	for i := 0; i < n/2; i++ {
+		upper[i], lower[i] = swap(switches[i], values[2*i], values[2*i+1])
+	}
+	switches = switches[n/2:]
+	upper, switches = permuteBenes(upper, switches)
+	lower, switches = permuteBenes(lower, switches)
+	outputs := make([]int, n)
+	for i := 0; i < n/2; i++ {
+		outputs[2*i], outputs[2*i+1] = swap(switches[i], upper[i], lower[i])
+	}
+	return outputs, switches[n/2:]
+}
+
+func TestBenesSwitches(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for n := 1; n <= 64; n *= 2 {
+		for i := 0; i < 20; i++ {
+			sources := random.Perm(n)
+			inputs := make([]int, n)
+			for j := range inputs {
+				inputs[j] = j
+			}
+
+			switches := benesSwitches(sources)
+			assert.Len(t, switches, nbBenesSwitches(n))
+			outputs, rest := permuteBenes(inputs, switches)
+			assert.Equal(t, sources, outputs)
+			assert.Empty(t, rest)
+		}
+	}
+}
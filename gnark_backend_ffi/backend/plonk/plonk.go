@@ -3,20 +3,32 @@ package plonk_backend
 import (
 	"gnark_backend_ffi/acir"
 	"gnark_backend_ffi/backend"
+	"gnark_backend_ffi/internal/felt"
 	"log"
 
 	"github.com/consensys/gnark-crypto/ecc"
-	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/backend/plonk"
 )
 
-func Preprocess(acir acir.ACIR, values fr_bn254.Vector) (pk plonk.ProvingKey, vk plonk.VerifyingKey) {
-	sparseR1CS, _, _ := BuildSparseR1CS(acir, values)
+func Preprocess(circuit acir.ACIR, values felt.Vector) (pk plonk.ProvingKey, vk plonk.VerifyingKey, fingerprint backend.CircuitFingerprint) {
+	sparseR1CS, _, _ := BuildSparseR1CS(circuit, values)
 
-	srs, err := backend.TryLoadSRS(sparseR1CS.CurveID())
+	acirFingerprint, err := backend.FingerprintACIR(circuit)
 	if err != nil {
 		log.Fatal(err)
 	}
+	srs, srsMode, err := backend.TryLoadSRS(sparseR1CS.CurveID(), SRSSize(sparseR1CS))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if srsMode == backend.DevSRS {
+		log.Print("Warning: setting up keys with an insecure dev SRS, they must not be used in production.")
+	}
+	fingerprint = backend.CircuitFingerprint{
+		ACIR:       acirFingerprint,
+		SparseR1CS: backend.FingerprintSparseR1CS(sparseR1CS),
+		SRSMode:    srsMode,
+	}
 
 	pk, vk, err = plonk.Setup(sparseR1CS, srs)
 	if err != nil {
@@ -26,16 +38,32 @@ func Preprocess(acir acir.ACIR, values fr_bn254.Vector) (pk plonk.ProvingKey, vk
 	return
 }
 
-func VerifyWithVK(circuit acir.ACIR, verifyingKey plonk.VerifyingKey, proof plonk.Proof, publicVariables fr_bn254.Vector, curveID ecc.ID) bool {
-	sparseR1CS, publicVariables, secretVariables := BuildSparseR1CS(circuit, publicVariables)
-	witness := backend.BuildWitnesses(curveID.ScalarField(), publicVariables, secretVariables, sparseR1CS.GetNbPublicVariables(), sparseR1CS.GetNbSecretVariables())
+// VerifyWithVK verifies the proof of the circuit for the values, of which
+// only the public ones matter. The circuit is only checked against the key,
+// the constraint system isn't rebuilt.
+func VerifyWithVK(circuit acir.ACIR, verifyingKey plonk.VerifyingKey, keyFingerprint *backend.CircuitFingerprint, proof plonk.Proof, values felt.Vector, curveID ecc.ID) bool {
+	// The verifier only has the public inputs so the constraint system can't
+	// be rebuilt as the prover does, but the circuit must still match.
+	if err := keyFingerprint.CheckACIR(circuit); err != nil {
+		log.Fatal(err)
+	}
 
-	// Setup.
-	srs, err := backend.TryLoadSRS(curveID)
+	return Verify(verifyingKey, keyFingerprint, proof, backend.PublicValues(circuit, values), curveID)
+}
+
+// Verify verifies the proof for the public inputs, ordered by increasing
+// witness index, with only the verifying key.
+func Verify(verifyingKey plonk.VerifyingKey, keyFingerprint *backend.CircuitFingerprint, proof plonk.Proof, publicInputs felt.Vector, curveID ecc.ID) bool {
+	if nbPublicInputs := verifyingKey.NbPublicWitness(); len(publicInputs) != nbPublicInputs {
+		log.Fatalf("%d public inputs, the verifying key expects %d", len(publicInputs), nbPublicInputs)
+	}
+	witness, err := backend.NewWitness(curveID.ScalarField(), publicInputs, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
-	if verifyingKey.InitKZG(srs) != nil {
+
+	// Setup.
+	if _, err := initVerifyingKeyKZG(verifyingKey, keyFingerprint, curveID); err != nil {
 		log.Fatal(err)
 	}
 
@@ -50,16 +78,56 @@ func VerifyWithVK(circuit acir.ACIR, verifyingKey plonk.VerifyingKey, proof plon
 	return true
 }
 
-func ProveWithPK(circuit acir.ACIR, provingKey plonk.ProvingKey, values fr_bn254.Vector, curveID ecc.ID) (proof plonk.Proof) {
-	sparseR1CS, publicVariables, secretVariables := BuildSparseR1CS(circuit, values)
-	witness := backend.BuildWitnesses(sparseR1CS.CurveID().ScalarField(), publicVariables, secretVariables, sparseR1CS.GetNbPublicVariables(), sparseR1CS.GetNbSecretVariables())
+// initVerifyingKeyKZG loads the SRS of the verifying key, which must be of
+// the mode its fingerprint records, into it. It returns that mode.
+func initVerifyingKeyKZG(verifyingKey plonk.VerifyingKey, keyFingerprint *backend.CircuitFingerprint, curveID ecc.ID) (backend.SRSMode, error) {
+	srsSize, err := verifyingKeySize(verifyingKey)
+	if err != nil {
+		return "", err
+	}
+	srs, srsMode, err := backend.TryLoadSRS(curveID, srsSize)
+	if err != nil {
+		return "", err
+	}
+	if err := keyFingerprint.CheckSRSMode(srsMode); err != nil {
+		return "", err
+	}
+	if err := verifyingKey.InitKZG(srs); err != nil {
+		return "", err
+	}
+	return srsMode, nil
+}
+
+func ProveWithPK(circuit acir.ACIR, provingKey plonk.ProvingKey, keyFingerprint *backend.CircuitFingerprint, values felt.Vector, curveID ecc.ID) (proof plonk.Proof) {
+	if err := keyFingerprint.CheckACIR(circuit); err != nil {
+		log.Fatal(err)
+	}
+	sparseR1CS, publicVariables, secretVariables, origins := BuildTracedSparseR1CS(circuit, values)
+	if err := keyFingerprint.CheckSparseR1CS(sparseR1CS); err != nil {
+		log.Fatal(err)
+	}
+	// Report every bad gate instead of gnark's first failure.
+	unsatisfiedConstraints, err := checkSparseR1CS(circuit, sparseR1CS, publicVariables, secretVariables, origins)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(unsatisfiedConstraints) > 0 {
+		log.Fatal(unsatisfiedConstraints)
+	}
+	witness, err := backend.NewWitness(sparseR1CS.CurveID().ScalarField(), publicVariables, secretVariables)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Setup.
-	srs, err := backend.TryLoadSRS(sparseR1CS.CurveID())
+	srs, srsMode, err := backend.TryLoadSRS(sparseR1CS.CurveID(), SRSSize(sparseR1CS))
 	if err != nil {
 		log.Fatal(err)
 	}
-	if provingKey.InitKZG(srs) != nil {
+	if err := keyFingerprint.CheckSRSMode(srsMode); err != nil {
+		log.Fatal(err)
+	}
+	if err := provingKey.InitKZG(srs); err != nil {
 		log.Fatal(err)
 	}
 
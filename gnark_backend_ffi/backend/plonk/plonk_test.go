@@ -0,0 +1,132 @@
+package plonk_backend
+
+import (
+	"testing"
+
+	"gnark_backend_ffi/acir"
+	"gnark_backend_ffi/backend"
+	backend_helpers "gnark_backend_ffi/internal/backend"
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	gnark_backend "github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreprocessProveAndVerify(t *testing.T) {
+	withDevSRS(t)
+
+	for _, curveID := range backend.SupportedCurves {
+		t.Run(curveID.String(), func(t *testing.T) {
+			circuit := curveWitnessCircuit(t, curveID)
+			values := memoryValues(2, 5, 3)
+
+			pk, vk, fingerprint := Preprocess(circuit, values)
+			// Keys go through the FFI hex encoded.
+			pk = backend_helpers.DeserializeProvingKey(backend_helpers.SerializeProvingKey(pk), curveID)
+			vk = backend_helpers.DeserializeVerifyingKey(backend_helpers.SerializeVerifyingKey(vk), curveID)
+
+			proof := ProveWithPK(circuit, pk, &fingerprint, values, curveID)
+			proof = backend_helpers.DeserializeProof(backend_helpers.SerializeProof(proof), curveID)
+
+			// The verifier only keeps the public values.
+			assert.True(t, VerifyWithVK(circuit, vk, &fingerprint, proof, memoryValues(0, 5, 0), curveID))
+			assert.False(t, VerifyWithVK(circuit, vk, &fingerprint, proof, memoryValues(0, 6, 0), curveID))
+		})
+	}
+}
+
+func TestProveAfterALargerCircuitLoadedTheDevSRS(t *testing.T) {
+	withDevSRS(t)
+	circuit := witnessCircuit(t)
+	values := memoryValues(2, 5, 3)
+	pk, vk, fingerprint := Preprocess(circuit, values)
+
+	// A larger circuit loads more points of the same dev SRS, the keys set
+	// up with fewer of them still prove and verify.
+	_, _, err := backend.TryLoadSRS(ecc.BN254, devSRSSize)
+	assert.NoError(t, err)
+
+	proof := ProveWithPK(circuit, pk, &fingerprint, values, ecc.BN254)
+	assert.True(t, VerifyWithVK(circuit, vk, &fingerprint, proof, values, ecc.BN254))
+}
+
+func TestDevKeyIsRejectedInTrustedMode(t *testing.T) {
+	withDevSRS(t)
+	_, vk, fingerprint := Preprocess(witnessCircuit(t), memoryValues(2, 5, 3))
+	encodedVerifyingKey := fingerprint.EmbedInKey(backend_helpers.SerializeVerifyingKey(vk))
+
+	// Even with the dev SRS imported as the trusted one, the mode recorded
+	// in the key rejects it.
+	srss, err := generateDevSRSs()
+	assert.NoError(t, err)
+	path, err := backend.SRSPath(ecc.BN254)
+	assert.NoError(t, err)
+	if !assert.NoError(t, backend.WriteSRSFile(path, srss[ecc.BN254])) {
+		t.FailNow()
+	}
+	t.Setenv(backend.SRSModeEnv, string(backend.TrustedSRS))
+
+	keyFingerprint, encodedVerifyingKey, err := backend.ExtractKeyFingerprint(encodedVerifyingKey)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	vk = backend_helpers.DeserializeVerifyingKey(encodedVerifyingKey, ecc.BN254)
+	_, err = initVerifyingKeyKZG(vk, keyFingerprint, ecc.BN254)
+	assert.ErrorContains(t, err, "key was set up with a dev SRS but the SRS mode is trusted")
+}
+
+func TestProveMemoryArgument(t *testing.T) {
+	withDevSRS(t)
+	circuit := memoryOpCircuit()
+	circuit.PublicInputs = []uint32{4}
+	values := memoryValues(10, 20, 30, 1, 20, 99, 99, 10)
+	pk, vk, fingerprint := Preprocess(circuit, values)
+
+	// The prover solves the hint that sorts the accesses.
+	proof := ProveWithPK(circuit, pk, &fingerprint, values, ecc.BN254)
+	assert.True(t, VerifyWithVK(circuit, vk, &fingerprint, proof, values, ecc.BN254))
+}
+
+func TestVerifyWithoutCircuit(t *testing.T) {
+	withDevSRS(t)
+	circuit := witnessCircuit(t)
+	circuit.PublicInputs = []uint32{3, 1}
+	values := memoryValues(2, 5, 3)
+	pk, vk, fingerprint := Preprocess(circuit, values)
+	proof := ProveWithPK(circuit, pk, &fingerprint, values, ecc.BN254)
+
+	// By increasing witness index.
+	assert.True(t, Verify(vk, &fingerprint, proof, felt.Vector{felt.NewElement(2), felt.NewElement(3)}, ecc.BN254))
+	assert.False(t, Verify(vk, &fingerprint, proof, felt.Vector{felt.NewElement(3), felt.NewElement(2)}, ecc.BN254))
+	assert.True(t, VerifyWithVK(circuit, vk, &fingerprint, proof, memoryValues(2, 0, 3), ecc.BN254))
+}
+
+// forgedProof proves the circuit for forged public inputs and the secret
+// values of the values, ignoring the constraints they don't satisfy.
+func forgedProof(t *testing.T, circuit acir.ACIR, pk plonk.ProvingKey, values felt.Vector, forgedPublicInputs felt.Vector) plonk.Proof {
+	sparseR1CS, _, secretVariables := BuildSparseR1CS(circuit, values)
+	witness, err := backend.NewWitness(sparseR1CS.CurveID().ScalarField(), forgedPublicInputs, secretVariables)
+	assert.NoError(t, err)
+	proof, err := plonk.Prove(sparseR1CS, pk, witness, gnark_backend.IgnoreSolverError())
+	assert.NoError(t, err)
+	return proof
+}
+
+func TestVerifyRejectsForgedPublicInputs(t *testing.T) {
+	withDevSRS(t)
+
+	for name, publicInputs := range map[string][]uint32{"one public input": {2}, "several public inputs": {3, 1}} {
+		t.Run(name, func(t *testing.T) {
+			circuit := witnessCircuit(t)
+			circuit.PublicInputs = publicInputs
+			values := memoryValues(2, 5, 3)
+			pk, vk, fingerprint := Preprocess(circuit, values)
+
+			forgedPublicInputs := memoryValues(100, 77)[:len(publicInputs)]
+			proof := forgedProof(t, circuit, pk, values, forgedPublicInputs)
+			assert.False(t, Verify(vk, &fingerprint, proof, forgedPublicInputs, ecc.BN254))
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package plonk_backend
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"gnark_backend_ffi/backend"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/plonk"
+)
+
+// SolidityVerifierContract is the contract of the verifier ExportSolidity
+// writes. Its verify_serialized_proof(uint256[] public_inputs, uint256[]
+// serialized_proof) returns whether the proof is valid, and reverts when
+// there aren't as many public inputs as the circuit has.
+const SolidityVerifierContract = "KeyedPlonkVerifier"
+
+// ExportSolidity writes the Solidity verifier of the proofs of a verifying
+// key. The verifier embeds the KZG commitment key, so the SRS the key was set
+// up with is loaded. gnark only generates verifiers for BN254, the curve of
+// the precompiles of the EVM.
+func ExportSolidity(verifyingKey plonk.VerifyingKey, keyFingerprint *backend.CircuitFingerprint, curveID ecc.ID, w io.Writer) error {
+	if curveID != ecc.BN254 {
+		return fmt.Errorf("solidity verifiers are only generated for %s, not %s", ecc.BN254, curveID)
+	}
+	srsMode, err := initVerifyingKeyKZG(verifyingKey, keyFingerprint, curveID)
+	if err != nil {
+		return err
+	}
+	if srsMode == backend.DevSRS {
+		log.Print("Warning: exporting a verifier of keys set up with an insecure dev SRS, it must not be deployed in production.")
+	}
+	var verifier strings.Builder
+	if err := verifyingKey.ExportSolidity(&verifier); err != nil {
+		return err
+	}
+	fixedVerifier, err := fixSolidityVerifier(verifier.String(), curveID)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, fixedVerifier)
+	return err
+}
+
+// solidityTemplateGnarkVersion is the version of gnark whose verifier
+// fixSolidityVerifier fixes: solidityTemplate in
+// internal/backend/bn254/plonk/solidity.go, which VerifyingKey.ExportSolidity
+// renders. The fixes must be checked again against the template of any other
+// version, a test fails when the version of gnark changes.
+const solidityTemplateGnarkVersion = "v0.8.0"
+
+// gnark's verifier derives γ from the key and the public inputs in
+// verify_initial, but its prover also binds the commitments to l, r and o,
+// so the verifier rejects every proof unless it binds them too.
+const (
+	solidityGammaBindings = `        for (uint256 i = 0; i < proof.input_values.length; i++) {
+            t.update_with_u256(proof.input_values[i]);
+        }
+        state.gamma = t.get_challenge();`
+	fixedSolidityGammaBindings = `        for (uint256 i = 0; i < proof.input_values.length; i++) {
+            t.update_with_u256(proof.input_values[i]);
+        }
+        for (uint256 i = 0; i < proof.wire_commitments.length; i++) {
+            t.update_with_g1(proof.wire_commitments[i]);
+        }
+        state.gamma = t.get_challenge();`
+)
+
+// negativeScalar matches the scalars of a Solidity verifier printed as
+// negatives.
+var negativeScalar = regexp.MustCompile(`new_fr\(uint256\(-([0-9]+)\)\)`)
+
+// fixSolidityVerifier fixes the verifier gnark generates so it accepts the
+// proofs of its prover and compiles.
+func fixSolidityVerifier(verifier string, curveID ecc.ID) (string, error) {
+	if strings.Count(verifier, solidityGammaBindings) != 1 {
+		return "", fmt.Errorf("unexpected Solidity verifier, γ isn't derived as in gnark %s", solidityTemplateGnarkVersion)
+	}
+	verifier = strings.Replace(verifier, solidityGammaBindings, fixedSolidityGammaBindings, 1)
+	// gnark prints the scalars of the key with fr.Element.String, as in
+	// uint256({{.Generator.String}}), so the small negative ones are printed
+	// as such, like the generator of a domain of 2, -1, which Solidity
+	// doesn't convert to a uint256.
+	return negativeScalar.ReplaceAllStringFunc(verifier, func(match string) string {
+		value, _ := new(big.Int).SetString(negativeScalar.FindStringSubmatch(match)[1], 10)
+		return fmt.Sprintf("new_fr(uint256(%s))", value.Sub(curveID.ScalarField(), value))
+	}), nil
+}
@@ -0,0 +1,221 @@
+package plonk_backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+
+	"gnark_backend_ffi/backend"
+	"gnark_backend_ffi/internal/evm"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	kzg_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/kzg"
+	kzg_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/kzg"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	kzg_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/kzg"
+	kzgg "github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// devSRSSize is the size of the dev SRSs withDevSRS writes, enough for the
+// circuits of the tests.
+const devSRSSize = 1 << 11
+
+var (
+	devSRSs     map[ecc.ID]kzgg.SRS
+	devSRSsErr  error
+	devSRSsOnce sync.Once
+)
+
+// generateDevSRSs generates the dev SRS of every curve once for all tests,
+// from a fixed secret so that the verifiers exported from their keys are
+// always the same.
+func generateDevSRSs() (map[ecc.ID]kzgg.SRS, error) {
+	devSRSsOnce.Do(func() {
+		srss := make(map[ecc.ID]kzgg.SRS)
+		alpha := big.NewInt(42)
+		if srss[ecc.BN254], devSRSsErr = kzg.NewSRS(devSRSSize, alpha); devSRSsErr != nil {
+			return
+		}
+		if srss[ecc.BLS12_381], devSRSsErr = kzg_bls12381.NewSRS(devSRSSize, alpha); devSRSsErr != nil {
+			return
+		}
+		if srss[ecc.BLS12_377], devSRSsErr = kzg_bls12377.NewSRS(devSRSSize, alpha); devSRSsErr != nil {
+			return
+		}
+		if srss[ecc.BW6_761], devSRSsErr = kzg_bw6761.NewSRS(devSRSSize, alpha); devSRSsErr != nil {
+			return
+		}
+		devSRSs = srss
+	})
+	return devSRSs, devSRSsErr
+}
+
+// withDevSRS switches to DevSRS mode in a temporary config dir holding the
+// dev SRSs of generateDevSRSs.
+func withDevSRS(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(backend.SRSPathEnv, "")
+	t.Setenv(backend.SRSModeEnv, string(backend.DevSRS))
+
+	srss, err := generateDevSRSs()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for curveID, srs := range srss {
+		path, err := backend.DevSRSPath(curveID)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, backend.WriteSRSFile(path, srs)) {
+			t.FailNow()
+		}
+	}
+}
+
+func TestExportSolidity(t *testing.T) {
+	withDevSRS(t)
+	circuit := witnessCircuit(t)
+	_, vk, fingerprint := Preprocess(circuit, memoryValues(2, 5, 3))
+
+	var verifier strings.Builder
+	assert.NoError(t, ExportSolidity(vk, &fingerprint, ecc.BN254, &verifier))
+	assert.Contains(t, verifier.String(), "contract "+SolidityVerifierContract)
+	assert.Contains(t, verifier.String(), "vk.num_inputs = 1;")
+	// γ is bound to the commitments to l, r and o as the prover binds it, and
+	// the generator of the domain of 2, -1, is a uint256.
+	assert.Contains(t, verifier.String(), fixedSolidityGammaBindings)
+	assert.Contains(t, verifier.String(), "vk.omega = PairingsBn254.new_fr(uint256(21888242871839275222246405745257275088548364400416034343698204186575808495616));")
+
+	// The verifier embeds the SRS of the key.
+	trustedFingerprint := fingerprint
+	trustedFingerprint.SRSMode = backend.TrustedSRS
+	assert.Error(t, ExportSolidity(vk, &trustedFingerprint, ecc.BN254, &verifier))
+}
+
+func TestExportSolidityOnlyForBN254(t *testing.T) {
+	withDevSRS(t)
+	circuit := curveWitnessCircuit(t, ecc.BLS12_381)
+	_, vk, fingerprint := Preprocess(circuit, memoryValues(2, 5, 3))
+
+	var verifier strings.Builder
+	assert.ErrorContains(t, ExportSolidity(vk, &fingerprint, ecc.BLS12_381, &verifier), "only generated for bn254")
+}
+
+func TestSolidityTemplateGnarkVersion(t *testing.T) {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	for _, module := range buildInfo.Deps {
+		if module.Path == "github.com/consensys/gnark" {
+			if module.Version != solidityTemplateGnarkVersion {
+				t.Fatalf("gnark is %s but fixSolidityVerifier fixes the verifier of gnark %s, check its fixes against the new template and update solidityTemplateGnarkVersion", module.Version, solidityTemplateGnarkVersion)
+			}
+			return
+		}
+	}
+	t.Fatal("gnark isn't a dependency")
+}
+
+func TestSolidityVerifierOnEVM(t *testing.T) {
+	withDevSRS(t)
+	circuit := witnessCircuit(t)
+	values := memoryValues(2, 5, 3)
+	pk, vk, fingerprint := Preprocess(circuit, values)
+	proof := ProveWithPK(circuit, pk, &fingerprint, values, ecc.BN254)
+	solidityProof, err := SolidityProof(proof, ecc.BN254)
+	assert.NoError(t, err)
+
+	verifier := deploySolidityVerifier(t, "verifier", vk, &fingerprint)
+
+	outputs, err := verifier.Call("verify_serialized_proof", []*big.Int{big.NewInt(5)}, solidityProof)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{true}, outputs)
+	outputs, err = verifier.Call("verify_serialized_proof", []*big.Int{big.NewInt(6)}, solidityProof)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{false}, outputs)
+	// The circuit has a single public input.
+	_, err = verifier.Call("verify_serialized_proof", []*big.Int{big.NewInt(5), big.NewInt(5)}, solidityProof)
+	assert.Error(t, err)
+	// Nor are proofs of the wrong length accepted.
+	_, err = verifier.Call("verify_serialized_proof", []*big.Int{big.NewInt(5)}, solidityProof[1:])
+	assert.Error(t, err)
+}
+
+// updateVerifiers compiles the Solidity verifiers the tests deploy with solc
+// and saves them in testdata: go test ./backend/plonk -update.
+var updateVerifiers = flag.Bool("update", false, "compile the Solidity verifiers with solc and save them in testdata")
+
+// verifierSolcVersion is the solc the verifiers in testdata are compiled
+// with, -update fails with another one so their bytecode only changes with
+// their source.
+const verifierSolcVersion = "0.8.21+commit.d9974bed"
+
+// deploySolidityVerifier deploys the verifier of the key in an in-process
+// EVM. It was compiled with solc and saved in testdata, along with its
+// source, which must be the verifier exported for the key.
+func deploySolidityVerifier(t *testing.T, name string, vk plonk.VerifyingKey, fingerprint *backend.CircuitFingerprint) *evm.Contract {
+	var verifier strings.Builder
+	assert.NoError(t, ExportSolidity(vk, fingerprint, ecc.BN254, &verifier))
+	sourcePath := filepath.Join("testdata", name+".sol")
+	bytecodePath := filepath.Join("testdata", name+".bin")
+	// The checksum of the source the bytecode was compiled from, in the
+	// format of sha256sum.
+	sourceChecksumPath := filepath.Join("testdata", name+".sol.sha256")
+	if *updateVerifiers {
+		version, err := evm.SolcVersion()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		if version != verifierSolcVersion {
+			t.Fatalf("the verifiers in testdata are compiled with solc %s but the one in the PATH is %s", verifierSolcVersion, version)
+		}
+		_, bytecode, err := evm.Compile(verifier.String(), SolidityVerifierContract)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.NoError(t, os.WriteFile(sourcePath, []byte(verifier.String()), 0644))
+		assert.NoError(t, os.WriteFile(bytecodePath, []byte(hex.EncodeToString(bytecode)+"\n"), 0644))
+		sourceChecksum := sha256.Sum256([]byte(verifier.String()))
+		assert.NoError(t, os.WriteFile(sourceChecksumPath, []byte(fmt.Sprintf("%x  %s.sol\n", sourceChecksum, name)), 0644))
+	}
+
+	source, err := os.ReadFile(sourcePath)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if string(source) != verifier.String() {
+		t.Fatalf("the verifier exported for the key isn't %s, compile it again with go test ./backend/plonk -run %s -update", sourcePath, t.Name())
+	}
+	sourceChecksum := sha256.Sum256(source)
+	recordedSourceChecksum, err := os.ReadFile(sourceChecksumPath)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if string(recordedSourceChecksum) != fmt.Sprintf("%x  %s.sol\n", sourceChecksum, name) {
+		t.Fatalf("%s wasn't compiled from %s, compile it again with go test ./backend/plonk -run %s -update", bytecodePath, sourcePath, t.Name())
+	}
+	encodedBytecode, err := os.ReadFile(bytecodePath)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	contractABI, err := abi.JSON(strings.NewReader(verifierABI))
+	assert.NoError(t, err)
+	contract, err := evm.Deploy(contractABI, common.FromHex(strings.TrimSpace(string(encodedBytecode))))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return contract
+}
@@ -5,27 +5,77 @@ import (
 	"gnark_backend_ffi/acir"
 	"gnark_backend_ffi/backend"
 	"log"
+	"math/big"
+	"sort"
+	"strings"
 
 	acir_opcode "gnark_backend_ffi/acir/opcode"
+	"gnark_backend_ffi/internal/felt"
 
-	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/constraint"
-	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
 )
 
 // TODO: Make this a method for acir.ACIR.
 // qL⋅xa + qR⋅xb + qO⋅xc + qM⋅(xa⋅xb) + qC == 0
-func BuildSparseR1CS(circuit acir.ACIR, values fr_bn254.Vector) (*cs_bn254.SparseR1CS, fr_bn254.Vector, fr_bn254.Vector) {
-	sparseR1CS := cs_bn254.NewSparseR1CS(int(circuit.CurrentWitness) - 1)
+func BuildSparseR1CS(circuit acir.ACIR, values felt.Vector) (backend.SparseR1CS, felt.Vector, felt.Vector) {
+	sparseR1CS, publicVariables, secretVariables, _ := BuildTracedSparseR1CS(circuit, values)
+	return sparseR1CS, publicVariables, secretVariables
+}
+
+// BuildTracedSparseR1CS also returns, for every constraint, the index of the
+// opcode it was built from. The constraint system carries the description of
+// that opcode as debug info, so unsatisfied constraint errors name it.
+func BuildTracedSparseR1CS(circuit acir.ACIR, values felt.Vector) (backend.SparseR1CS, felt.Vector, felt.Vector, []int) {
+	if err := acir.Validate(circuit); err != nil {
+		log.Fatal(err)
+	}
+
+	sparseR1CS, err := backend.NewSparseR1CS(circuit.CurveID(), int(circuit.CurrentWitness)-1)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	publicVariables, secretVariables, indexMap := backend.HandleValues(circuit, sparseR1CS, values)
-	handleOpcodes(circuit, sparseR1CS, indexMap)
+	tracer := &opcodeTracer{SparseR1CS: sparseR1CS}
+	handleOpcodes(circuit, tracer, indexMap)
 
-	return sparseR1CS, publicVariables, secretVariables
+	return sparseR1CS, publicVariables, secretVariables, tracer.origins
+}
+
+// opcodeTracer records the opcode every constraint added through it comes
+// from.
+type opcodeTracer struct {
+	constraint.SparseR1CS
+	opcodeIndex int
+	// origins holds the opcode index of every constraint.
+	origins []int
+	// constraintIDs holds the constraints of the current opcode.
+	constraintIDs []int
+}
+
+func (t *opcodeTracer) AddConstraint(c constraint.SparseR1C, debugInfo ...constraint.DebugInfo) int {
+	constraintID := t.SparseR1CS.AddConstraint(c, debugInfo...)
+	t.origins = append(t.origins, t.opcodeIndex)
+	t.constraintIDs = append(t.constraintIDs, constraintID)
+	return constraintID
 }
 
-func handleOpcodes(a acir.ACIR, sparseR1CS constraint.SparseR1CS, indexMap map[string]int) {
-	for _, opcode := range a.Opcodes {
+// traceOpcode attaches the description of the opcode to the constraints
+// added since the previous call.
+func (t *opcodeTracer) traceOpcode(description string) {
+	if len(t.constraintIDs) > 0 {
+		// The description is used as a format string when it is logged.
+		debugInfo := constraint.DebugInfo{Format: strings.ReplaceAll(description, "%", "%%")}
+		t.AttachDebugInfo(debugInfo, t.constraintIDs)
+	}
+	t.constraintIDs = t.constraintIDs[:0]
+	t.opcodeIndex++
+}
+
+func handleOpcodes(a acir.ACIR, tracer *opcodeTracer, indexMap map[string]int) {
+	var sparseR1CS constraint.SparseR1CS = tracer
+	memoryBlocks := make(map[acir_opcode.BlockID]*memoryBlock)
+	for i, opcode := range a.Opcodes {
 		switch opcode := opcode.Data.(type) {
 		case *acir_opcode.ArithmeticOpcode:
 			handleArithmeticOpcode(opcode, sparseR1CS, indexMap)
@@ -35,9 +85,38 @@ func handleOpcodes(a acir.ACIR, sparseR1CS constraint.SparseR1CS, indexMap map[s
 			break
 		case *acir_opcode.DirectiveOpcode:
 			break
+		case *acir_opcode.MemoryInitOpcode:
+			handleMemoryInitOpcode(opcode, memoryBlocks, indexMap, i)
+			break
+		case *acir_opcode.MemoryOpOpcode:
+			handleMemoryOpOpcode(opcode, memoryBlocks, sparseR1CS, indexMap)
+			break
+		case *acir_opcode.MemoryBlockOpcode:
+			handleMemoryBlockOpcode(opcode, memoryBlocks, sparseR1CS, indexMap, i)
+			break
+		case *acir_opcode.BrilligOpcode, *acir_opcode.OracleOpcode:
+			// Unconstrained, they only solve witnesses in the ACVM.
+			break
 		default:
 			log.Fatal("unknown opcode type")
 		}
+		tracer.traceOpcode(a.DescribeOpcode(i))
+	}
+
+	// Blocks are complete once every opcode was handled. The memory argument
+	// of every block is traced to the opcode that initialized it, and they
+	// are lowered in the order of the blocks so the constraint system is
+	// always the same.
+	blockIDs := make([]acir_opcode.BlockID, 0, len(memoryBlocks))
+	for blockID := range memoryBlocks {
+		blockIDs = append(blockIDs, blockID)
+	}
+	sort.Slice(blockIDs, func(i, j int) bool { return blockIDs[i] < blockIDs[j] })
+	for _, blockID := range blockIDs {
+		block := memoryBlocks[blockID]
+		tracer.opcodeIndex = block.opcodeIndex
+		block.checkRecords(sparseR1CS)
+		tracer.traceOpcode(a.DescribeOpcode(block.opcodeIndex))
 	}
 }
 
@@ -48,7 +127,7 @@ func handleArithmeticOpcode(a *acir_opcode.ArithmeticOpcode, sparseR1CS constrai
 	// Case qM⋅(xa⋅xb)
 	if len(a.MulTerms) != 0 {
 		mulTerm := a.MulTerms[0]
-		qM1 = sparseR1CS.FromInterface(mulTerm.Coefficient)
+		qM1 = sparseR1CS.FromInterface(mulTerm.Coefficient.BigInt(new(big.Int)))
 		qM2 = sparseR1CS.FromInterface(1)
 		xa = indexMap[fmt.Sprint(int(mulTerm.MultiplicandIndex))]
 		xb = indexMap[fmt.Sprint(int(mulTerm.MultiplierIndex))]
@@ -57,7 +136,7 @@ func handleArithmeticOpcode(a *acir_opcode.ArithmeticOpcode, sparseR1CS constrai
 	// Case qO⋅xc
 	if len(a.SimpleTerms) == 1 {
 		qOwOTerm := a.SimpleTerms[0]
-		qO = sparseR1CS.FromInterface(qOwOTerm.Coefficient)
+		qO = sparseR1CS.FromInterface(qOwOTerm.Coefficient.BigInt(new(big.Int)))
 		xc = indexMap[fmt.Sprint(int(qOwOTerm.VariableIndex))]
 	}
 
@@ -65,11 +144,11 @@ func handleArithmeticOpcode(a *acir_opcode.ArithmeticOpcode, sparseR1CS constrai
 	if len(a.SimpleTerms) == 2 {
 		// qL⋅xa
 		qLwLTerm := a.SimpleTerms[0]
-		qL = sparseR1CS.FromInterface(qLwLTerm.Coefficient)
+		qL = sparseR1CS.FromInterface(qLwLTerm.Coefficient.BigInt(new(big.Int)))
 		xa = indexMap[fmt.Sprint(int(qLwLTerm.VariableIndex))]
 		// qR⋅xb
 		qRwRTerm := a.SimpleTerms[1]
-		qR = sparseR1CS.FromInterface(qRwRTerm.Coefficient)
+		qR = sparseR1CS.FromInterface(qRwRTerm.Coefficient.BigInt(new(big.Int)))
 		xb = indexMap[fmt.Sprint(int(qRwRTerm.VariableIndex))]
 	}
 
@@ -77,20 +156,20 @@ func handleArithmeticOpcode(a *acir_opcode.ArithmeticOpcode, sparseR1CS constrai
 	if len(a.SimpleTerms) == 3 {
 		// qL⋅xa
 		qLwLTerm := a.SimpleTerms[0]
-		qL = sparseR1CS.FromInterface(qLwLTerm.Coefficient)
+		qL = sparseR1CS.FromInterface(qLwLTerm.Coefficient.BigInt(new(big.Int)))
 		xa = indexMap[fmt.Sprint(int(qLwLTerm.VariableIndex))]
 		// qR⋅xb
 		qRwRTerm := a.SimpleTerms[1]
-		qR = sparseR1CS.FromInterface(qRwRTerm.Coefficient)
+		qR = sparseR1CS.FromInterface(qRwRTerm.Coefficient.BigInt(new(big.Int)))
 		xb = indexMap[fmt.Sprint(int(qRwRTerm.VariableIndex))]
 		// qO⋅xc
 		qOwOTerm := a.SimpleTerms[2]
-		qO = sparseR1CS.FromInterface(qOwOTerm.Coefficient)
+		qO = sparseR1CS.FromInterface(qOwOTerm.Coefficient.BigInt(new(big.Int)))
 		xc = indexMap[fmt.Sprint(int(qOwOTerm.VariableIndex))]
 	}
 
 	// Add the qC term
-	qC = sparseR1CS.FromInterface(a.QC)
+	qC = sparseR1CS.FromInterface(a.QC.BigInt(new(big.Int)))
 
 	K := sparseR1CS.MakeTerm(&qC, 0)
 	K.MarkConstant()
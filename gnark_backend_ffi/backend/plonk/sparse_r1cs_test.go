@@ -0,0 +1,51 @@
+package plonk_backend
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gnark_backend_ffi/acir"
+	acir_opcode "gnark_backend_ffi/acir/opcode"
+	common "gnark_backend_ffi/internal"
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	zero     = "0000000000000000000000000000000000000000000000000000000000000000"
+	one      = "0000000000000000000000000000000000000000000000000000000000000001"
+	minusOne = "30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000"
+)
+
+func TestBuildSparseR1CSSkipsUnconstrainedOpcodes(t *testing.T) {
+	circuit := acir.ACIR{
+		CurrentWitness: 2,
+		Opcodes: []acir_opcode.Opcode{
+			{Data: &acir_opcode.BrilligOpcode{Outputs: common.Witnesses{1}}},
+			{Data: &acir_opcode.OracleOpcode{Name: "get_number", Outputs: common.Witnesses{2}}},
+		},
+	}
+
+	sparseR1CS, _, secretVariables := BuildSparseR1CS(circuit, make(felt.Vector, 2))
+
+	assert.Equal(t, 0, sparseR1CS.GetNbConstraints())
+	assert.Len(t, secretVariables, 2)
+}
+
+func TestBuildTracedSparseR1CS(t *testing.T) {
+	var circuit acir.ACIR
+	err := json.Unmarshal([]byte(`{"current_witness_index":3,"opcodes":[
+		{"Brillig":{"inputs":[],"outputs":[{"Simple":3}],"foreign_call_results":[],"bytecode":[],"predicate":null}},
+		{"Arithmetic":{"mul_terms":[],"linear_combinations":[["`+one+`",1],["`+minusOne+`",2]],"q_c":"`+zero+`"}},
+		{"Arithmetic":{"mul_terms":[],"linear_combinations":[["`+one+`",2],["`+minusOne+`",3]],"q_c":"`+zero+`"}}
+	],"public_inputs":[],"assert_messages":[[{"Acir":2},"y != z"]]}`), &circuit)
+	assert.NoError(t, err)
+
+	sparseR1CS, _, _, origins := BuildTracedSparseR1CS(circuit, memoryValues(1, 1, 1))
+	assert.Equal(t, []int{1, 2}, origins)
+	assert.Equal(t, sparseR1CS.GetNbConstraints(), len(origins))
+
+	err = isSolved(circuit, memoryValues(1, 1, 2))
+	assert.ErrorContains(t, err, `opcode 2: w2 − w3 = 0, assert "y != z"`)
+}
@@ -0,0 +1,52 @@
+package plonk_backend
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"gnark_backend_ffi/backend"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/plonk"
+)
+
+// SRSSize is the number of G1 points plonk.Setup and plonk.Prove need for
+// the sparse R1CS: the size of its FFT domain, which also holds a
+// placeholder constraint per public variable, plus 3 for the blinding of
+// the polynomials.
+func SRSSize(sparseR1CS backend.SparseR1CS) int {
+	cardinality := ecc.NextPowerOfTwo(uint64(sparseR1CS.GetNbConstraints() + sparseR1CS.GetNbPublicVariables()))
+	return int(cardinality) + 3
+}
+
+// verifyingKeySize is the number of G1 points the verifying key needs, the
+// size of its domain. gnark doesn't expose it but it is the first thing the
+// key encodes.
+func verifyingKeySize(vk plonk.VerifyingKey) (int, error) {
+	w := &prefixWriter{limit: 8}
+	if _, err := vk.WriteTo(w); err != nil && err != errPrefixWritten {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint64(w.prefix)), nil
+}
+
+var errPrefixWritten = errors.New("prefix written")
+
+// prefixWriter keeps the first limit bytes written to it and fails once it
+// has them, so the rest isn't encoded.
+type prefixWriter struct {
+	prefix []byte
+	limit  int
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	n := w.limit - len(w.prefix)
+	if n > len(p) {
+		n = len(p)
+	}
+	w.prefix = append(w.prefix, p[:n]...)
+	if len(w.prefix) == w.limit {
+		return n, errPrefixWritten
+	}
+	return n, nil
+}
@@ -0,0 +1,45 @@
+package plonk_backend
+
+import (
+	"math/big"
+	"testing"
+
+	"gnark_backend_ffi/backend"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSRSSize(t *testing.T) {
+	sparseR1CS, publicVariables, secretVariables := BuildSparseR1CS(witnessCircuit(t), memoryValues(2, 5, 3))
+	size := SRSSize(sparseR1CS)
+	witness, err := backend.NewWitness(sparseR1CS.CurveID().ScalarField(), publicVariables, secretVariables)
+	assert.NoError(t, err)
+
+	srs, err := kzg.NewSRS(uint64(size), big.NewInt(42))
+	assert.NoError(t, err)
+	pk, vk, err := plonk.Setup(sparseR1CS, srs)
+	assert.NoError(t, err)
+	proof, err := plonk.Prove(sparseR1CS, pk, witness)
+	assert.NoError(t, err)
+	publicWitness, err := witness.Public()
+	assert.NoError(t, err)
+	assert.NoError(t, plonk.Verify(proof, vk, publicWitness))
+
+	// The verifier only needs the domain of the key.
+	vkSize, err := verifyingKeySize(vk)
+	assert.NoError(t, err)
+	assert.Equal(t, size-3, vkSize)
+	srs.G1 = srs.G1[:vkSize]
+	assert.NoError(t, vk.InitKZG(srs))
+	assert.NoError(t, plonk.Verify(proof, vk, publicWitness))
+
+	// One point less is enough for the setup but not to prove.
+	srs, err = kzg.NewSRS(uint64(size-1), big.NewInt(42))
+	assert.NoError(t, err)
+	pk, _, err = plonk.Setup(sparseR1CS, srs)
+	assert.NoError(t, err)
+	_, err = plonk.Prove(sparseR1CS, pk, witness)
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package plonk_backend
+
+import (
+	"fmt"
+
+	"gnark_backend_ffi/acir"
+	"gnark_backend_ffi/backend"
+	"gnark_backend_ffi/internal/felt"
+)
+
+// witnessVariables adds the public and secret variables of the circuit to an
+// empty sparse R1CS, which is all a witness depends on. The circuit must be
+// valid, a witness of an invalid one couldn't be proven.
+func witnessVariables(circuit acir.ACIR, values felt.Vector) (sparseR1CS backend.SparseR1CS, publicVariables felt.Vector, secretVariables felt.Vector, indexMap map[string]int, err error) {
+	if err = acir.Validate(circuit); err != nil {
+		return
+	}
+	if sparseR1CS, err = backend.NewSparseR1CS(circuit.CurveID(), 0); err != nil {
+		return
+	}
+	publicVariables, secretVariables, indexMap = backend.HandleValues(circuit, sparseR1CS, values)
+	return
+}
+
+// ExportWitness encodes the full and the public witness that ProveWithPK and
+// Verify build for the values.
+func ExportWitness(circuit acir.ACIR, values felt.Vector, format backend.WitnessFormat) (fullWitness []byte, publicWitness []byte, err error) {
+	sparseR1CS, publicVariables, secretVariables, _, err := witnessVariables(circuit, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := backend.WitnessSchema(backend.System(sparseR1CS))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	witness, err := backend.NewWitness(sparseR1CS.CurveID().ScalarField(), publicVariables, secretVariables)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fullWitness, err = backend.EncodeWitness(witness, s, format); err != nil {
+		return nil, nil, err
+	}
+	witnessPublics, err := witness.Public()
+	if err != nil {
+		return nil, nil, err
+	}
+	if publicWitness, err = backend.EncodeWitness(witnessPublics, s, format); err != nil {
+		return nil, nil, err
+	}
+	return fullWitness, publicWitness, nil
+}
+
+// ImportWitness decodes a witness exported by ExportWitness. A full witness
+// gives back the values of w1 to the current witness, as ProveWithPK takes
+// them, and a public witness the values of the public inputs, as
+// Verify takes them.
+func ImportWitness(circuit acir.ACIR, data []byte, format backend.WitnessFormat) (values felt.Vector, err error) {
+	sparseR1CS, _, _, indexMap, err := witnessVariables(circuit, make(felt.Vector, circuit.CurrentWitness))
+	if err != nil {
+		return nil, err
+	}
+	s, err := backend.WitnessSchema(backend.System(sparseR1CS))
+	if err != nil {
+		return nil, err
+	}
+
+	witness, err := backend.DecodeWitness(data, s, format, sparseR1CS.CurveID().ScalarField())
+	if err != nil {
+		return nil, err
+	}
+	variables, err := backend.WitnessValues(witness)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(variables) {
+	case s.NbPublic:
+		return variables, nil
+	case s.NbPublic + s.NbSecret:
+		values = make(felt.Vector, circuit.CurrentWitness)
+		for i := range values {
+			values[i] = variables[indexMap[fmt.Sprint(i+1)]]
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("witness has %d variables, the circuit has %d public and %d secret", len(variables), s.NbPublic, s.NbSecret)
+	}
+}
@@ -0,0 +1,88 @@
+package plonk_backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"gnark_backend_ffi/acir"
+	"gnark_backend_ffi/backend"
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/assert"
+)
+
+func witnessCircuit(t *testing.T) acir.ACIR {
+	return curveWitnessCircuit(t, ecc.BN254)
+}
+
+// curveWitnessCircuit is witnessCircuit with the coefficients of the scalar
+// field of the curve.
+func curveWitnessCircuit(t *testing.T, curveID ecc.ID) acir.ACIR {
+	// w1 − w2 + w3 = 0 with w2 public.
+	minusOne := fmt.Sprintf("%0*x", 2*backend.ScalarFieldBytes(curveID), new(big.Int).Sub(curveID.ScalarField(), big.NewInt(1)))
+	var circuit acir.ACIR
+	err := json.Unmarshal([]byte(`{"current_witness_index":3,"opcodes":[
+		{"Arithmetic":{"mul_terms":[],"linear_combinations":[["`+one+`",1],["`+minusOne+`",2],["`+one+`",3]],"q_c":"`+zero+`"}}
+	],"public_inputs":[2]}`), &circuit)
+	assert.NoError(t, err)
+	circuit.Curve = curveID
+	return circuit
+}
+
+func TestExportAndImportWitness(t *testing.T) {
+	circuit := witnessCircuit(t)
+	values := memoryValues(2, 5, 3)
+
+	for _, format := range []backend.WitnessFormat{backend.BinaryWitness, backend.JSONWitness} {
+		fullWitness, publicWitness, err := ExportWitness(circuit, values, format)
+		assert.NoError(t, err)
+
+		importedValues, err := ImportWitness(circuit, fullWitness, format)
+		assert.NoError(t, err)
+		assert.Equal(t, values, importedValues)
+
+		publicInputs, err := ImportWitness(circuit, publicWitness, format)
+		assert.NoError(t, err)
+		assert.Equal(t, felt.Vector{felt.NewElement(5)}, publicInputs)
+	}
+}
+
+func TestExportWitnessJSON(t *testing.T) {
+	fullWitness, publicWitness, err := ExportWitness(witnessCircuit(t), memoryValues(2, 5, 3), backend.JSONWitness)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"public_2":5,"secret_1":2,"secret_3":3}`, string(fullWitness))
+	assert.JSONEq(t, `{"public_2":5}`, string(publicWitness))
+
+	circuit := witnessCircuit(t)
+	circuit.PublicInputs = []uint32{3, 1}
+	fullWitness, publicWitness, err = ExportWitness(circuit, memoryValues(2, 5, 3), backend.JSONWitness)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"public_1":2,"public_3":3,"secret_2":5}`, string(fullWitness))
+	assert.JSONEq(t, `{"public_1":2,"public_3":3}`, string(publicWitness))
+}
+
+func TestImportWitnessOfAnotherCircuit(t *testing.T) {
+	fullWitness, _, err := ExportWitness(witnessCircuit(t), memoryValues(2, 5, 3), backend.BinaryWitness)
+	assert.NoError(t, err)
+
+	circuit := witnessCircuit(t)
+	circuit.CurrentWitness = 4
+	_, err = ImportWitness(circuit, fullWitness, backend.BinaryWitness)
+	assert.Error(t, err)
+}
+
+func TestExportAndImportWitnessOfInvalidCircuit(t *testing.T) {
+	fullWitness, _, err := ExportWitness(witnessCircuit(t), memoryValues(2, 5, 3), backend.BinaryWitness)
+	assert.NoError(t, err)
+
+	circuit := witnessCircuit(t)
+	circuit.PublicInputs = []uint32{2, 2}
+	var validationErrors acir.ValidationErrors
+	_, _, err = ExportWitness(circuit, memoryValues(2, 5, 3), backend.BinaryWitness)
+	assert.ErrorAs(t, err, &validationErrors)
+	_, err = ImportWitness(circuit, fullWitness, backend.BinaryWitness)
+	assert.ErrorAs(t, err, &validationErrors)
+}
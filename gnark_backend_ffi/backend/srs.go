@@ -0,0 +1,483 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	kzgg "github.com/consensys/gnark-crypto/kzg"
+)
+
+// SRSMode tells where the SRS comes from.
+type SRSMode string
+
+const (
+	// TrustedSRS only uses an SRS imported from a ceremony.
+	TrustedSRS SRSMode = "trusted"
+	// DevSRS generates an SRS when there is none. Whoever generates it knows
+	// its secret and can forge proofs, so the keys set up with it are insecure.
+	DevSRS SRSMode = "dev"
+)
+
+// SRSModeEnv is the environment variable that sets the SRS mode.
+const SRSModeEnv = "GNARK_BACKEND_SRS_MODE"
+
+// srsMode is the mode set through SetSRSMode, it takes precedence over
+// SRSModeEnv.
+var srsMode SRSMode
+
+func ParseSRSMode(mode string) (SRSMode, error) {
+	switch SRSMode(mode) {
+	case TrustedSRS, DevSRS:
+		return SRSMode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown SRS mode %q, expected %q or %q", mode, TrustedSRS, DevSRS)
+	}
+}
+
+func SetSRSMode(mode SRSMode) {
+	srsMode = mode
+}
+
+// CurrentSRSMode is the mode set with SetSRSMode, else the one in
+// SRSModeEnv, else TrustedSRS.
+func CurrentSRSMode() (SRSMode, error) {
+	if srsMode != "" {
+		return srsMode, nil
+	}
+	if mode := os.Getenv(SRSModeEnv); mode != "" {
+		return ParseSRSMode(mode)
+	}
+	return TrustedSRS, nil
+}
+
+// SRSPathEnv is the environment variable that overrides where the SRS is
+// stored.
+const SRSPathEnv = "GNARK_BACKEND_SRS_PATH"
+
+// srsPath is the path set through SetSRSPath, it takes precedence over
+// SRSPathEnv.
+var srsPath string
+
+func SetSRSPath(path string) {
+	srsPath = path
+}
+
+// SRSPath is where the SRS of the curve is loaded from and saved to: the
+// path set with SetSRSPath, else the one in SRSPathEnv, else srs.bin in the
+// noir-lang directory of the user config dir. The SRSs of the other curves
+// than BN254 are next to it, the curve added to the file name, like
+// srs.bls12_381.bin.
+func SRSPath(curveID ecc.ID) (string, error) {
+	path := srsPath
+	if path == "" {
+		path = os.Getenv(SRSPathEnv)
+	}
+	if path == "" {
+		userConfigDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(userConfigDir, "noir-lang", "srs.bin")
+	}
+	if curveID == ecc.BN254 {
+		return path, nil
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + curveID.String() + ext, nil
+}
+
+// DevSRSPath is where DevSRS mode keeps the SRS it generates, next to
+// SRSPath so a generated SRS is never taken for an imported one.
+func DevSRSPath(curveID ecc.ID) (string, error) {
+	path, err := SRSPath(curveID)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".dev.bin", nil
+}
+
+// legacySRSPath is where the BN254 SRS was saved, hex encoded, before its
+// path could be configured. It was generated from a random alpha so it is
+// only loaded in DevSRS mode.
+func legacySRSPath() (string, error) {
+	userConfigDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userConfigDir, "noir-lang", "srs.hex"), nil
+}
+
+// ErrSRSTooSmall is returned when an SRS has less points than needed.
+var ErrSRSTooSmall = errors.New("SRS is too small")
+
+// ErrCorruptedSRS is returned when an SRS doesn't match its manifest or its
+// points aren't consecutive powers of the same τ.
+var ErrCorruptedSRS = errors.New("SRS is corrupted")
+
+// LoadSRS reads the first size points of the SRS of the curve at SRSPath.
+func LoadSRS(curveID ecc.ID, size int) (srs kzgg.SRS, err error) {
+	path, err := SRSPath(curveID)
+	if err != nil {
+		return
+	}
+	return ReadSRSFile(path, curveID, size)
+}
+
+// ReadSRSFile reads the first size G1 points of an SRS of the curve, or all
+// of them if size is 0, in gnark-crypto's binary format or, as older versions saved
+// it, hex encoded. The rest of the file is not read. The points read are
+// checked against the manifest of the file, if it has one, and with CheckSRS
+// unless the manifest records that they already passed it.
+func ReadSRSFile(path string, curveID ecc.ID, size int) (srs kzgg.SRS, err error) {
+	// Without an SRS there is nothing to lock.
+	if _, err = os.Stat(path); err != nil {
+		return
+	}
+	lock, err := lockSRSFile(path)
+	if err != nil {
+		return
+	}
+	if lock != nil {
+		defer lock.Close()
+	}
+	return readLockedSRSFile(path, curveID, size)
+}
+
+// lockSRSFile takes the lock of the SRS at path, which is held while the SRS
+// or its manifest are read or written so that they always match. The lock
+// is nil when the directory of the SRS is read-only: nobody writes them then.
+func lockSRSFile(path string) (*os.File, error) {
+	lock, err := lockFile(path + ".lock")
+	if errors.Is(err, fs.ErrPermission) {
+		return nil, nil
+	}
+	return lock, err
+}
+
+// readLockedSRSFile is ReadSRSFile for callers holding the lock of the SRS.
+func readLockedSRSFile(path string, curveID ecc.ID, size int) (srs kzgg.SRS, err error) {
+	curve, err := getSRSCurve(curveID)
+	if err != nil {
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	var serializedSRS io.Reader = r
+	header, _ := r.Peek(8)
+	hexEncoded := isHexEncoded(header)
+	if hexEncoded {
+		serializedSRS = hex.NewDecoder(r)
+	}
+	manifest, err := readSRSManifest(path, curve)
+	if err != nil {
+		return
+	}
+	if manifest == nil && !hexEncoded {
+		log.Printf("Warning: SRS at %s has no checksum manifest, only the consistency of its points is checked.", path)
+	}
+
+	srs, err = readSRS(serializedSRS, curve, size, manifest)
+	if errors.Is(err, ErrCorruptedSRS) {
+		err = fmt.Errorf("SRS at %s: %w", path, err)
+	}
+	if err == nil && manifest != nil {
+		if size == 0 {
+			size = manifest.NbPoints
+		}
+		// The points are checked once, the next loads only check the
+		// checksums.
+		if err := manifest.recordCheckedPoints(path, size); err != nil {
+			log.Printf("Warning: can't record in the manifest of the SRS at %s that it was checked: %v.", path, err)
+		}
+	}
+	return
+}
+
+// readSRS decodes the prefix of an SRS encoded by kzg.SRS.WriteTo, which is
+// [G₂, [α]G₂, uint32(len(G1)), G1...]: the length of the G1 points is
+// replaced by size so the decoder stops there.
+func readSRS(r io.Reader, curve *srsCurve, size int, manifest *srsManifest) (kzgg.SRS, error) {
+	header := make([]byte, curve.headerSize())
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptedSRS, err)
+	}
+	nbPoints := int(binary.BigEndian.Uint32(header[len(header)-4:]))
+	if size == 0 {
+		size = nbPoints
+	}
+	if nbPoints < size {
+		return nil, fmt.Errorf("%w, it has %d points but %d are needed", ErrSRSTooSmall, nbPoints, size)
+	}
+
+	// Whole chunks are read to check them against the manifest.
+	g1Size := size * curve.g1PointSize
+	if manifest != nil {
+		if manifest.NbPoints != nbPoints {
+			return nil, fmt.Errorf("%w: it has %d points but its manifest %d", ErrCorruptedSRS, nbPoints, manifest.NbPoints)
+		}
+		g1Size = manifest.chunksSize(size)
+	}
+	g1 := make([]byte, g1Size)
+	if _, err := io.ReadFull(r, g1); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptedSRS, err)
+	}
+	if manifest != nil {
+		if err := manifest.check(header, g1); err != nil {
+			return nil, err
+		}
+	}
+
+	srs := curve.newSRS()
+	binary.BigEndian.PutUint32(header[len(header)-4:], uint32(size))
+	srsReader := io.MultiReader(bytes.NewReader(header), bytes.NewReader(g1[:size*curve.g1PointSize]))
+	if _, err := srs.ReadFrom(srsReader); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptedSRS, err)
+	}
+	if manifest == nil || manifest.checkedPoints() < size {
+		if err := curve.checkSRS(srs); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptedSRS, err)
+		}
+	}
+	return srs, nil
+}
+
+// isHexEncoded tells hex encoded SRSs apart from binary ones, which start
+// with a compressed G2 point whose first byte has its most significant bit
+// set and so isn't a hex digit.
+func isHexEncoded(serializedSRS []byte) bool {
+	if len(serializedSRS) < 8 {
+		return false
+	}
+	for _, b := range serializedSRS[:8] {
+		if !('0' <= b && b <= '9' || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// SaveSRS writes the SRS to the SRSPath of its curve in gnark-crypto's
+// binary format, creating the directories it needs.
+func SaveSRS(srs kzgg.SRS) (err error) {
+	// We need to save the SRS because the struct VerifyingKey has a pointer
+	// to a SRS struct but we can't rely on a pointer because memory is volatile.
+	// When we deserialize the VerifyingKey we will deserialize the SRS and insert
+	// a valid pointer.
+	curveID, err := srsCurveID(srs)
+	if err != nil {
+		return err
+	}
+	path, err := SRSPath(curveID)
+	if err != nil {
+		return err
+	}
+	return WriteSRSFile(path, srs)
+}
+
+// WriteSRSFile writes the SRS and its checksum manifest, each to a
+// temporary file that is then renamed, so readers never see a partially
+// written SRS. Both are written holding the lock of the SRS, so readers
+// never see the SRS with the manifest of another one either.
+func WriteSRSFile(path string, srs kzgg.SRS) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	lock, err := lockSRSFile(path)
+	if err != nil {
+		return err
+	}
+	if lock != nil {
+		defer lock.Close()
+	}
+	return writeLockedSRSFile(path, srs)
+}
+
+// writeLockedSRSFile is WriteSRSFile for callers holding the lock of the SRS.
+func writeLockedSRSFile(path string, srs kzgg.SRS) error {
+	curveID, err := srsCurveID(srs)
+	if err != nil {
+		return err
+	}
+	curve, err := getSRSCurve(curveID)
+	if err != nil {
+		return err
+	}
+	var serializedSRS bytes.Buffer
+	if _, err := srs.WriteTo(&serializedSRS); err != nil {
+		return err
+	}
+	serializedManifest, err := json.MarshalIndent(newSRSManifest(serializedSRS.Bytes(), curve), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomically(path, serializedSRS.Bytes()); err != nil {
+		return err
+	}
+	return writeFileAtomically(SRSManifestPath(path), serializedManifest)
+}
+
+func writeFileAtomically(path string, data []byte) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+
+	if _, err = file.Write(data); err != nil {
+		return
+	}
+	if err = file.Chmod(0644); err != nil {
+		return
+	}
+	if err = file.Sync(); err != nil {
+		return
+	}
+	if err = file.Close(); err != nil {
+		return
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// loadedSRSs caches the SRSs TryLoadSRS loaded or generated, keyed by mode
+// and SRS path, so every export of the process shares the same SRS.
+var (
+	loadedSRSs     = make(map[string]kzgg.SRS)
+	loadedSRSsLock sync.Mutex
+)
+
+// devSRSMinSize is the least number of points DevSRS generates, so that an
+// SRS generated for a small circuit serves the larger ones too: each SRS is
+// generated from its own random secret, a larger one replaces it and breaks
+// the keys set up with it.
+var devSRSMinSize = 1 << 16
+
+// TryLoadSRS returns the first size points of the SRS of the curve in the
+// current SRS mode. In TrustedSRS mode it fails if no SRS of that size was imported at
+// SRSPath. In DevSRS mode it loads the SRS at DevSRSPath or, if there is
+// none, the legacy srs.hex, and if neither is large enough it generates one
+// of at least devSRSMinSize points from a random alpha, which is then
+// discarded, and saves it at DevSRSPath. Processes running at the same
+// time wait for each other through a lock file so only one of them
+// generates it.
+func TryLoadSRS(curveID ecc.ID, size int) (srs kzgg.SRS, mode SRSMode, err error) {
+	if mode, err = CurrentSRSMode(); err != nil {
+		return
+	}
+	curve, err := getSRSCurve(curveID)
+	if err != nil {
+		return
+	}
+	path, err := SRSPath(curveID)
+	if err != nil {
+		return
+	}
+
+	loadedSRSsLock.Lock()
+	defer loadedSRSsLock.Unlock()
+	cacheKey := string(mode) + ":" + path
+	if cachedSRS, ok := loadedSRSs[cacheKey]; ok {
+		if srs, ok := curve.prefix(cachedSRS, size); ok {
+			return srs, mode, nil
+		}
+	}
+
+	if mode == TrustedSRS {
+		srs, err = ReadSRSFile(path, curveID, size)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			err = fmt.Errorf("no trusted SRS at %s, import one from a ceremony or set %s=%s to generate an insecure one", path, SRSModeEnv, DevSRS)
+		case errors.Is(err, ErrSRSTooSmall):
+			err = fmt.Errorf("trusted SRS at %s: %w, import a larger one", path, err)
+		case err == nil:
+			loadedSRSs[cacheKey] = srs
+		}
+		return srs, mode, err
+	}
+
+	if srs, err = loadDevSRS(curveID, curve, size); err != nil {
+		return
+	}
+	loadedSRSs[cacheKey] = srs
+	srs, _ = curve.prefix(srs, size)
+	return
+}
+
+// loadDevSRS returns an SRS of at least size points: the one at DevSRSPath,
+// else the legacy srs.hex if there is no SRS at DevSRSPath, else a new one
+// saved at DevSRSPath.
+func loadDevSRS(curveID ecc.ID, curve *srsCurve, size int) (srs kzgg.SRS, err error) {
+	devSRSPath, err := DevSRSPath(curveID)
+	if err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Dir(devSRSPath), 0755); err != nil {
+		return
+	}
+	lock, err := lockFile(devSRSPath + ".lock")
+	if err != nil {
+		return
+	}
+	defer lock.Close()
+
+	path := devSRSPath
+	srs, err = readLockedSRSFile(path, curveID, size)
+	if errors.Is(err, fs.ErrNotExist) && curveID == ecc.BN254 {
+		if path, err = legacySRSPath(); err != nil {
+			return
+		}
+		srs, err = ReadSRSFile(path, curveID, size)
+	}
+	switch {
+	case err == nil:
+		return
+	case errors.Is(err, ErrCorruptedSRS):
+		log.Printf("Warning: %v.", err)
+	case errors.Is(err, ErrSRSTooSmall):
+		log.Printf("Warning: dev SRS at %s: %v, it is replaced by a larger one and the keys set up with it must be set up again.", path, err)
+	case !errors.Is(err, fs.ErrNotExist):
+		return
+	}
+
+	// SRS wasn't generated, is too small or is corrupted so we generate it.
+	if size < devSRSMinSize {
+		size = devSRSMinSize
+	}
+	log.Printf("Warning: generating an insecure SRS of %d points in %s, keys set up with it must not be used in production.", size, devSRSPath)
+	alpha, err := rand.Int(rand.Reader, curveID.ScalarField())
+	if err != nil {
+		return
+	}
+	srs, err = curve.generateSRS(uint64(size), alpha)
+	// The SRS is never generated again from the same alpha.
+	alpha.SetInt64(0)
+	if err != nil {
+		return
+	}
+	err = writeLockedSRSFile(devSRSPath, srs)
+	return
+}
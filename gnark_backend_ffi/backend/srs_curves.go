@@ -0,0 +1,205 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377"
+	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	kzg_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/kzg"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381"
+	fr_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	kzg_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/kzg"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	"github.com/consensys/gnark-crypto/ecc/bw6-761"
+	fr_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+	kzg_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/kzg"
+	kzgg "github.com/consensys/gnark-crypto/kzg"
+)
+
+// srsCurve is what reading, generating and checking the SRS of a curve
+// depends on.
+type srsCurve struct {
+	g1PointSize int
+	g2PointSize int
+	// newSRS returns an empty SRS to decode into.
+	newSRS      func() kzgg.SRS
+	generateSRS func(size uint64, alpha *big.Int) (kzgg.SRS, error)
+	checkSRS    func(srs kzgg.SRS) error
+	// prefix returns the first size points of the SRS, or false if it has
+	// less.
+	prefix func(srs kzgg.SRS, size int) (kzgg.SRS, bool)
+}
+
+var srsCurves = map[ecc.ID]*srsCurve{
+	ecc.BN254: newSRSCurve[fr.Element](
+		bn254.SizeOfG1AffineCompressed, bn254.SizeOfG2AffineCompressed, kzg_bn254.NewSRS,
+		func(srs *kzg_bn254.SRS) (*[]bn254.G1Affine, *[2]bn254.G2Affine) { return &srs.G1, &srs.G2 },
+		func() (bn254.G1Affine, bn254.G2Affine) { _, _, g1, g2 := bn254.Generators(); return g1, g2 },
+		bn254.PairingCheck,
+	),
+	ecc.BLS12_381: newSRSCurve[fr_bls12381.Element](
+		bls12381.SizeOfG1AffineCompressed, bls12381.SizeOfG2AffineCompressed, kzg_bls12381.NewSRS,
+		func(srs *kzg_bls12381.SRS) (*[]bls12381.G1Affine, *[2]bls12381.G2Affine) { return &srs.G1, &srs.G2 },
+		func() (bls12381.G1Affine, bls12381.G2Affine) { _, _, g1, g2 := bls12381.Generators(); return g1, g2 },
+		bls12381.PairingCheck,
+	),
+	ecc.BLS12_377: newSRSCurve[fr_bls12377.Element](
+		bls12377.SizeOfG1AffineCompressed, bls12377.SizeOfG2AffineCompressed, kzg_bls12377.NewSRS,
+		func(srs *kzg_bls12377.SRS) (*[]bls12377.G1Affine, *[2]bls12377.G2Affine) { return &srs.G1, &srs.G2 },
+		func() (bls12377.G1Affine, bls12377.G2Affine) { _, _, g1, g2 := bls12377.Generators(); return g1, g2 },
+		bls12377.PairingCheck,
+	),
+	ecc.BW6_761: newSRSCurve[fr_bw6761.Element](
+		bw6761.SizeOfG1AffineCompressed, bw6761.SizeOfG2AffineCompressed, kzg_bw6761.NewSRS,
+		func(srs *kzg_bw6761.SRS) (*[]bw6761.G1Affine, *[2]bw6761.G2Affine) { return &srs.G1, &srs.G2 },
+		func() (bw6761.G1Affine, bw6761.G2Affine) { _, _, g1, g2 := bw6761.Generators(); return g1, g2 },
+		bw6761.PairingCheck,
+	),
+}
+
+// srsG1 is what checkSRSPowers needs of the G1 points of a curve, whose
+// scalars are Fr.
+type srsG1[G1, Fr any] interface {
+	*G1
+	Equal(a *G1) bool
+	IsOnCurve() bool
+	IsInSubGroup() bool
+	IsInfinity() bool
+	Neg(a *G1) *G1
+	MultiExp(points []G1, scalars []Fr, config ecc.MultiExpConfig) (*G1, error)
+}
+
+// srsG2 is what checkSRSPowers needs of the G2 points of a curve.
+type srsG2[G2 any] interface {
+	*G2
+	Equal(a *G2) bool
+	IsOnCurve() bool
+	IsInSubGroup() bool
+	IsInfinity() bool
+}
+
+// srsScalar is what checkSRSPowers needs of the scalars of a curve.
+type srsScalar[Fr any] interface {
+	*Fr
+	SetRandom() (*Fr, error)
+}
+
+// newSRSCurve describes the SRS of a curve from its KZG package: points
+// returns the G1 and G2 points of an SRS.
+func newSRSCurve[Fr, SRS, G1, G2 any, PSRS interface {
+	*SRS
+	kzgg.SRS
+}, PG1 srsG1[G1, Fr], PG2 srsG2[G2], PFr srsScalar[Fr]](
+	g1PointSize, g2PointSize int,
+	generateSRS func(size uint64, alpha *big.Int) (*SRS, error),
+	points func(srs *SRS) (*[]G1, *[2]G2),
+	generators func() (G1, G2),
+	pairingCheck func(P []G1, Q []G2) (bool, error),
+) *srsCurve {
+	return &srsCurve{
+		g1PointSize: g1PointSize,
+		g2PointSize: g2PointSize,
+		newSRS:      func() kzgg.SRS { return PSRS(new(SRS)) },
+		generateSRS: func(size uint64, alpha *big.Int) (kzgg.SRS, error) {
+			srs, err := generateSRS(size, alpha)
+			if err != nil {
+				return nil, err
+			}
+			return PSRS(srs), nil
+		},
+		checkSRS: func(srs kzgg.SRS) error {
+			g1, g2 := points(srs.(PSRS))
+			generatorG1, generatorG2 := generators()
+			return checkSRSPowers[Fr, G1, G2, PG1, PG2, PFr](*g1, *g2, generatorG1, generatorG2, pairingCheck)
+		},
+		prefix: func(srs kzgg.SRS, size int) (kzgg.SRS, bool) {
+			g1, g2 := points(srs.(PSRS))
+			if len(*g1) < size {
+				return nil, false
+			}
+			prefix := new(SRS)
+			prefixG1, prefixG2 := points(prefix)
+			*prefixG1, *prefixG2 = (*g1)[:size], *g2
+			return PSRS(prefix), true
+		},
+	}
+}
+
+func getSRSCurve(curveID ecc.ID) (*srsCurve, error) {
+	curve, ok := srsCurves[curveID]
+	if !ok {
+		return nil, fmt.Errorf("unsupported curve %s", curveID)
+	}
+	return curve, nil
+}
+
+// srsCurveID returns the curve of an SRS.
+func srsCurveID(srs kzgg.SRS) (ecc.ID, error) {
+	switch srs.(type) {
+	case *kzg_bn254.SRS:
+		return ecc.BN254, nil
+	case *kzg_bls12381.SRS:
+		return ecc.BLS12_381, nil
+	case *kzg_bls12377.SRS:
+		return ecc.BLS12_377, nil
+	case *kzg_bw6761.SRS:
+		return ecc.BW6_761, nil
+	default:
+		return ecc.UNKNOWN, fmt.Errorf("unsupported SRS %T", srs)
+	}
+}
+
+// headerSize is the size of the G2 points and the number of G1 points that
+// start an SRS encoded by WriteTo.
+func (c *srsCurve) headerSize() int {
+	return 2*c.g2PointSize + 4
+}
+
+// checkSRSPowers checks that the points of an SRS are consecutive powers of
+// the same τ: G1 = [G₁, [τ]G₁, [τ²]G₁, ...] and G2 = [G₂, [τ]G₂]. Instead of
+// a pairing per power it checks a random linear combination of them,
+// e(∑ rᵢ⋅[τⁱ⁺¹]G₁, G₂) = e(∑ rᵢ⋅[τⁱ]G₁, [τ]G₂).
+func checkSRSPowers[Fr, G1, G2 any, PG1 srsG1[G1, Fr], PG2 srsG2[G2], PFr srsScalar[Fr]](g1 []G1, g2 [2]G2, generatorG1 G1, generatorG2 G2, pairingCheck func(P []G1, Q []G2) (bool, error)) error {
+	if len(g1) < 2 {
+		return errors.New("SRS has less than 2 G1 points")
+	}
+	if !PG1(&g1[0]).Equal(&generatorG1) || !PG2(&g2[0]).Equal(&generatorG2) {
+		return errors.New("SRS doesn't start with the generators")
+	}
+	for i := range g1 {
+		if point := PG1(&g1[i]); !point.IsOnCurve() || !point.IsInSubGroup() || point.IsInfinity() {
+			return fmt.Errorf("SRS G1 point %d is not a valid point", i)
+		}
+	}
+	if point := PG2(&g2[1]); !point.IsOnCurve() || !point.IsInSubGroup() || point.IsInfinity() {
+		return errors.New("SRS G2 point 1 is not a valid point")
+	}
+
+	randomScalars := make([]Fr, len(g1)-1)
+	for i := range randomScalars {
+		if _, err := PFr(&randomScalars[i]).SetRandom(); err != nil {
+			return err
+		}
+	}
+	var higherPowers, lowerPowers G1
+	if _, err := PG1(&higherPowers).MultiExp(g1[1:], randomScalars, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if _, err := PG1(&lowerPowers).MultiExp(g1[:len(g1)-1], randomScalars, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	PG1(&lowerPowers).Neg(&lowerPowers)
+	consistent, err := pairingCheck([]G1{higherPowers, lowerPowers}, []G2{g2[0], g2[1]})
+	if err != nil {
+		return err
+	}
+	if !consistent {
+		return errors.New("SRS points are not consecutive powers of the same τ")
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// srsManifestChunkPoints is the number of G1 points each checksum of a
+// manifest covers, so loading a prefix of the SRS only hashes the chunks it
+// reads.
+const srsManifestChunkPoints = 1 << 16
+
+// srsManifest holds the checksums of an SRS file. It is stored next to the
+// file, in SRSManifestPath.
+type srsManifest struct {
+	NbPoints    int `json:"nb_points"`
+	ChunkPoints int `json:"chunk_points"`
+	// Header is the SHA-256 of the G2 points and the number of G1 points.
+	Header string `json:"header"`
+	// Chunks are the SHA-256 of each ChunkPoints G1 points.
+	Chunks []string `json:"chunks"`
+	// CheckedPoints is the number of G1 points, from the first one, that
+	// passed CheckSRS. Loading at most as many only checks the checksums.
+	CheckedPoints int `json:"checked_points,omitempty"`
+	// CheckedDigest is the SHA-256 of the checksums of the G2 points and of
+	// the chunks of the CheckedPoints, which only count for these checksums.
+	CheckedDigest string `json:"checked_digest,omitempty"`
+
+	// g1PointSize is the size of the G1 points of the curve of the SRS.
+	g1PointSize int
+}
+
+// SRSManifestPath is where the checksum manifest of the SRS at path is.
+func SRSManifestPath(path string) string {
+	return path + ".manifest.json"
+}
+
+// newSRSManifest computes the manifest of an SRS of the curve serialized by
+// kzg.SRS.WriteTo.
+func newSRSManifest(serializedSRS []byte, curve *srsCurve) srsManifest {
+	header, g1 := serializedSRS[:curve.headerSize()], serializedSRS[curve.headerSize():]
+	manifest := srsManifest{
+		NbPoints:    len(g1) / curve.g1PointSize,
+		ChunkPoints: srsManifestChunkPoints,
+		Header:      checksum(header),
+		g1PointSize: curve.g1PointSize,
+	}
+	chunkSize := srsManifestChunkPoints * curve.g1PointSize
+	for start := 0; start < len(g1); start += chunkSize {
+		end := start + chunkSize
+		if end > len(g1) {
+			end = len(g1)
+		}
+		manifest.Chunks = append(manifest.Chunks, checksum(g1[start:end]))
+	}
+	return manifest
+}
+
+// readSRSManifest returns nil if the SRS has no manifest.
+func readSRSManifest(path string, curve *srsCurve) (*srsManifest, error) {
+	serializedManifest, err := os.ReadFile(SRSManifestPath(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	manifest := srsManifest{g1PointSize: curve.g1PointSize}
+	if err := json.Unmarshal(serializedManifest, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: invalid manifest %s: %v", ErrCorruptedSRS, SRSManifestPath(path), err)
+	}
+	if manifest.ChunkPoints <= 0 || len(manifest.Chunks) != (manifest.NbPoints+manifest.ChunkPoints-1)/manifest.ChunkPoints || manifest.CheckedPoints < 0 || manifest.CheckedPoints > manifest.NbPoints {
+		return nil, fmt.Errorf("%w: invalid manifest %s", ErrCorruptedSRS, SRSManifestPath(path))
+	}
+	return &manifest, nil
+}
+
+// chunksSize is the number of bytes of G1 points to read so that the first
+// size points can be checked: whole chunks, the last one possibly shorter.
+func (m *srsManifest) chunksSize(size int) int {
+	nbPoints := (size + m.ChunkPoints - 1) / m.ChunkPoints * m.ChunkPoints
+	if nbPoints > m.NbPoints {
+		nbPoints = m.NbPoints
+	}
+	return nbPoints * m.g1PointSize
+}
+
+// check fails if the header or the chunks of G1 points read don't match
+// their checksums.
+func (m *srsManifest) check(header, g1 []byte) error {
+	if checksum(header) != m.Header {
+		return fmt.Errorf("%w: checksum of the G2 points doesn't match the manifest", ErrCorruptedSRS)
+	}
+	chunkSize := m.ChunkPoints * m.g1PointSize
+	for i := 0; i*chunkSize < len(g1); i++ {
+		end := (i + 1) * chunkSize
+		if end > len(g1) {
+			end = len(g1)
+		}
+		if checksum(g1[i*chunkSize:end]) != m.Chunks[i] {
+			return fmt.Errorf("%w: checksum of G1 points %d to %d doesn't match the manifest", ErrCorruptedSRS, i*m.ChunkPoints, end/m.g1PointSize-1)
+		}
+	}
+	return nil
+}
+
+// checkedDigest is the CheckedDigest of the first checkedPoints G1 points.
+func (m *srsManifest) checkedDigest(checkedPoints int) string {
+	nbChunks := (checkedPoints + m.ChunkPoints - 1) / m.ChunkPoints
+	return checksum([]byte(m.Header + strings.Join(m.Chunks[:nbChunks], "")))
+}
+
+// checkedPoints is CheckedPoints if CheckedDigest matches the checksums of
+// the manifest, else 0: the points were checked for another SRS.
+func (m *srsManifest) checkedPoints() int {
+	if m.CheckedDigest != m.checkedDigest(m.CheckedPoints) {
+		return 0
+	}
+	return m.CheckedPoints
+}
+
+// recordCheckedPoints saves in the manifest of the SRS at path that its
+// first checkedPoints G1 points passed CheckSRS, unless it already says so.
+// The lock of the SRS must be held.
+func (m *srsManifest) recordCheckedPoints(path string, checkedPoints int) error {
+	if checkedPoints <= m.checkedPoints() {
+		return nil
+	}
+	m.CheckedPoints = checkedPoints
+	m.CheckedDigest = m.checkedDigest(checkedPoints)
+	serializedManifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(SRSManifestPath(path), serializedManifest)
+}
+
+func checksum(data []byte) string {
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
+}
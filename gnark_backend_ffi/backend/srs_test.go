@@ -0,0 +1,495 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	kzg_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/kzg"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	kzgg "github.com/consensys/gnark-crypto/kzg"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSRS(t *testing.T) *kzg.SRS {
+	srs, err := kzg.NewSRS(8, big.NewInt(42))
+	assert.NoError(t, err)
+	return srs
+}
+
+// withUserConfigDir points the user config dir and every SRS path setting to
+// a temporary directory.
+func withUserConfigDir(t *testing.T) string {
+	userConfigDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userConfigDir)
+	t.Setenv(SRSPathEnv, "")
+	t.Setenv(SRSModeEnv, "")
+	SetSRSPath("")
+	SetSRSMode("")
+	// Dev SRSs are generated with the size asked for, unless a test sets a
+	// minimum.
+	minSize := devSRSMinSize
+	devSRSMinSize = 0
+	t.Cleanup(func() {
+		SetSRSPath("")
+		SetSRSMode("")
+		devSRSMinSize = minSize
+		loadedSRSs = make(map[string]kzgg.SRS)
+	})
+	return userConfigDir
+}
+
+func TestSRSPath(t *testing.T) {
+	userConfigDir := withUserConfigDir(t)
+
+	path, err := SRSPath(ecc.BN254)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(userConfigDir, "noir-lang", "srs.bin"), path)
+
+	t.Setenv(SRSPathEnv, "/from/env/srs.bin")
+	path, err = SRSPath(ecc.BN254)
+	assert.NoError(t, err)
+	assert.Equal(t, "/from/env/srs.bin", path)
+
+	SetSRSPath("/from/ffi/srs.bin")
+	path, err = SRSPath(ecc.BN254)
+	assert.NoError(t, err)
+	assert.Equal(t, "/from/ffi/srs.bin", path)
+
+	// The SRSs of the other curves are next to it.
+	path, err = SRSPath(ecc.BLS12_381)
+	assert.NoError(t, err)
+	assert.Equal(t, "/from/ffi/srs.bls12_381.bin", path)
+	path, err = DevSRSPath(ecc.BLS12_381)
+	assert.NoError(t, err)
+	assert.Equal(t, "/from/ffi/srs.bls12_381.dev.bin", path)
+}
+
+func TestSaveAndLoadSRS(t *testing.T) {
+	userConfigDir := withUserConfigDir(t)
+	path := filepath.Join(userConfigDir, "missing", "directories", "srs.bin")
+	SetSRSPath(path)
+	srs := testSRS(t)
+
+	assert.NoError(t, SaveSRS(srs))
+	var serializedSRS bytes.Buffer
+	_, err := srs.WriteTo(&serializedSRS)
+	assert.NoError(t, err)
+	savedSRS, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, serializedSRS.Bytes(), savedSRS)
+
+	loadedSRS, err := LoadSRS(ecc.BN254, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, srs, loadedSRS)
+}
+
+func writeSRSFile(t *testing.T, path string, srs *kzg.SRS, hexEncoded bool) {
+	if !hexEncoded {
+		assert.NoError(t, WriteSRSFile(path, srs))
+		return
+	}
+	var serializedSRS bytes.Buffer
+	_, err := srs.WriteTo(&serializedSRS)
+	assert.NoError(t, err)
+	data := []byte(hex.EncodeToString(serializedSRS.Bytes()))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+func TestCurrentSRSMode(t *testing.T) {
+	withUserConfigDir(t)
+
+	mode, err := CurrentSRSMode()
+	assert.NoError(t, err)
+	assert.Equal(t, TrustedSRS, mode)
+
+	t.Setenv(SRSModeEnv, "dev")
+	mode, err = CurrentSRSMode()
+	assert.NoError(t, err)
+	assert.Equal(t, DevSRS, mode)
+
+	SetSRSMode(TrustedSRS)
+	mode, err = CurrentSRSMode()
+	assert.NoError(t, err)
+	assert.Equal(t, TrustedSRS, mode)
+
+	SetSRSMode("")
+	t.Setenv(SRSModeEnv, "insecure")
+	_, err = CurrentSRSMode()
+	assert.Error(t, err)
+}
+
+func TestTryLoadTrustedSRS(t *testing.T) {
+	userConfigDir := withUserConfigDir(t)
+	// Neither the legacy SRS nor a dev one are trusted.
+	writeSRSFile(t, filepath.Join(userConfigDir, "noir-lang", "srs.hex"), testSRS(t), true)
+	writeSRSFile(t, filepath.Join(userConfigDir, "noir-lang", "srs.dev.bin"), testSRS(t), false)
+
+	_, mode, err := TryLoadSRS(ecc.BN254, 8)
+	assert.Equal(t, TrustedSRS, mode)
+	assert.ErrorContains(t, err, "no trusted SRS at "+filepath.Join(userConfigDir, "noir-lang", "srs.bin"))
+
+	srs := testSRS(t)
+	writeSRSFile(t, filepath.Join(userConfigDir, "noir-lang", "srs.bin"), srs, false)
+	loadedSRS, mode, err := TryLoadSRS(ecc.BN254, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, TrustedSRS, mode)
+	assert.Equal(t, srs, loadedSRS)
+
+	// A trusted SRS is never regenerated.
+	_, _, err = TryLoadSRS(ecc.BN254, 9)
+	assert.ErrorIs(t, err, ErrSRSTooSmall)
+	assert.ErrorContains(t, err, "it has 8 points but 9 are needed")
+}
+
+func TestTryLoadDevSRS(t *testing.T) {
+	userConfigDir := withUserConfigDir(t)
+	SetSRSMode(DevSRS)
+	legacySRS := testSRS(t)
+	legacySRSPath := filepath.Join(userConfigDir, "noir-lang", "srs.hex")
+	writeSRSFile(t, legacySRSPath, legacySRS, true)
+
+	loadedSRS, mode, err := TryLoadSRS(ecc.BN254, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, DevSRS, mode)
+	assert.Equal(t, legacySRS, loadedSRS)
+
+	// The legacy SRS is too small, a new one of at least devSRSMinSize points
+	// is generated next to the SRS path and the legacy one is left as is.
+	devSRSMinSize = 32
+	loadedSRS, _, err = TryLoadSRS(ecc.BN254, 16)
+	assert.NoError(t, err)
+	assert.Len(t, loadedSRS.(*kzg.SRS).G1, 16)
+	assert.NotEqual(t, legacySRS.G2, loadedSRS.(*kzg.SRS).G2)
+	savedSRS, err := ReadSRSFile(filepath.Join(userConfigDir, "noir-lang", "srs.dev.bin"), ecc.BN254, 0)
+	assert.NoError(t, err)
+	assert.Len(t, savedSRS.(*kzg.SRS).G1, 32)
+	assert.Equal(t, loadedSRS.(*kzg.SRS).G1, savedSRS.(*kzg.SRS).G1[:16])
+	savedLegacySRS, err := ReadSRSFile(legacySRSPath, ecc.BN254, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, legacySRS, savedLegacySRS)
+
+	// Up to its size, it is served from the cache.
+	cachedSRS, _, err := TryLoadSRS(ecc.BN254, 32)
+	assert.NoError(t, err)
+	assert.Equal(t, savedSRS, cachedSRS)
+}
+
+func TestTryLoadDevSRSTooSmall(t *testing.T) {
+	userConfigDir := withUserConfigDir(t)
+	SetSRSMode(DevSRS)
+	// A legacy SRS large enough is ignored once there is a dev SRS, its
+	// secret is another one.
+	writeSRSFile(t, filepath.Join(userConfigDir, "noir-lang", "srs.hex"), testSRS(t), true)
+	devSRSPath := filepath.Join(userConfigDir, "noir-lang", "srs.dev.bin")
+	devSRS, err := kzg.NewSRS(4, big.NewInt(7))
+	assert.NoError(t, err)
+	writeSRSFile(t, devSRSPath, devSRS, false)
+
+	loadedSRS, _, err := TryLoadSRS(ecc.BN254, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, devSRS, loadedSRS)
+
+	// The dev SRS is generated again with the larger size.
+	loadedSRS, _, err = TryLoadSRS(ecc.BN254, 8)
+	assert.NoError(t, err)
+	assert.Len(t, loadedSRS.(*kzg.SRS).G1, 8)
+	assert.NotEqual(t, devSRS.G2, loadedSRS.(*kzg.SRS).G2)
+	assert.NotEqual(t, testSRS(t).G2, loadedSRS.(*kzg.SRS).G2)
+	savedSRS, err := ReadSRSFile(devSRSPath, ecc.BN254, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, loadedSRS, savedSRS)
+}
+
+func TestTryLoadBLS12381DevSRS(t *testing.T) {
+	userConfigDir := withUserConfigDir(t)
+	SetSRSMode(DevSRS)
+	// The legacy SRS is for BN254.
+	writeSRSFile(t, filepath.Join(userConfigDir, "noir-lang", "srs.hex"), testSRS(t), true)
+
+	srs, _, err := TryLoadSRS(ecc.BLS12_381, 8)
+	assert.NoError(t, err)
+	assert.Len(t, srs.(*kzg_bls12381.SRS).G1, 8)
+
+	devSRSPath := filepath.Join(userConfigDir, "noir-lang", "srs.bls12_381.dev.bin")
+	savedSRS, err := ReadSRSFile(devSRSPath, ecc.BLS12_381, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, srs, savedSRS)
+	_, err = ReadSRSFile(devSRSPath, ecc.BN254, 0)
+	assert.ErrorIs(t, err, ErrCorruptedSRS)
+
+	// Both curves are cached apart.
+	bn254SRS, _, err := TryLoadSRS(ecc.BN254, 8)
+	assert.NoError(t, err)
+	assert.IsType(t, &kzg.SRS{}, bn254SRS)
+	srs, _, err = TryLoadSRS(ecc.BLS12_381, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, savedSRS.(*kzg_bls12381.SRS).G1[:4], srs.(*kzg_bls12381.SRS).G1)
+}
+
+func TestTryLoad2ChainDevSRS(t *testing.T) {
+	userConfigDir := withUserConfigDir(t)
+	SetSRSMode(DevSRS)
+
+	for _, curveID := range []ecc.ID{ecc.BLS12_377, ecc.BW6_761} {
+		srs, _, err := TryLoadSRS(curveID, 8)
+		assert.NoError(t, err)
+		curve, err := getSRSCurve(curveID)
+		assert.NoError(t, err)
+		assert.IsType(t, curve.newSRS(), srs)
+
+		devSRSPath := filepath.Join(userConfigDir, "noir-lang", "srs."+curveID.String()+".dev.bin")
+		savedSRS, err := ReadSRSFile(devSRSPath, curveID, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, srs, savedSRS)
+		_, err = ReadSRSFile(devSRSPath, ecc.BLS12_381, 0)
+		assert.ErrorIs(t, err, ErrCorruptedSRS)
+	}
+}
+
+func TestTryLoadDevSRSConcurrently(t *testing.T) {
+	userConfigDir := withUserConfigDir(t)
+	SetSRSMode(DevSRS)
+
+	srss := make([]kzgg.SRS, 8)
+	var wg sync.WaitGroup
+	for i := range srss {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			srs, _, err := TryLoadSRS(ecc.BN254, 8)
+			assert.NoError(t, err)
+			srss[i] = srs
+		}(i)
+	}
+	wg.Wait()
+	for _, srs := range srss[1:] {
+		assert.Equal(t, srss[0], srs)
+	}
+
+	// Another process loads the SRS that was saved instead of generating one.
+	loadedSRSs = make(map[string]kzgg.SRS)
+	loadedSRS, _, err := TryLoadSRS(ecc.BN254, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, srss[0], loadedSRS)
+
+	// Smaller SRSs are served from the cache.
+	loadedSRS, _, err = TryLoadSRS(ecc.BN254, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, srss[0].(*kzg.SRS).G1[:4], loadedSRS.(*kzg.SRS).G1)
+
+	entries, err := os.ReadDir(filepath.Join(userConfigDir, "noir-lang"))
+	assert.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"srs.dev.bin", "srs.dev.bin.lock", "srs.dev.bin.manifest.json"}, names)
+}
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "srs.lock")
+	lock, err := lockFile(path)
+	assert.NoError(t, err)
+
+	locked := make(chan struct{})
+	go func() {
+		lock, err := lockFile(path)
+		assert.NoError(t, err)
+		close(locked)
+		lock.Close()
+	}()
+	select {
+	case <-locked:
+		t.Fatal("the lock was taken twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.NoError(t, lock.Close())
+	<-locked
+}
+
+func TestReadHexSRSFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "srs.hex")
+	srs := testSRS(t)
+	writeSRSFile(t, path, srs, true)
+
+	loadedSRS, err := ReadSRSFile(path, ecc.BN254, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, srs, loadedSRS)
+}
+
+func TestReadSRSFilePrefix(t *testing.T) {
+	for _, hexEncoded := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "srs")
+		srs := testSRS(t)
+		writeSRSFile(t, path, srs, hexEncoded)
+
+		loadedSRS, err := ReadSRSFile(path, ecc.BN254, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, &kzg.SRS{G1: srs.G1[:5], G2: srs.G2}, loadedSRS)
+
+		_, err = ReadSRSFile(path, ecc.BN254, 9)
+		assert.ErrorIs(t, err, ErrSRSTooSmall)
+	}
+}
+
+func TestReadTruncatedSRSFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "srs.bin")
+	var serializedSRS bytes.Buffer
+	_, err := testSRS(t).WriteTo(&serializedSRS)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, serializedSRS.Bytes()[:serializedSRS.Len()/2], 0644))
+
+	_, err = ReadSRSFile(path, ecc.BN254, 0)
+	assert.Error(t, err)
+}
+
+// corruptSRSFile flips a bit of the G1 point i of an SRS file.
+func corruptSRSFile(t *testing.T, path string, i int) {
+	serializedSRS, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	serializedSRS[srsCurves[ecc.BN254].headerSize()+i*bn254.SizeOfG1AffineCompressed+bn254.SizeOfG1AffineCompressed-1] ^= 1
+	assert.NoError(t, os.WriteFile(path, serializedSRS, 0644))
+}
+
+func TestReadCorruptedSRSFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "srs.bin")
+	writeSRSFile(t, path, testSRS(t), false)
+	corruptSRSFile(t, path, 6)
+
+	_, err := ReadSRSFile(path, ecc.BN254, 0)
+	assert.ErrorIs(t, err, ErrCorruptedSRS)
+	assert.ErrorContains(t, err, "checksum of G1 points 0 to 7 doesn't match the manifest")
+
+	// Without a manifest the points are still checked, whether the corrupted
+	// point is read or not.
+	assert.NoError(t, os.Remove(SRSManifestPath(path)))
+	_, err = ReadSRSFile(path, ecc.BN254, 0)
+	assert.ErrorIs(t, err, ErrCorruptedSRS)
+	_, err = ReadSRSFile(path, ecc.BN254, 6)
+	assert.NoError(t, err)
+}
+
+func TestReadInconsistentSRSFile(t *testing.T) {
+	// Valid points and a matching manifest, but G1 doesn't hold powers of α.
+	path := filepath.Join(t.TempDir(), "srs.bin")
+	srs := testSRS(t)
+	srs.G1[3] = srs.G1[2]
+	writeSRSFile(t, path, srs, false)
+
+	_, err := ReadSRSFile(path, ecc.BN254, 0)
+	assert.ErrorIs(t, err, ErrCorruptedSRS)
+	assert.ErrorContains(t, err, "not consecutive powers")
+}
+
+func TestReadSRSFileChecksItOnce(t *testing.T) {
+	curve := srsCurves[ecc.BN254]
+	checkSRS := curve.checkSRS
+	t.Cleanup(func() { curve.checkSRS = checkSRS })
+	var checkedPoints []int
+	curve.checkSRS = func(srs kzgg.SRS) error {
+		checkedPoints = append(checkedPoints, len(srs.(*kzg.SRS).G1))
+		return checkSRS(srs)
+	}
+
+	path := filepath.Join(t.TempDir(), "srs.bin")
+	writeSRSFile(t, path, testSRS(t), false)
+	for _, size := range []int{5, 4, 5, 0, 8} {
+		_, err := ReadSRSFile(path, ecc.BN254, size)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []int{5, 8}, checkedPoints)
+	manifest, err := readSRSManifest(path, curve)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, manifest.CheckedPoints)
+
+	// A file written again is checked again.
+	writeSRSFile(t, path, testSRS(t), false)
+	_, err = ReadSRSFile(path, ecc.BN254, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{5, 8, 4}, checkedPoints)
+
+	// Checked points only count for the checksums they were checked with.
+	otherSRS, err := kzg.NewSRS(8, big.NewInt(43))
+	assert.NoError(t, err)
+	writeSRSFile(t, path, otherSRS, false)
+	otherManifest, err := readSRSManifest(path, curve)
+	assert.NoError(t, err)
+	otherManifest.CheckedPoints, otherManifest.CheckedDigest = manifest.CheckedPoints, manifest.CheckedDigest
+	serializedManifest, err := json.Marshal(otherManifest)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(SRSManifestPath(path), serializedManifest, 0644))
+	_, err = ReadSRSFile(path, ecc.BN254, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{5, 8, 4, 4}, checkedPoints)
+}
+
+func TestReadAndWriteSRSFileConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "srs.bin")
+	srss := []*kzg.SRS{testSRS(t)}
+	otherSRS, err := kzg.NewSRS(16, big.NewInt(43))
+	assert.NoError(t, err)
+	srss = append(srss, otherSRS)
+	writeSRSFile(t, path, srss[0], false)
+
+	// Readers never see an SRS with the manifest of the other one.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, WriteSRSFile(path, srss[i%2]))
+		}(i)
+		go func() {
+			defer wg.Done()
+			srs, err := ReadSRSFile(path, ecc.BN254, 0)
+			assert.NoError(t, err)
+			assert.Contains(t, []kzgg.SRS{srss[0], srss[1]}, srs)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestReadSRSFileWithMismatchedManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeSRSFile(t, filepath.Join(dir, "small.bin"), testSRS(t), false)
+	largeSRS, err := kzg.NewSRS(16, big.NewInt(42))
+	assert.NoError(t, err)
+	writeSRSFile(t, filepath.Join(dir, "large.bin"), largeSRS, false)
+	assert.NoError(t, os.Rename(SRSManifestPath(filepath.Join(dir, "large.bin")), SRSManifestPath(filepath.Join(dir, "small.bin"))))
+
+	_, err = ReadSRSFile(filepath.Join(dir, "small.bin"), ecc.BN254, 0)
+	assert.ErrorIs(t, err, ErrCorruptedSRS)
+	assert.ErrorContains(t, err, "it has 8 points but its manifest 16")
+}
+
+func TestTryLoadCorruptedSRS(t *testing.T) {
+	userConfigDir := withUserConfigDir(t)
+	path := filepath.Join(userConfigDir, "noir-lang", "srs.bin")
+	writeSRSFile(t, path, testSRS(t), false)
+	corruptSRSFile(t, path, 2)
+
+	_, _, err := TryLoadSRS(ecc.BN254, 8)
+	assert.ErrorIs(t, err, ErrCorruptedSRS)
+	assert.ErrorContains(t, err, "SRS at "+path)
+
+	// A corrupted dev SRS is generated again.
+	SetSRSMode(DevSRS)
+	devSRSPath := filepath.Join(userConfigDir, "noir-lang", "srs.dev.bin")
+	writeSRSFile(t, devSRSPath, testSRS(t), false)
+	corruptSRSFile(t, devSRSPath, 2)
+	srs, _, err := TryLoadSRS(ecc.BN254, 8)
+	assert.NoError(t, err)
+	savedSRS, err := ReadSRSFile(devSRSPath, ecc.BN254, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, srs, savedSRS)
+}
@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// WitnessFormat is the encoding of an exported witness.
+type WitnessFormat string
+
+const (
+	// BinaryWitness is gnark's binary witness protocol:
+	// [uint32(nbPublic) | uint32(nbSecret) | fr.Vector(variables)].
+	BinaryWitness WitnessFormat = "binary"
+	// JSONWitness is a JSON object keyed by the names of the variables.
+	JSONWitness WitnessFormat = "json"
+)
+
+func ParseWitnessFormat(format string) (WitnessFormat, error) {
+	switch WitnessFormat(format) {
+	case BinaryWitness, JSONWitness:
+		return WitnessFormat(format), nil
+	default:
+		return "", fmt.Errorf("unknown witness format %q, expected %q or %q", format, BinaryWitness, JSONWitness)
+	}
+}
+
+// WitnessSchema describes the public and secret variables of a constraint
+// system for gnark's JSON witness encoding. Variables are keyed by the names
+// HandleValues gives them (public_<w> and secret_<w>), which must be unique.
+func WitnessSchema(cs *constraint.System) (*schema.Schema, error) {
+	s := &schema.Schema{NbPublic: len(cs.Public), NbSecret: len(cs.Secret)}
+	seen := make(map[string]bool, len(cs.Public)+len(cs.Secret))
+	addFields := func(names []string, visibility schema.Visibility) error {
+		for _, name := range names {
+			if seen[name] {
+				return fmt.Errorf("variable %q is defined more than once", name)
+			}
+			seen[name] = true
+			s.Fields = append(s.Fields, schema.Field{
+				Name:       fmt.Sprintf("V%d", len(s.Fields)),
+				NameTag:    name,
+				FullName:   name,
+				Visibility: visibility,
+				Type:       schema.Leaf,
+			})
+		}
+		return nil
+	}
+	if err := addFields(cs.Public, schema.Public); err != nil {
+		return nil, err
+	}
+	if err := addFields(cs.Secret, schema.Secret); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// EncodeWitness encodes a full or public witness. The schema is only used by
+// the JSON format.
+func EncodeWitness(w witness.Witness, s *schema.Schema, format WitnessFormat) ([]byte, error) {
+	switch format {
+	case BinaryWitness:
+		return w.MarshalBinary()
+	case JSONWitness:
+		return w.ToJSON(s)
+	default:
+		return nil, fmt.Errorf("unknown witness format %q", format)
+	}
+}
+
+// DecodeWitness decodes a witness encoded by EncodeWitness. A JSON witness
+// without secret values is decoded as a public witness.
+func DecodeWitness(data []byte, s *schema.Schema, format WitnessFormat, scalarField *big.Int) (witness.Witness, error) {
+	w, err := witness.New(scalarField)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case BinaryWitness:
+		err = w.UnmarshalBinary(data)
+	case JSONWitness:
+		err = w.FromJSON(s, data)
+	default:
+		err = fmt.Errorf("unknown witness format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// WitnessValues returns the public and then the secret values of a witness.
+func WitnessValues(w witness.Witness) (felt.Vector, error) {
+	encodedWitness, err := w.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	// [uint32(nbPublic) | uint32(nbSecret) | uint32(nbVariables) | variables]
+	if len(encodedWitness) < 12 {
+		return nil, fmt.Errorf("witness of %d bytes is too short", len(encodedWitness))
+	}
+	nbVariables := int(binary.BigEndian.Uint32(encodedWitness[8:12]))
+	encodedVariables := encodedWitness[12:]
+	if nbVariables == 0 {
+		return felt.Vector{}, nil
+	}
+	if len(encodedVariables)%nbVariables != 0 {
+		return nil, fmt.Errorf("witness of %d variables has %d bytes of values", nbVariables, len(encodedVariables))
+	}
+	elementSize := len(encodedVariables) / nbVariables
+	values := make(felt.Vector, nbVariables)
+	for i := range values {
+		if err := values[i].SetBytes(encodedVariables[i*elementSize : (i+1)*elementSize]); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,152 @@
+package backend
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	"github.com/consensys/gnark/frontend/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWitnessSchema(t *testing.T) {
+	sparseR1CS := cs_bn254.NewSparseR1CS(0)
+	sparseR1CS.AddPublicVariable("public_1")
+	sparseR1CS.AddSecretVariable("secret_2")
+	sparseR1CS.AddSecretVariable("secret_3")
+
+	s, err := WitnessSchema(&sparseR1CS.System)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, s.NbPublic)
+	assert.Equal(t, 2, s.NbSecret)
+	assert.Equal(t, []string{"public_1", "secret_2", "secret_3"}, []string{s.Fields[0].NameTag, s.Fields[1].NameTag, s.Fields[2].NameTag})
+	assert.Equal(t, schema.Public, s.Fields[0].Visibility)
+	assert.Equal(t, schema.Secret, s.Fields[2].Visibility)
+}
+
+func TestWitnessSchemaThrowsErrorDuplicateName(t *testing.T) {
+	sparseR1CS := cs_bn254.NewSparseR1CS(0)
+	sparseR1CS.AddPublicVariable("public_1")
+	sparseR1CS.AddSecretVariable("secret_2")
+	sparseR1CS.AddSecretVariable("secret_2")
+
+	_, err := WitnessSchema(&sparseR1CS.System)
+	assert.ErrorContains(t, err, `variable "secret_2" is defined more than once`)
+}
+
+func TestEncodeAndDecodeWitness(t *testing.T) {
+	sparseR1CS := cs_bn254.NewSparseR1CS(0)
+	sparseR1CS.AddPublicVariable("public_1")
+	sparseR1CS.AddSecretVariable("secret_2")
+	s, err := WitnessSchema(&sparseR1CS.System)
+	assert.NoError(t, err)
+	scalarField := sparseR1CS.CurveID().ScalarField()
+	witness := BuildWitnesses(scalarField, felt.Vector{felt.NewElement(7)}, felt.Vector{felt.NewElement(9)}, 1, 1)
+
+	for _, format := range []WitnessFormat{BinaryWitness, JSONWitness} {
+		encodedWitness, err := EncodeWitness(witness, s, format)
+		assert.NoError(t, err)
+		decodedWitness, err := DecodeWitness(encodedWitness, s, format, scalarField)
+		assert.NoError(t, err)
+		assert.Equal(t, witness.Vector(), decodedWitness.Vector())
+	}
+
+	encodedWitness, err := EncodeWitness(witness, s, JSONWitness)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"public_1":7,"secret_2":9}`, string(encodedWitness))
+}
+
+func TestParseWitnessFormat(t *testing.T) {
+	format, err := ParseWitnessFormat("json")
+	assert.NoError(t, err)
+	assert.Equal(t, JSONWitness, format)
+
+	_, err = ParseWitnessFormat("hex")
+	assert.Error(t, err)
+}
+
+func randomVariables(t testing.TB, scalarField *big.Int, n int) felt.Vector {
+	variables := make(felt.Vector, n)
+	for i := range variables {
+		variable, err := rand.Int(rand.Reader, scalarField)
+		assert.NoError(t, err)
+		variables[i] = felt.FromBigInt(variable)
+	}
+	return variables
+}
+
+func TestNewWitnessMatchesBuildWitnesses(t *testing.T) {
+	for _, curveID := range SupportedCurves {
+		testNewWitnessMatchesBuildWitnesses(t, curveID.ScalarField())
+	}
+}
+
+func testNewWitnessMatchesBuildWitnesses(t *testing.T, scalarField *big.Int) {
+	for _, sizes := range [][2]int{{0, 0}, {0, 3}, {2, 0}, {3, 17}} {
+		publicVariables, secretVariables := randomVariables(t, scalarField, sizes[0]), randomVariables(t, scalarField, sizes[1])
+
+		expectedWitness := BuildWitnesses(scalarField, publicVariables, secretVariables, len(publicVariables), len(secretVariables))
+		witness, err := NewWitness(scalarField, publicVariables, secretVariables)
+		assert.NoError(t, err)
+
+		assert.Equal(t, expectedWitness.Vector(), witness.Vector())
+		expectedEncoding, err := expectedWitness.MarshalBinary()
+		assert.NoError(t, err)
+		encoding, err := witness.MarshalBinary()
+		assert.NoError(t, err)
+		assert.Equal(t, expectedEncoding, encoding)
+
+		expectedPublicWitness, err := expectedWitness.Public()
+		assert.NoError(t, err)
+		publicWitness, err := witness.Public()
+		assert.NoError(t, err)
+		assert.Equal(t, expectedPublicWitness.Vector(), publicWitness.Vector())
+	}
+}
+
+func TestNewWitnessReducesValues(t *testing.T) {
+	for _, curveID := range SupportedCurves {
+		scalarField := curveID.ScalarField()
+		largeValue := felt.FromBigInt(new(big.Int).Add(scalarField, big.NewInt(5)))
+
+		witness, err := NewWitness(scalarField, felt.Vector{largeValue}, felt.Vector{felt.NewElement(5)})
+		assert.NoError(t, err)
+		values, err := WitnessValues(witness)
+		assert.NoError(t, err)
+		assert.Equal(t, felt.Vector{felt.NewElement(5), felt.NewElement(5)}, values)
+	}
+}
+
+var witnessSizes = []int{1 << 10, 1 << 16, 1 << 20}
+
+func BenchmarkBuildWitnesses(b *testing.B) {
+	scalarField := ecc.BN254.ScalarField()
+	for _, size := range witnessSizes {
+		publicVariables, secretVariables := randomVariables(b, scalarField, size/8), randomVariables(b, scalarField, size-size/8)
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				BuildWitnesses(scalarField, publicVariables, secretVariables, len(publicVariables), len(secretVariables))
+			}
+		})
+	}
+}
+
+func BenchmarkNewWitness(b *testing.B) {
+	scalarField := ecc.BN254.ScalarField()
+	for _, size := range witnessSizes {
+		publicVariables, secretVariables := randomVariables(b, scalarField, size/8), randomVariables(b, scalarField, size-size/8)
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := NewWitness(scalarField, publicVariables, secretVariables); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
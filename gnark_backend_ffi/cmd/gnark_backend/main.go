@@ -0,0 +1,238 @@
+// Command gnark_backend exposes parts of the backend that are useful outside
+// of Noir, like reproducing a failing proof offline.
+//
+// Usage:
+//
+//	gnark_backend witness export -acir circuit.json -values values.hex [-curve bn254|bls12_381|bls12_377|bw6_761] [-format binary|json] [-out witness]
+//	gnark_backend witness import -acir circuit.json -witness witness.bin [-curve bn254|bls12_381|bls12_377|bw6_761] [-format binary|json]
+//	gnark_backend srs import (-ptau file.ptau | -ignition transcript00.dat,...) (-size N | -acir circuit.json) [-out srs.bin]
+//	gnark_backend verifier export -vk vk.hex [-curve bn254] [-out Verifier.sol]
+//
+// Circuits are read in any of the formats acir.Decode accepts and values are
+// hex encoded felts, as the Rust side sends them through the FFI. The SRSs of
+// both ceremonies are for BN254. Verifying keys are hex encoded, as
+// PlonkPreprocess returns them, and their Solidity verifiers are for BN254.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"gnark_backend_ffi/acir"
+	"gnark_backend_ffi/backend"
+	plonk_backend "gnark_backend_ffi/backend/plonk"
+	backend_helpers "gnark_backend_ffi/internal/backend"
+	"gnark_backend_ffi/internal/felt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+)
+
+const usage = `usage:
+  gnark_backend witness export -acir FILE -values FILE [-solved FILE] [-curve bn254|bls12_381|bls12_377|bw6_761] [-format binary|json] [-out PREFIX]
+  gnark_backend witness import -acir FILE -witness FILE [-curve bn254|bls12_381|bls12_377|bw6_761] [-format binary|json]
+  gnark_backend srs import (-ptau FILE | -ignition FILE,...) (-size N | -acir FILE) [-out FILE]
+  gnark_backend verifier export -vk FILE [-curve bn254] [-out FILE]
+`
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gnark_backend: ")
+
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] + " " + os.Args[2] {
+	case "witness export":
+		exportWitness(os.Args[3:])
+	case "witness import":
+		importWitness(os.Args[3:])
+	case "srs import":
+		importSRS(os.Args[3:])
+	case "verifier export":
+		exportVerifier(os.Args[3:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// exportWitness writes the full witness to PREFIX.<ext> and the public
+// witness to PREFIX.public.<ext>.
+func exportWitness(args []string) {
+	flags := flag.NewFlagSet("witness export", flag.ExitOnError)
+	acirPath := flags.String("acir", "", "circuit file")
+	valuesPath := flags.String("values", "", "file with the hex encoded values")
+	solvedPath := flags.String("solved", "", "file with the JSON array of the witnesses the ACVM solved, every value if empty")
+	curve := flags.String("curve", ecc.BN254.String(), "curve the circuit is proven on")
+	format := flags.String("format", string(backend.BinaryWitness), "witness format, binary or json")
+	out := flags.String("out", "witness", "prefix of the output files")
+	flags.Parse(args)
+
+	circuit := readCircuit(*acirPath, *curve)
+	encodedValues, err := os.ReadFile(*valuesPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	values := backend_helpers.DeserializeFelts(strings.TrimSpace(string(encodedValues)), circuit.CurveID().ScalarField())
+	var solvedWitnesses []uint32
+	if *solvedPath != "" {
+		encodedSolvedWitnesses, err := os.ReadFile(*solvedPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		solvedWitnesses = backend_helpers.DeserializeWitnesses(string(encodedSolvedWitnesses))
+	}
+	if err := acir.CheckWitnesses(circuit, values, solvedWitnesses); err != nil {
+		log.Fatal(err)
+	}
+	witnessFormat, err := backend.ParseWitnessFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fullWitness, publicWitness, err := plonk_backend.ExportWitness(circuit, values, witnessFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	extension := ".bin"
+	if witnessFormat == backend.JSONWitness {
+		extension = ".json"
+	}
+	if err := os.WriteFile(*out+extension, fullWitness, 0644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out+".public"+extension, publicWitness, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// importWitness prints the values of a witness hex encoded, so they can be
+// passed back to the FFI.
+func importWitness(args []string) {
+	flags := flag.NewFlagSet("witness import", flag.ExitOnError)
+	acirPath := flags.String("acir", "", "circuit file")
+	witnessPath := flags.String("witness", "", "witness file")
+	curve := flags.String("curve", ecc.BN254.String(), "curve the circuit is proven on")
+	format := flags.String("format", string(backend.BinaryWitness), "witness format, binary or json")
+	flags.Parse(args)
+
+	circuit := readCircuit(*acirPath, *curve)
+	witness, err := os.ReadFile(*witnessPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	witnessFormat, err := backend.ParseWitnessFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	values, err := plonk_backend.ImportWitness(circuit, witness, witnessFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(backend_helpers.SerializeFelts(values, circuit.CurveID().ScalarField()))
+}
+
+// importSRS converts the powers of tau of a ceremony into the SRS of the
+// PLONK backend, truncated to the size given or to the size the circuit
+// needs, and writes it to the SRS path or to -out.
+func importSRS(args []string) {
+	flags := flag.NewFlagSet("srs import", flag.ExitOnError)
+	ptauPath := flags.String("ptau", "", "snarkjs .ptau file")
+	ignitionPaths := flags.String("ignition", "", "comma separated Aztec Ignition transcripts, in order")
+	size := flags.Int("size", 0, "number of G1 points")
+	acirPath := flags.String("acir", "", "circuit file to size the SRS for")
+	out := flags.String("out", "", "output file, the SRS path by default")
+	flags.Parse(args)
+
+	if *acirPath != "" {
+		circuit := readCircuit(*acirPath, ecc.BN254.String())
+		sparseR1CS, _, _ := plonk_backend.BuildSparseR1CS(circuit, make(felt.Vector, circuit.CurrentWitness))
+		*size = plonk_backend.SRSSize(sparseR1CS)
+	}
+	if *size == 0 {
+		log.Fatal("either -size or -acir is needed")
+	}
+
+	var srs *kzg.SRS
+	var err error
+	switch {
+	case *ptauPath != "" && *ignitionPaths == "":
+		srs, err = backend.ImportPtau(*ptauPath, *size)
+	case *ignitionPaths != "" && *ptauPath == "":
+		srs, err = backend.ImportIgnition(strings.Split(*ignitionPaths, ","), *size)
+	default:
+		log.Fatal("either -ptau or -ignition is needed")
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	path := *out
+	if path == "" {
+		if path, err = backend.SRSPath(ecc.BN254); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := backend.WriteSRSFile(path, srs); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("SRS of %d points written to %s\n", len(srs.G1), path)
+}
+
+// exportVerifier writes the Solidity verifier of a PLONK verifying key.
+func exportVerifier(args []string) {
+	flags := flag.NewFlagSet("verifier export", flag.ExitOnError)
+	vkPath := flags.String("vk", "", "file with the hex encoded verifying key")
+	curve := flags.String("curve", ecc.BN254.String(), "curve of the verifying key")
+	out := flags.String("out", "PlonkVerifier.sol", "output file")
+	flags.Parse(args)
+
+	curveID, err := backend.ParseCurve(*curve)
+	if err != nil {
+		log.Fatal(err)
+	}
+	encodedVerifyingKey, err := os.ReadFile(*vkPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	keyFingerprint, verifyingKeyHex, err := backend.ExtractKeyFingerprint(strings.TrimSpace(string(encodedVerifyingKey)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	verifyingKey := backend_helpers.DeserializeVerifyingKey(verifyingKeyHex, curveID)
+
+	var verifier bytes.Buffer
+	if err := plonk_backend.ExportSolidity(verifyingKey, keyFingerprint, curveID, &verifier); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, verifier.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s written to %s\n", plonk_backend.SolidityVerifierContract, *out)
+}
+
+func readCircuit(path string, curve string) acir.ACIR {
+	serializedACIR, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	curveID, err := backend.ParseCurve(curve)
+	if err != nil {
+		log.Fatal(err)
+	}
+	circuit, _, err := acir.Decode(serializedACIR, curveID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := acir.Validate(circuit); err != nil {
+		log.Fatal(err)
+	}
+	return circuit
+}
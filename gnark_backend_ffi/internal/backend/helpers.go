@@ -2,34 +2,97 @@ package backend
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"log"
+	"math/big"
+
+	common "gnark_backend_ffi/internal"
+	"gnark_backend_ffi/internal/felt"
 
 	"github.com/consensys/gnark-crypto/ecc"
-	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/backend/plonk"
 )
 
-func DeserializeFelt(encodedFelt string) (felt fr_bn254.Element) {
+// DeserializeFelt decodes a felt of the scalar field of the curve, it must be
+// its canonical encoding on the size of the field as in DeserializeFelts.
+func DeserializeFelt(encodedFelt string, scalarField *big.Int) (f felt.Element, err error) {
 	// Decode the received felt.
 	decodedFelt, err := hex.DecodeString(encodedFelt)
 	if err != nil {
-		log.Fatal(err)
+		return f, fmt.Errorf("felt %q: %w", encodedFelt, err)
+	}
+	// Deserialize the decoded felt.
+	if err = f.SetCanonicalBytes(decodedFelt, scalarField); err != nil {
+		return f, fmt.Errorf("felt %q: %w", encodedFelt, err)
+	}
+	return
+}
+
+// DeserializeUncheckedFelt decodes a felt serialized by Noir, on
+// noirFeltBytes or, for the larger fields, felt.Bytes. It is for JSON
+// circuits, whose curve isn't known while they are unmarshalled:
+// acir.Decode checks their felts with DeserializeFelt once it is.
+func DeserializeUncheckedFelt(encodedFelt string) (f felt.Element, err error) {
+	// Decode the received felt.
+	decodedFelt, err := hex.DecodeString(encodedFelt)
+	if err != nil {
+		return f, fmt.Errorf("felt %q: %w", encodedFelt, err)
+	}
+	if len(decodedFelt) != noirFeltBytes && len(decodedFelt) != felt.Bytes {
+		return f, fmt.Errorf("felt %q of %d bytes, expected %d or %d", encodedFelt, len(decodedFelt), noirFeltBytes, felt.Bytes)
 	}
 	// Deserialize the decoded felt.
-	felt.SetBytes(decodedFelt)
+	err = f.SetBytes(decodedFelt)
 	return
 }
 
-func DeserializeFelts(encodedFelts string) (felts fr_bn254.Vector) {
+// noirFeltBytes is the size Noir serializes field elements on, the one of the
+// scalar fields of BN254 and BLS12-381.
+const noirFeltBytes = 32
+
+// SerializeFelt encodes a felt as the big-endian hex string of its canonical
+// representation, which is how Noir serializes field elements. Felts too
+// large for Noir's size take felt.Bytes.
+func SerializeFelt(f felt.Element) string {
+	if f.BigInt(new(big.Int)).BitLen() > 8*noirFeltBytes {
+		return hex.EncodeToString(f[:])
+	}
+	return hex.EncodeToString(f.Encode(noirFeltBytes))
+}
+
+// DeserializeFelts decodes felts of the scalar field of the curve they belong
+// to. Every felt must be its canonical encoding.
+func DeserializeFelts(encodedFelts string, scalarField *big.Int) felt.Vector {
 	// Decode the received felts.
 	decodedFelts, err := hex.DecodeString(encodedFelts)
 	if err != nil {
 		log.Fatal(err)
 	}
 	// Unpack and deserialize the decoded felts.
-	felts.UnmarshalBinary(decodedFelts)
-	return
+	felts, err := felt.DecodeVector(decodedFelts, scalarField)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return felts
+}
+
+// DeserializeWitnesses decodes the JSON array of the indices of the
+// witnesses the ACVM solved.
+func DeserializeWitnesses(encodedWitnesses string) common.Witnesses {
+	var witnesses common.Witnesses
+	if err := json.Unmarshal([]byte(encodedWitnesses), &witnesses); err != nil {
+		log.Fatal(err)
+	}
+	return witnesses
+}
+
+// SerializeFelts is the inverse of DeserializeFelts, the felts are prefixed
+// by their amount.
+func SerializeFelts(felts felt.Vector, scalarField *big.Int) string {
+	return hex.EncodeToString(felts.Encode(scalarField))
 }
 
 func DeserializeProof(serializedProof string, curveID ecc.ID) (p plonk.Proof) {
@@ -93,25 +156,25 @@ func SerializeVerifyingKey(verifyingKey plonk.VerifyingKey) (vk string) {
 	return
 }
 
-// Samples a felt and returns the encoded felt and the non-encoded felt.
-func RandomEncodedFelt() (string, fr_bn254.Element) {
-	var felt fr_bn254.Element
-	felt.SetRandom()
+// Samples a felt of the scalar field and returns the encoded felt and the
+// non-encoded felt.
+func RandomEncodedFelt(scalarField *big.Int) (string, felt.Element) {
+	randomFelt, err := rand.Int(rand.Reader, scalarField)
+	if err != nil {
+		log.Fatal(err)
+	}
+	f := felt.FromBigInt(randomFelt)
 
-	return hex.EncodeToString(felt.Marshal()), felt
+	return hex.EncodeToString(f.Encode(felt.Size(scalarField))), f
 }
 
-// Samples a felts vector and returns the encoded felts and the non-encoded felts vector.
-func RandomEncodedFelts() (string, fr_bn254.Vector) {
-	var felt1 fr_bn254.Element
-	felt1.SetRandom()
-
-	var felt2 fr_bn254.Element
-	felt2.SetRandom()
-
-	felts := fr_bn254.Vector{felt1, felt2}
+// Samples a felts vector of the scalar field and returns the encoded felts
+// and the non-encoded felts vector.
+func RandomEncodedFelts(scalarField *big.Int) (string, felt.Vector) {
+	_, felt1 := RandomEncodedFelt(scalarField)
+	_, felt2 := RandomEncodedFelt(scalarField)
 
-	binaryFelts, _ := felts.MarshalBinary()
+	felts := felt.Vector{felt1, felt2}
 
-	return hex.EncodeToString(binaryFelts), felts
+	return SerializeFelts(felts, scalarField), felts
 }
@@ -0,0 +1,161 @@
+// Package evm compiles Solidity contracts and runs them in an in-process
+// EVM, go-ethereum's simulated backend, so generated verifiers are tested
+// as they run on chain.
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrNoSolc is returned by Compile when solc isn't in the PATH.
+var ErrNoSolc = errors.New("solc not found in PATH")
+
+// gasLimit is the gas limit of the blocks of the simulated chain, the one of
+// the mainnet.
+const gasLimit = 30_000_000
+
+// Contract is a contract deployed in a simulated chain.
+type Contract struct {
+	Address  common.Address
+	backend  *backends.SimulatedBackend
+	contract *bind.BoundContract
+}
+
+// Compile compiles the contract of the Solidity source with solc, optimized
+// as it would be deployed for the London EVM, and returns its ABI and
+// bytecode.
+func Compile(source string, contract string) (abi.ABI, []byte, error) {
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		return abi.ABI{}, nil, ErrNoSolc
+	}
+	dir, err := os.MkdirTemp("", "evm")
+	if err != nil {
+		return abi.ABI{}, nil, err
+	}
+	defer os.RemoveAll(dir)
+	sourceName := contract + ".sol"
+	if err := os.WriteFile(filepath.Join(dir, sourceName), []byte(source), 0644); err != nil {
+		return abi.ABI{}, nil, err
+	}
+
+	// The simulated chain of go-ethereum 1.10 predates Shanghai and its PUSH0
+	// opcode, which newer solc versions emit by default. The source is
+	// compiled from its directory so the hash of the metadata appended to
+	// the bytecode, which covers its path, doesn't change between runs.
+	cmd := exec.Command(solc, "--optimize", "--evm-version", "london", "--combined-json", "abi,bin", sourceName)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			return abi.ABI{}, nil, fmt.Errorf("solc: %s", exitError.Stderr)
+		}
+		return abi.ABI{}, nil, err
+	}
+	var compiled struct {
+		Contracts map[string]struct {
+			// Older solc versions encode the ABI as a JSON string.
+			ABI json.RawMessage `json:"abi"`
+			Bin string          `json:"bin"`
+		} `json:"contracts"`
+	}
+	if err := json.Unmarshal(output, &compiled); err != nil {
+		return abi.ABI{}, nil, err
+	}
+	for name, compiledContract := range compiled.Contracts {
+		if !strings.HasSuffix(name, ":"+contract) {
+			continue
+		}
+		encodedABI := string(compiledContract.ABI)
+		if strings.HasPrefix(encodedABI, `"`) {
+			if err := json.Unmarshal(compiledContract.ABI, &encodedABI); err != nil {
+				return abi.ABI{}, nil, err
+			}
+		}
+		contractABI, err := abi.JSON(strings.NewReader(encodedABI))
+		if err != nil {
+			return abi.ABI{}, nil, err
+		}
+		return contractABI, common.FromHex(compiledContract.Bin), nil
+	}
+	return abi.ABI{}, nil, fmt.Errorf("solc didn't output contract %s", contract)
+}
+
+// solcVersion matches the version solc --version prints, like
+// 0.8.21+commit.d9974bed.Linux.g++.
+var solcVersion = regexp.MustCompile(`Version: ([0-9]+\.[0-9]+\.[0-9]+\+commit\.[0-9a-f]+)`)
+
+// SolcVersion returns the version and commit of the solc in the PATH, like
+// 0.8.21+commit.d9974bed, without the platform it was built for.
+func SolcVersion() (string, error) {
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		return "", ErrNoSolc
+	}
+	output, err := exec.Command(solc, "--version").Output()
+	if err != nil {
+		return "", err
+	}
+	match := solcVersion.FindSubmatch(output)
+	if match == nil {
+		return "", fmt.Errorf("unexpected solc --version output %q", output)
+	}
+	return string(match[1]), nil
+}
+
+// Deploy deploys a contract in a new simulated chain.
+func Deploy(contractABI abi.ABI, bytecode []byte) (*Contract, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	// A simulated backend always uses chain ID 1337.
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		return nil, err
+	}
+	balance := new(big.Int).Lsh(big.NewInt(1), 100)
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: balance}}, gasLimit)
+	auth.GasLimit = gasLimit
+
+	address, _, contract, err := bind.DeployContract(auth, contractABI, bytecode, backend)
+	if err != nil {
+		return nil, err
+	}
+	backend.Commit()
+	return &Contract{Address: address, backend: backend, contract: contract}, nil
+}
+
+// CallData calls the contract with raw calldata, as an ABI encoded call a
+// client sends, and returns the data it returns.
+func (c *Contract) CallData(calldata []byte) ([]byte, error) {
+	return c.backend.CallContract(context.Background(), ethereum.CallMsg{To: &c.Address, Data: calldata}, nil)
+}
+
+// Call calls a view method of the contract and returns its outputs. A
+// reverted call is an error.
+func (c *Contract) Call(method string, params ...interface{}) ([]interface{}, error) {
+	var outputs []interface{}
+	if err := c.contract.Call(&bind.CallOpts{}, &outputs, method, params...); err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
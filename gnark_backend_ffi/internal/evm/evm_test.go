@@ -0,0 +1,42 @@
+package evm
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// answerBytecode deploys a contract that returns 42 to any call:
+// PUSH1 42 PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN, copied to memory and
+// returned by its constructor.
+const answerBytecode = "600a600c600039600a6000f3" + "602a60005260206000f3"
+
+func TestDeployAndCall(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"answer","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]}]`))
+	assert.NoError(t, err)
+
+	contract, err := Deploy(contractABI, common.FromHex(answerBytecode))
+	assert.NoError(t, err)
+	outputs, err := contract.Call("answer")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{big.NewInt(42)}, outputs)
+
+	_, err = contract.Call("question")
+	assert.Error(t, err)
+
+	output, err := contract.CallData([]byte{1, 2, 3, 4})
+	assert.NoError(t, err)
+	assert.Equal(t, common.LeftPadBytes([]byte{42}, 32), output)
+}
+
+func TestCompileWithoutSolc(t *testing.T) {
+	t.Setenv("PATH", "")
+	_, _, err := Compile("contract C {}", "C")
+	assert.ErrorIs(t, err, ErrNoSolc)
+	_, err = SolcVersion()
+	assert.ErrorIs(t, err, ErrNoSolc)
+}
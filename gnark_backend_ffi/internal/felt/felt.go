@@ -0,0 +1,166 @@
+// Package felt holds field elements independently of the curve they belong
+// to, so circuits and values can be decoded before the curve is known.
+package felt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Bytes is the size of an element: the one of the largest scalar field of
+// the supported curves, BW6-761's. Elements of smaller fields are encoded on
+// the size of their field, see Encode.
+const Bytes = 48
+
+// Element is the canonical value of a field element, big-endian, as Noir
+// serializes it. It is reduced in the scalar field of a curve when it is
+// lowered for that curve.
+type Element [Bytes]byte
+
+// Vector is a list of elements.
+type Vector []Element
+
+// ErrNonCanonical is returned when decoding a value that isn't smaller than
+// the modulus of the field.
+var ErrNonCanonical = errors.New("value is not smaller than the modulus")
+
+// DecodeError is a felt of a vector that couldn't be decoded. Index is its
+// position in the vector.
+type DecodeError struct {
+	Index int
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("felt %d: %v", e.Index, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Size is the size of the canonical encoding of the elements of the field of
+// the modulus.
+func Size(modulus *big.Int) int {
+	return (modulus.BitLen() + 7) / 8
+}
+
+func NewElement(v uint64) (e Element) {
+	binary.BigEndian.PutUint64(e[Bytes-8:], v)
+	return
+}
+
+func One() Element {
+	return NewElement(1)
+}
+
+// FromBigInt returns the element of value v, which must be positive and fit
+// in Bytes bytes.
+func FromBigInt(v *big.Int) (e Element) {
+	v.FillBytes(e[:])
+	return
+}
+
+// SetBytes sets the element to the big-endian value b, which can't be
+// larger than Bytes.
+func (e *Element) SetBytes(b []byte) error {
+	if len(b) > Bytes {
+		return fmt.Errorf("felt of %d bytes, expected at most %d", len(b), Bytes)
+	}
+	*e = Element{}
+	copy(e[Bytes-len(b):], b)
+	return nil
+}
+
+// SetCanonicalBytes sets the element to the big-endian value b, which must
+// be the canonical encoding of an element of the field of the modulus:
+// Size(modulus) bytes of a value smaller than the modulus.
+func (e *Element) SetCanonicalBytes(b []byte, modulus *big.Int) error {
+	if size := Size(modulus); len(b) != size {
+		return fmt.Errorf("felt of %d bytes, expected %d", len(b), size)
+	}
+	var decoded Element
+	if err := decoded.SetBytes(b); err != nil {
+		return err
+	}
+	if !decoded.IsCanonical(modulus) {
+		return ErrNonCanonical
+	}
+	*e = decoded
+	return nil
+}
+
+// IsCanonical reports whether the element is smaller than the modulus, so
+// it is an element of its field and not one to be reduced.
+func (e Element) IsCanonical(modulus *big.Int) bool {
+	return e.BigInt(new(big.Int)).Cmp(modulus) < 0
+}
+
+func (e Element) BigInt(res *big.Int) *big.Int {
+	return res.SetBytes(e[:])
+}
+
+func (e Element) IsZero() bool {
+	return e == Element{}
+}
+
+func (e Element) IsOne() bool {
+	return e == One()
+}
+
+// String returns the value in base 10.
+func (e Element) String() string {
+	return e.BigInt(new(big.Int)).String()
+}
+
+// Encode returns the last size bytes of the element, its encoding in a field
+// of elements of that size. The value must fit in them.
+func (e Element) Encode(size int) []byte {
+	return e[Bytes-size:]
+}
+
+// Encode encodes the vector as gnark-crypto encodes its fr.Vector, with
+// elements of the size of the field of the modulus: its length as a
+// big-endian uint32 followed by the elements.
+func (v Vector) Encode(modulus *big.Int) []byte {
+	size := Size(modulus)
+	data := make([]byte, 4, 4+len(v)*size)
+	binary.BigEndian.PutUint32(data, uint32(len(v)))
+	for i := range v {
+		data = append(data, v[i].Encode(size)...)
+	}
+	return data
+}
+
+// DecodeVector decodes a vector encoded by Vector.Encode. Every element must
+// be canonical in the field of the modulus, the first one that isn't, or
+// that is truncated, is reported as a DecodeError.
+func DecodeVector(data []byte, modulus *big.Int) (Vector, error) {
+	size := Size(modulus)
+	if size > Bytes {
+		return nil, fmt.Errorf("felts of %d bytes, expected at most %d", size, Bytes)
+	}
+	if len(data) < 4 {
+		return nil, errors.New("felts are missing their length")
+	}
+	n := int(binary.BigEndian.Uint32(data))
+	data = data[4:]
+	if len(data) < n*size {
+		return nil, &DecodeError{
+			Index: len(data) / size,
+			Err:   fmt.Errorf("truncated, %d felts of %d bytes expected, got %d bytes", n, size, len(data)),
+		}
+	}
+	if len(data) > n*size {
+		return nil, fmt.Errorf("%d trailing bytes after %d felts of %d bytes", len(data)-n*size, n, size)
+	}
+	v := make(Vector, n)
+	for i := range v {
+		if err := v[i].SetCanonicalBytes(data[i*size:(i+1)*size], modulus); err != nil {
+			return nil, &DecodeError{Index: i, Err: err}
+		}
+	}
+	return v, nil
+}
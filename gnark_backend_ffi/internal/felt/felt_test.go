@@ -0,0 +1,68 @@
+package felt
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVectorEncode(t *testing.T) {
+	// The sizes of the scalar fields of BN254 and BW6-761.
+	for _, modulus := range []*big.Int{new(big.Int).Lsh(big.NewInt(1), 254), new(big.Int).Lsh(big.NewInt(1), 377)} {
+		v := Vector{NewElement(1), FromBigInt(new(big.Int).Lsh(big.NewInt(1), 250))}
+		data := v.Encode(modulus)
+		assert.Len(t, data, 4+2*Size(modulus))
+
+		decoded, err := DecodeVector(data, modulus)
+		assert.NoError(t, err)
+		assert.Equal(t, v, decoded)
+
+		_, err = DecodeVector(data[:len(data)-1], modulus)
+		var decodeError *DecodeError
+		assert.ErrorAs(t, err, &decodeError)
+		assert.Equal(t, 1, decodeError.Index)
+
+		_, err = DecodeVector(append(data, 0), modulus)
+		assert.ErrorContains(t, err, "1 trailing bytes after 2 felts")
+	}
+
+	_, err := DecodeVector(Vector{}.Encode(big.NewInt(1)), new(big.Int).Lsh(big.NewInt(1), 8*Bytes))
+	assert.Error(t, err)
+}
+
+func TestDecodeVectorRejectsNonCanonicalFelts(t *testing.T) {
+	modulus := big.NewInt(251)
+	data := Vector{NewElement(250), NewElement(251), NewElement(252)}.Encode(modulus)
+
+	_, err := DecodeVector(data, modulus)
+	var decodeError *DecodeError
+	assert.ErrorAs(t, err, &decodeError)
+	assert.Equal(t, 1, decodeError.Index)
+	assert.ErrorIs(t, err, ErrNonCanonical)
+	assert.EqualError(t, err, "felt 1: value is not smaller than the modulus")
+}
+
+func TestSetCanonicalBytes(t *testing.T) {
+	modulus := big.NewInt(0x1234)
+	var e Element
+	assert.NoError(t, e.SetCanonicalBytes([]byte{0x12, 0x33}, modulus))
+	assert.Equal(t, NewElement(0x1233), e)
+	assert.True(t, e.IsCanonical(modulus))
+
+	assert.ErrorIs(t, e.SetCanonicalBytes([]byte{0x12, 0x34}, modulus), ErrNonCanonical)
+	assert.Error(t, e.SetCanonicalBytes([]byte{0x12}, modulus))
+	assert.Error(t, e.SetCanonicalBytes([]byte{0, 0x12, 0x33}, modulus))
+	// A failed decoding leaves the element unchanged.
+	assert.Equal(t, NewElement(0x1233), e)
+	assert.False(t, NewElement(0x1234).IsCanonical(modulus))
+}
+
+func TestElement(t *testing.T) {
+	var e Element
+	assert.True(t, e.IsZero())
+	assert.NoError(t, e.SetBytes([]byte{1}))
+	assert.True(t, e.IsOne())
+	assert.Equal(t, "1", e.String())
+	assert.Error(t, e.SetBytes(make([]byte, Bytes+1)))
+}
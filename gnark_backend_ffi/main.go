@@ -3,16 +3,20 @@ package main
 import "C"
 import (
 	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math/big"
+	"os"
+	"strings"
 
 	"gnark_backend_ffi/acir"
 	"gnark_backend_ffi/backend"
 	plonk_backend "gnark_backend_ffi/backend/plonk"
 	backend_helpers "gnark_backend_ffi/internal/backend"
+	"gnark_backend_ffi/internal/felt"
 
-	"github.com/consensys/gnark-crypto/ecc"
 	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
 	"github.com/consensys/gnark/backend/groth16"
@@ -21,17 +25,31 @@ import (
 	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
 )
 
+// The exports take the curve the circuit is proven on by the name
+// ecc.ID.String gives it: bn254, bls12_381, bls12_377 or bw6_761.
+
+// PlonkProveWithPK proves the circuit for the values of every witness. The
+// witnesses the ACVM didn't solve have a 0 value, encodedSolvedWitnesses is
+// the JSON array of the ones it solved.
+//
 //export PlonkProveWithPK
-func PlonkProveWithPK(acirJSON string, encodedValues string, encodedProvingKey string) *C.char {
-	var circuit acir.ACIR
-	err := json.Unmarshal([]byte(acirJSON), &circuit)
+func PlonkProveWithPK(serializedACIR string, encodedValues string, encodedSolvedWitnesses string, encodedProvingKey string, curve string) *C.char {
+	circuit := decodeCircuit(serializedACIR, curve)
+	if err := acir.Validate(circuit); err != nil {
+		log.Fatal(err)
+	}
+	values := backend_helpers.DeserializeFelts(encodedValues, circuit.CurveID().ScalarField())
+	solvedWitnesses := backend_helpers.DeserializeWitnesses(encodedSolvedWitnesses)
+	if err := acir.CheckWitnesses(circuit, values, solvedWitnesses); err != nil {
+		log.Fatal(err)
+	}
+	keyFingerprint, encodedProvingKey, err := backend.ExtractKeyFingerprint(encodedProvingKey)
 	if err != nil {
 		log.Fatal(err)
 	}
-	values := backend_helpers.DeserializeFelts(encodedValues)
-	provingKey := backend_helpers.DeserializeProvingKey(encodedProvingKey, ecc.BN254)
+	provingKey := backend_helpers.DeserializeProvingKey(encodedProvingKey, circuit.CurveID())
 
-	proof := plonk_backend.ProveWithPK(circuit, provingKey, values, ecc.BN254)
+	proof := plonk_backend.ProveWithPK(circuit, provingKey, keyFingerprint, values, circuit.CurveID())
 
 	return C.CString(backend_helpers.SerializeProof(proof))
 }
@@ -42,44 +60,216 @@ func PlonkVerifyWithMeta(acirJSON string, encodedValues string, encodedProof str
 }
 
 //export PlonkVerifyWithVK
-func PlonkVerifyWithVK(acirJSON string, encodedProof string, encodedPublicInputs string, encodedVerifyingKey string) bool {
-	var circuit acir.ACIR
-	err := json.Unmarshal([]byte(acirJSON), &circuit)
+func PlonkVerifyWithVK(serializedACIR string, encodedProof string, encodedPublicInputs string, encodedVerifyingKey string, curve string) bool {
+	circuit := decodeCircuit(serializedACIR, curve)
+	if err := acir.Validate(circuit); err != nil {
+		log.Fatal(err)
+	}
+	proof := backend_helpers.DeserializeProof(encodedProof, circuit.CurveID())
+	publicInputs := backend_helpers.DeserializeFelts(encodedPublicInputs, circuit.CurveID().ScalarField())
+	keyFingerprint, encodedVerifyingKey, err := backend.ExtractKeyFingerprint(encodedVerifyingKey)
 	if err != nil {
 		log.Fatal(err)
 	}
-	proof := backend_helpers.DeserializeProof(encodedProof, ecc.BN254)
-	publicInputs := backend_helpers.DeserializeFelts(encodedPublicInputs)
-	verifyingKey := backend_helpers.DeserializeVerifyingKey(encodedVerifyingKey, ecc.BN254)
+	verifyingKey := backend_helpers.DeserializeVerifyingKey(encodedVerifyingKey, circuit.CurveID())
 
-	return plonk_backend.VerifyWithVK(circuit, verifyingKey, proof, publicInputs, ecc.BN254)
+	return plonk_backend.VerifyWithVK(circuit, verifyingKey, keyFingerprint, proof, publicInputs, circuit.CurveID())
 }
 
-//export PlonkPreprocess
-func PlonkPreprocess(acirJSON string, encodedRandomValues string) (*C.char, *C.char) {
-	// Deserialize ACIR.
-	var acir acir.ACIR
-	err := json.Unmarshal([]byte(acirJSON), &acir)
+// PlonkVerify verifies a proof with only its verifying key: the public
+// inputs are the values of the public witnesses ordered by increasing
+// witness index, as PlonkImportWitness returns them for a public witness.
+//
+//export PlonkVerify
+func PlonkVerify(encodedProof string, encodedPublicInputs string, encodedVerifyingKey string, curve string) bool {
+	curveID, err := backend.ParseCurve(curve)
+	if err != nil {
+		log.Fatal(err)
+	}
+	proof := backend_helpers.DeserializeProof(encodedProof, curveID)
+	publicInputs := backend_helpers.DeserializeFelts(encodedPublicInputs, curveID.ScalarField())
+	keyFingerprint, encodedVerifyingKey, err := backend.ExtractKeyFingerprint(encodedVerifyingKey)
 	if err != nil {
 		log.Fatal(err)
 	}
+	verifyingKey := backend_helpers.DeserializeVerifyingKey(encodedVerifyingKey, curveID)
+
+	return plonk_backend.Verify(verifyingKey, keyFingerprint, proof, publicInputs, curveID)
+}
+
+//export PlonkPreprocess
+func PlonkPreprocess(serializedACIR string, encodedRandomValues string, curve string) (*C.char, *C.char) {
+	// Deserialize ACIR. It could come either as JSON or in a binary format.
+	circuit := decodeCircuit(serializedACIR, curve)
+	if err := acir.Validate(circuit); err != nil {
+		log.Fatal(err)
+	}
 	// TODO: Fix this in the Rust backend side. We should not receive a JSON.
 	// Decode values.
 	var valuesToDecode string
-	err = json.Unmarshal([]byte(encodedRandomValues), &valuesToDecode)
+	err := json.Unmarshal([]byte(encodedRandomValues), &valuesToDecode)
 	if err != nil {
 		log.Fatal(err)
 	}
-	decodedRandomValues := backend_helpers.DeserializeFelts(valuesToDecode)
+	decodedRandomValues := backend_helpers.DeserializeFelts(valuesToDecode, circuit.CurveID().ScalarField())
+
+	provingKey, verifyingKey, fingerprint := plonk_backend.Preprocess(circuit, decodedRandomValues)
 
-	provingKey, verifyingKey := plonk_backend.Preprocess(acir, decodedRandomValues)
+	// Both keys carry the circuit fingerprint so they can't be used with
+	// another circuit.
+	encodedProvingKey := fingerprint.EmbedInKey(backend_helpers.SerializeProvingKey(provingKey))
+	encodedVerifyingKey := fingerprint.EmbedInKey(backend_helpers.SerializeVerifyingKey(verifyingKey))
 
-	return C.CString(backend_helpers.SerializeProvingKey(provingKey)), C.CString(backend_helpers.SerializeVerifyingKey(verifyingKey))
+	return C.CString(encodedProvingKey), C.CString(encodedVerifyingKey)
+}
+
+// PlonkExportWitness returns the full and the public witness of the circuit
+// for the values. Binary witnesses are hex encoded.
+//
+//export PlonkExportWitness
+func PlonkExportWitness(serializedACIR string, encodedValues string, format string, curve string) (*C.char, *C.char) {
+	circuit := decodeCircuit(serializedACIR, curve)
+	if err := acir.Validate(circuit); err != nil {
+		log.Fatal(err)
+	}
+	values := backend_helpers.DeserializeFelts(encodedValues, circuit.CurveID().ScalarField())
+	if err := acir.CheckWitnesses(circuit, values, nil); err != nil {
+		log.Fatal(err)
+	}
+	witnessFormat, err := backend.ParseWitnessFormat(format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fullWitness, publicWitness, err := plonk_backend.ExportWitness(circuit, values, witnessFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return C.CString(encodeWitness(fullWitness, witnessFormat)), C.CString(encodeWitness(publicWitness, witnessFormat))
+}
+
+// PlonkImportWitness reads a witness exported by PlonkExportWitness back into
+// encoded values: every value for a full witness, which PlonkProveWithPK
+// takes, and the public inputs for a public witness, which PlonkVerify
+// takes.
+//
+//export PlonkImportWitness
+func PlonkImportWitness(serializedACIR string, encodedWitness string, format string, curve string) *C.char {
+	circuit := decodeCircuit(serializedACIR, curve)
+	if err := acir.Validate(circuit); err != nil {
+		log.Fatal(err)
+	}
+	witnessFormat, err := backend.ParseWitnessFormat(format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	witness := []byte(encodedWitness)
+	if witnessFormat == backend.BinaryWitness {
+		if witness, err = hex.DecodeString(encodedWitness); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	values, err := plonk_backend.ImportWitness(circuit, witness, witnessFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return C.CString(backend_helpers.SerializeFelts(values, circuit.CurveID().ScalarField()))
+}
+
+// PlonkExportSolidityVerifier returns the Solidity contract that verifies on
+// chain the proofs of a verifying key from PlonkPreprocess. Only BN254 keys
+// are supported.
+//
+//export PlonkExportSolidityVerifier
+func PlonkExportSolidityVerifier(encodedVerifyingKey string, curve string) *C.char {
+	curveID, err := backend.ParseCurve(curve)
+	if err != nil {
+		log.Fatal(err)
+	}
+	keyFingerprint, encodedVerifyingKey, err := backend.ExtractKeyFingerprint(encodedVerifyingKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	verifyingKey := backend_helpers.DeserializeVerifyingKey(encodedVerifyingKey, curveID)
+
+	var verifier strings.Builder
+	if err := plonk_backend.ExportSolidity(verifyingKey, keyFingerprint, curveID, &verifier); err != nil {
+		log.Fatal(err)
+	}
+
+	return C.CString(verifier.String())
+}
+
+// PlonkSolidityCalldata returns the hex encoded calldata of a call to the
+// verifier PlonkExportSolidityVerifier returns that verifies the proof. The
+// public inputs are given as PlonkVerifyWithVK takes them.
+//
+//export PlonkSolidityCalldata
+func PlonkSolidityCalldata(serializedACIR string, encodedProof string, encodedPublicInputs string, curve string) *C.char {
+	circuit := decodeCircuit(serializedACIR, curve)
+	if err := acir.Validate(circuit); err != nil {
+		log.Fatal(err)
+	}
+	proof := backend_helpers.DeserializeProof(encodedProof, circuit.CurveID())
+	publicInputs := backend_helpers.DeserializeFelts(encodedPublicInputs, circuit.CurveID().ScalarField())
+
+	calldata, err := plonk_backend.SolidityCalldata(circuit, proof, publicInputs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return C.CString(hex.EncodeToString(calldata))
+}
+
+// SetSRSPath sets where the SRS is loaded from and saved to, overriding the
+// GNARK_BACKEND_SRS_PATH environment variable.
+//
+//export SetSRSPath
+func SetSRSPath(path string) {
+	// Go strings passed through the FFI point to memory owned by the caller.
+	backend.SetSRSPath(strings.Clone(path))
+}
+
+// SetSRSMode sets the SRS mode, "trusted" or "dev", overriding the
+// GNARK_BACKEND_SRS_MODE environment variable.
+//
+//export SetSRSMode
+func SetSRSMode(mode string) {
+	srsMode, err := backend.ParseSRSMode(mode)
+	if err != nil {
+		log.Fatal(err)
+	}
+	backend.SetSRSMode(srsMode)
+}
+
+// decodeCircuit decodes the ACIR, which could come either as JSON or in a
+// binary format, of a circuit proven on the curve.
+func decodeCircuit(serializedACIR string, curve string) acir.ACIR {
+	curveID, err := backend.ParseCurve(curve)
+	if err != nil {
+		log.Fatal(err)
+	}
+	circuit, _, err := acir.Decode([]byte(serializedACIR), curveID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return circuit
+}
+
+// C strings can't hold binary witnesses so they are hex encoded.
+func encodeWitness(witness []byte, format backend.WitnessFormat) string {
+	if format == backend.BinaryWitness {
+		return hex.EncodeToString(witness)
+	}
+	return string(witness)
 }
 
 func ExampleSimpleCircuit() {
-	publicVariables := []fr_bn254.Element{fr_bn254.NewElement(2), fr_bn254.NewElement(6)}
-	secretVariables := []fr_bn254.Element{fr_bn254.NewElement(3)}
+	publicVariables := felt.Vector{felt.NewElement(2), felt.NewElement(6)}
+	secretVariables := felt.Vector{felt.NewElement(3)}
 
 	/* R1CS Building */
 
@@ -126,7 +316,10 @@ func ExampleSimpleCircuit() {
 
 	fmt.Println("Proving...")
 
-	witness := backend.BuildWitnesses(r1cs.CurveID().ScalarField(), publicVariables, secretVariables, r1cs.GetNbPublicVariables()-1, r1cs.GetNbSecretVariables())
+	witness, err := backend.NewWitness(r1cs.CurveID().ScalarField(), publicVariables, secretVariables)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	p, _ := groth16.Prove(r1cs, pk, witness)
 
@@ -143,7 +336,7 @@ func ExampleSimpleCircuit() {
 	fmt.Println("Verifies:", verifies == nil)
 }
 
-func PlonkExample(acirJSON string, values fr_bn254.Vector) {
+func PlonkExample(acirJSON string, values felt.Vector) {
 	fmt.Println("Deserializing ACIR...")
 	var a acir.ACIR
 	err := json.Unmarshal([]byte(acirJSON), &a)
@@ -151,6 +344,10 @@ func PlonkExample(acirJSON string, values fr_bn254.Vector) {
 		log.Fatal(err)
 	}
 	fmt.Println("ACIR deserialized.")
+	err = acir.Fprint(os.Stdout, a)
+	if err != nil {
+		log.Fatal(err)
+	}
 	fmt.Println()
 
 	fmt.Println("Building Sparse R1CS...")
@@ -164,7 +361,10 @@ func PlonkExample(acirJSON string, values fr_bn254.Vector) {
 	fmt.Println()
 
 	fmt.Println("Building witness...")
-	witness := backend.BuildWitnesses(sparseR1CS.CurveID().ScalarField(), publicVariables, secretVariables, sparseR1CS.GetNbPublicVariables(), sparseR1CS.GetNbSecretVariables())
+	witness, err := backend.NewWitness(sparseR1CS.CurveID().ScalarField(), publicVariables, secretVariables)
+	if err != nil {
+		log.Fatal(err)
+	}
 	fmt.Println("Witness built.")
 	fmt.Println()
 
@@ -194,11 +394,11 @@ func PlonkExample(acirJSON string, values fr_bn254.Vector) {
 
 	fmt.Println("Verifying proof...")
 	publicWitnesses, err := witness.Public()
-	fmt.Println("Public witness:", publicWitnesses.Vector().(fr_bn254.Vector).String())
-	fmt.Println("Witness:", witness.Vector().(fr_bn254.Vector).String())
 	if err != nil {
 		log.Fatal(err)
 	}
+	fmt.Println("Public witness:", publicWitnesses.Vector().(fr_bn254.Vector).String())
+	fmt.Println("Witness:", witness.Vector().(fr_bn254.Vector).String())
 	verifies := plonk.Verify(proof, vk, publicWitnesses)
 	fmt.Println("Verifies with valid public inputs:", verifies == nil)
 	fmt.Println()
@@ -221,28 +421,29 @@ func PlonkExample(acirJSON string, values fr_bn254.Vector) {
 }
 
 func main() {
-	zero := fr_bn254.NewElement(0)
-	one := fr_bn254.One()
-	two := fr_bn254.NewElement(2)
-	three := fr_bn254.NewElement(3)
+	zero := felt.NewElement(0)
+	one := felt.One()
+	two := felt.NewElement(2)
+	three := felt.NewElement(3)
 	var minusOne fr_bn254.Element
-	minusOne.Sub(&zero, &one)
+	minusOne.SetInt64(-1)
+	minusOneFelt := felt.FromBigInt(minusOne.BigInt(new(big.Int)))
 
 	// 0 != 1
 	PlonkExample(
 		`{"current_witness_index":6,"opcodes":[{"Arithmetic":{"mul_terms":[],"linear_combinations":[["0000000000000000000000000000000000000000000000000000000000000001",1],["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",2],["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",3]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},{"Directive":{"Invert":{"x":3,"result":4}}},{"Arithmetic":{"mul_terms":[["0000000000000000000000000000000000000000000000000000000000000001",3,4]],"linear_combinations":[["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",5]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},{"Arithmetic":{"mul_terms":[["0000000000000000000000000000000000000000000000000000000000000001",3,5]],"linear_combinations":[["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",3]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},{"Arithmetic":{"mul_terms":[],"linear_combinations":[["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",5]],"q_c":"0000000000000000000000000000000000000000000000000000000000000001"}}],"public_inputs":[2]}`,
-		fr_bn254.Vector{zero, one, minusOne, minusOne, one, zero},
+		felt.Vector{zero, one, minusOneFelt, minusOneFelt, one, zero},
 	)
 
 	// 2 == 2
 	PlonkExample(
 		`{"current_witness_index":6,"opcodes":[{"Arithmetic":{"mul_terms":[],"linear_combinations":[["0000000000000000000000000000000000000000000000000000000000000001",1],["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",2],["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",3]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},{"Directive":{"Invert":{"x":3,"result":4}}},{"Arithmetic":{"mul_terms":[["0000000000000000000000000000000000000000000000000000000000000001",3,4]],"linear_combinations":[["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",5]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},{"Arithmetic":{"mul_terms":[["0000000000000000000000000000000000000000000000000000000000000001",3,5]],"linear_combinations":[["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",3]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},{"Arithmetic":{"mul_terms":[],"linear_combinations":[["0000000000000000000000000000000000000000000000000000000000000001",5]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}}],"public_inputs":[2]}`,
-		fr_bn254.Vector{two, two, zero, zero, zero, zero},
+		felt.Vector{two, two, zero, zero, zero, zero},
 	)
 
 	// 3 == 3 (no public)
 	PlonkExample(
 		`{"current_witness_index":6,"opcodes":[{"Arithmetic":{"linear_combinations":[["0000000000000000000000000000000000000000000000000000000000000001",1],["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",2],["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",3]],"mul_terms":[],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},{"Directive":{"Invert":{"result":4,"x":3}}},{"Arithmetic":{"linear_combinations":[["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",5]],"mul_terms":[["0000000000000000000000000000000000000000000000000000000000000001",3,4]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},{"Arithmetic":{"linear_combinations":[["30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000000",3]],"mul_terms":[["0000000000000000000000000000000000000000000000000000000000000001",3,5]],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}},{"Arithmetic":{"linear_combinations":[["0000000000000000000000000000000000000000000000000000000000000001",5]],"mul_terms":[],"q_c":"0000000000000000000000000000000000000000000000000000000000000000"}}],"public_inputs":[]}`,
-		fr_bn254.Vector{three, three, zero, zero, zero, zero},
+		felt.Vector{three, three, zero, zero, zero, zero},
 	)
 }
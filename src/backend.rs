@@ -89,8 +89,14 @@ impl ProofSystemCompiler for Gnark {
         proving_key: &[u8],
     ) -> Vec<u8> {
         // TODO: modify gnark serializer to accept the BTreeMap
+        // Unsolved witnesses get a 0 value, the backend checks the ones that
+        // must have been solved against this list.
+        let solved_witnesses: Vec<u32> = witness_values
+            .keys()
+            .map(|witness| witness.witness_index())
+            .collect();
         let values = get_values_from_witness_tree(circuit.num_vars(), witness_values);
-        gnark_backend::prove_with_pk(circuit, values, proving_key).unwrap()
+        gnark_backend::prove_with_pk(circuit, values, &solved_witnesses, proving_key).unwrap()
     }
 
     fn verify_with_vk(
@@ -45,6 +45,7 @@ pub fn prove_with_meta(
 pub fn prove_with_pk(
     circuit: &acvm::Circuit,
     values: Vec<acvm::FieldElement>,
+    _solved_witnesses: &[u32],
     proving_key: &[u8],
 ) -> Result<Vec<u8>, GnarkBackendError> {
     let rawr1cs = RawR1CS::new(circuit.clone(), values)?;
@@ -14,6 +14,9 @@ cfg_if::cfg_if! {
     if #[cfg(feature = "bn254")] {
         pub use ark_bn254::{Bn254 as Curve, Fr};
 
+        // Name of the curve for the Go backend
+        pub const CURVE: &str = "bn254";
+
         // Converts a FieldElement to a Fr
         // noir_field uses arkworks for bn254
         pub fn from_felt(felt: acvm::FieldElement) -> Fr {
@@ -22,9 +25,12 @@ cfg_if::cfg_if! {
     } else if #[cfg(feature = "bls12_381")] {
         pub use ark_bls12_381::{Bls12_381 as Curve, Fr};
 
+        // Name of the curve for the Go backend
+        pub const CURVE: &str = "bls12_381";
+
         // Converts a FieldElement to a Fr
         // noir_field uses arkworks for bls12_381
-        pub fn from_felt(felt: FieldElement) -> Fr {
+        pub fn from_felt(felt: acvm::FieldElement) -> Fr {
             felt.into_repr()
         }
     } else {
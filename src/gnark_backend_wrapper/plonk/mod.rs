@@ -1,5 +1,5 @@
 use super::serialize;
-use super::{from_felt, num_constraints, serialize::serialize_felts};
+use super::{from_felt, num_constraints, serialize::serialize_felts, CURVE};
 use crate::acvm;
 use crate::gnark_backend_wrapper::c_go_structures::{GoString, KeyPair};
 use crate::gnark_backend_wrapper::errors::GnarkBackendError;
@@ -15,13 +15,17 @@ extern "C" {
         proof: GoString,
         public_inputs: GoString,
         verifying_key: GoString,
+        curve: GoString,
     ) -> c_uchar;
     fn PlonkProveWithPK(
         acir: GoString,
         encoded_values: GoString,
+        solved_witnesses: GoString,
         proving_key: GoString,
+        curve: GoString,
     ) -> *const c_char;
-    fn PlonkPreprocess(acir: GoString, encoded_random_values: GoString) -> KeyPair;
+    fn PlonkPreprocess(acir: GoString, encoded_random_values: GoString, curve: GoString)
+        -> KeyPair;
 }
 
 pub fn prove_with_meta(
@@ -59,6 +63,7 @@ pub fn prove_with_meta(
 pub fn prove_with_pk(
     circuit: &acvm::Circuit,
     values: Vec<acvm::FieldElement>,
+    solved_witnesses: &[u32],
     proving_key: &[u8],
 ) -> Result<Vec<u8>, GnarkBackendError> {
     // Serialize to json and then convert to GoString
@@ -74,14 +79,30 @@ pub fn prove_with_pk(
         .map_err(|e| GnarkBackendError::SerializeCircuitError(e.to_string()))?;
     let values_go_string = GoString::try_from(&felts_c_str)?;
 
+    let solved_witnesses_json = serde_json::to_string(solved_witnesses)
+        .map_err(|e| GnarkBackendError::SerializeFeltsError(e.to_string()))?;
+    let solved_witnesses_c_str = CString::new(solved_witnesses_json)
+        .map_err(|e| GnarkBackendError::SerializeFeltsError(e.to_string()))?;
+    let solved_witnesses_go_string = GoString::try_from(&solved_witnesses_c_str)?;
+
     let proving_key_serialized = hex::encode(proving_key);
     let proving_key_c_str = CString::new(proving_key_serialized)
         .map_err(|e| GnarkBackendError::SerializeKeyError(e.to_string()))?;
     let proving_key_go_string = GoString::try_from(&proving_key_c_str)
         .map_err(|e| GnarkBackendError::SerializeKeyError(e.to_string()))?;
 
-    let proof: *const c_char =
-        unsafe { PlonkProveWithPK(acir_go_string, values_go_string, proving_key_go_string) };
+    let curve_c_str = CString::new(CURVE).map_err(|e| GnarkBackendError::Error(e.to_string()))?;
+    let curve_go_string = GoString::try_from(&curve_c_str)?;
+
+    let proof: *const c_char = unsafe {
+        PlonkProveWithPK(
+            acir_go_string,
+            values_go_string,
+            solved_witnesses_go_string,
+            proving_key_go_string,
+            curve_go_string,
+        )
+    };
     let proof_c_str = unsafe { CStr::from_ptr(proof) };
     let proof_str = proof_c_str
         .to_str()
@@ -158,12 +179,16 @@ pub fn verify_with_vk(
         .map_err(|e| GnarkBackendError::SerializeKeyError(e.to_string()))?;
     let verifying_key_go_string = GoString::try_from(&verifying_key_c_str)?;
 
+    let curve_c_str = CString::new(CURVE).map_err(|e| GnarkBackendError::Error(e.to_string()))?;
+    let curve_go_string = GoString::try_from(&curve_c_str)?;
+
     let verifies = unsafe {
         PlonkVerifyWithVK(
             acir_go_string,
             proof_go_string,
             public_inputs_go_string,
             verifying_key_go_string,
+            curve_go_string,
         )
     };
     match verifies {
@@ -204,7 +229,11 @@ pub fn preprocess(circuit: &acvm::Circuit) -> Result<(Vec<u8>, Vec<u8>), GnarkBa
         .map_err(|e| GnarkBackendError::SerializeCircuitError(e.to_string()))?;
     let random_values_go_string = GoString::try_from(&random_values_c_str)?;
 
-    let key_pair: KeyPair = unsafe { PlonkPreprocess(acir_go_string, random_values_go_string) };
+    let curve_c_str = CString::new(CURVE).map_err(|e| GnarkBackendError::Error(e.to_string()))?;
+    let curve_go_string = GoString::try_from(&curve_c_str)?;
+
+    let key_pair: KeyPair =
+        unsafe { PlonkPreprocess(acir_go_string, random_values_go_string, curve_go_string) };
 
     let proving_key_c_str = unsafe { CStr::from_ptr(key_pair.proving_key) };
     let proving_key_str = proving_key_c_str
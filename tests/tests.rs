@@ -108,6 +108,7 @@ fn test_integration() {
         "bool_or",
         "cast_bool",
         "comptime_recursion_regression",
+        "dynamic_index",
         "generics",
         "global_consts",
         "main_bool_arg",